@@ -0,0 +1,78 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/format"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Escapes the characters that Slack treats as control characters in message text.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (w *Watcher) run(action Action, alert *Alert) error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.actionTimeout)
+	defer cancel()
+	// marshalling plain fields cannot fail
+	alertJson, _ := json.Marshal(alert)
+	switch action.Type {
+	case WebhookAction:
+		return w.post(ctx, action, alertJson)
+	case SlackAction:
+		message, _ := json.Marshal(map[string]string{"text": slackText(alert)})
+		return w.post(ctx, action, message)
+	default:
+		return runCommand(ctx, action.Command, alert, alertJson)
+	}
+}
+
+func (w *Watcher) post(ctx context.Context, action Action, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, action.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range action.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// the body is read so that the connection can be reused
+	_, _ = ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", action.Url, resp.StatusCode)
+	}
+	return nil
+}
+
+// Formats the alert as the text of a Slack message, with the sample as a code block.
+func slackText(alert *Alert) string {
+	return fmt.Sprintf(":rotating_light: *%s*\n```%s```", slackEscaper.Replace(alert.Text()), slackEscaper.Replace(format.Cut(alert.Sample, maxSampleLength)))
+}
+
+// Runs the passed command with the alert as JSON in its standard input, and its main fields in FOREST_ALERT_* environment variables.
+func runCommand(ctx context.Context, command []string, alert *Alert, alertJson []byte) error {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(alertJson)
+	cmd.Env = append(os.Environ(),
+		"FOREST_ALERT_RULE="+alert.Rule,
+		"FOREST_ALERT_COUNT="+strconv.Itoa(alert.Count),
+		"FOREST_ALERT_TEXT="+alert.Text())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if output = bytes.TrimSpace(output); len(output) > 0 {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return err
+	}
+	return nil
+}
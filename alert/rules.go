@@ -0,0 +1,210 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/filter"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/hanoch-jfrog/forest/util"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"time"
+)
+
+const (
+	WebhookAction = "webhook"
+	SlackAction   = "slack"
+	CommandAction = "command"
+
+	defaultWindow = time.Minute
+)
+
+// The rules that the entries of the followed logs are evaluated against.
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Fires once at least Threshold entries that match the rule are logged within Window, across every followed node and log,
+// and then not again until Cooldown has passed. The entries of an alert do not count towards the next one.
+type Rule struct {
+	Name  string `json:"name"`
+	Match Match  `json:"match"`
+	// The number of matching entries that fires the rule, defaulting to 1.
+	Threshold int `json:"threshold,omitempty"`
+	// The time the matching entries must be logged within, such as "5m", defaulting to 1m.
+	Window string `json:"window,omitempty"`
+	// The time after firing that the rule does not fire again, such as "15m", defaulting to the window.
+	Cooldown string   `json:"cooldown,omitempty"`
+	Actions  []Action `json:"actions"`
+
+	window   time.Duration
+	cooldown time.Duration
+	regex    *regexp.Regexp
+	filter   filter.Filter
+}
+
+// What entries a rule matches. Every non-empty condition must match, and a condition matches if any of its values matches.
+type Match struct {
+	// A regular expression to find in the whole text of the entry, including its stack trace.
+	Regex string `json:"regex,omitempty"`
+	// Levels, such as "error" or "warn+", for WARN and every level more severe than it.
+	Levels string `json:"levels,omitempty"`
+	// Service types to match exactly, such as jfrt or jfac.
+	Services []string `json:"services,omitempty"`
+	// Substrings to match in the logging location.
+	Loggers []string `json:"loggers,omitempty"`
+	// Substrings to match in the thread name.
+	Threads []string `json:"threads,omitempty"`
+	// Glob patterns of log names, such as "*-service.log".
+	Logs []string `json:"logs,omitempty"`
+	// Node ids to match exactly.
+	Nodes []string `json:"nodes,omitempty"`
+}
+
+// What is done when a rule fires: a webhook POST of the Alert as JSON, a Slack incoming webhook POST of a formatted message,
+// or a local command, which the Alert is written into as JSON through its standard input.
+type Action struct {
+	// Either webhook, slack or command.
+	Type string `json:"type"`
+	// The url to POST to, of the webhook and slack actions.
+	Url string `json:"url,omitempty"`
+	// Headers to set on the POST of the webhook and slack actions, such as an Authorization header.
+	Headers map[string]string `json:"headers,omitempty"`
+	// The program and arguments of the command action.
+	Command []string `json:"command,omitempty"`
+}
+
+// Reads and validates the JSON rules file of the passed path. Unknown fields are rejected, to catch typos.
+func LoadRules(rulesPath string) (*Rules, error) {
+	content, err := ioutil.ReadFile(rulesPath)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	rules := &Rules{}
+	if err = decoder.Decode(rules); err != nil {
+		return nil, fmt.Errorf("invalid rules file [%v]: %w", rulesPath, err)
+	}
+	if err = rules.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rules file [%v]: %w", rulesPath, err)
+	}
+	return rules, nil
+}
+
+// Validates the rules, and prepares them for matching. Must be called before the rules are used.
+func (r *Rules) Validate() error {
+	if len(r.Rules) == 0 {
+		return errors.New("no rules are configured")
+	}
+	names := make(map[string]bool)
+	for idx := range r.Rules {
+		rule := &r.Rules[idx]
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d has no name", idx+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule name [%v] is not unique", rule.Name)
+		}
+		names[rule.Name] = true
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule [%v] %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) validate() (err error) {
+	match := r.Match
+	if match.Regex == "" && match.Levels == "" && len(match.Services) == 0 && len(match.Loggers) == 0 && len(match.Threads) == 0 {
+		return errors.New("matches every entry, set a regex or a parsed field to match")
+	}
+	if match.Regex != "" {
+		if r.regex, err = regexp.Compile(match.Regex); err != nil {
+			return fmt.Errorf("has an invalid regex: %w", err)
+		}
+	}
+	r.filter = filter.Filter{Services: match.Services, Loggers: match.Loggers, Threads: match.Threads}
+	if match.Levels != "" {
+		if r.filter.Levels, err = filter.ParseLevels(match.Levels); err != nil {
+			return fmt.Errorf("has invalid levels: %w", err)
+		}
+	}
+	for _, pattern := range match.Logs {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("has an invalid log pattern [%v]", pattern)
+		}
+	}
+	if r.Threshold < 0 {
+		return fmt.Errorf("has a negative threshold [%v]", r.Threshold)
+	}
+	if r.Threshold == 0 {
+		r.Threshold = 1
+	}
+	if r.window, err = parseRuleDuration("window", r.Window, defaultWindow); err != nil {
+		return err
+	}
+	if r.cooldown, err = parseRuleDuration("cooldown", r.Cooldown, r.window); err != nil {
+		return err
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("has no actions")
+	}
+	for idx, action := range r.Actions {
+		switch action.Type {
+		case WebhookAction, SlackAction:
+			if action.Url == "" {
+				return fmt.Errorf("action %d has no url", idx+1)
+			}
+		case CommandAction:
+			if len(action.Command) == 0 {
+				return fmt.Errorf("action %d has no command", idx+1)
+			}
+		default:
+			return fmt.Errorf("action %d has an unknown type [%v], expected one of [%s,%s,%s]", idx+1, action.Type, WebhookAction, SlackAction, CommandAction)
+		}
+	}
+	return nil
+}
+
+func parseRuleDuration(name, value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("has an invalid %s [%v], expected a positive duration, e.g. 5m", name, value)
+	}
+	return duration, nil
+}
+
+// Returns whether the passed entry of the passed node and log matches the rule.
+// Continuation entries have no fields of their own, and never match.
+func (r *Rule) Matches(nodeId, logName string, logLine *parser.LogLine) bool {
+	if logLine.IsContinuation() {
+		return false
+	}
+	if len(r.Match.Nodes) > 0 && !util.InSlice(r.Match.Nodes, nodeId) {
+		return false
+	}
+	if len(r.Match.Logs) > 0 && !matchesAny(r.Match.Logs, logName) {
+		return false
+	}
+	if !r.filter.Match(logLine) {
+		return false
+	}
+	return r.regex == nil || r.regex.MatchString(logLine.Raw)
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		// patterns were validated when the rules were loaded
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var slackAction = Action{Type: SlackAction, Url: "https://hooks.slack.com/services/T/B/X"}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		rules         []Rule
+		expectedError string
+	}{
+		{name: "no rules", expectedError: "no rules are configured"},
+		{name: "no name", rules: []Rule{{Match: Match{Levels: "error"}, Actions: []Action{slackAction}}},
+			expectedError: "rule 1 has no name"},
+		{name: "duplicate name", rules: []Rule{
+			{Name: "a", Match: Match{Levels: "error"}, Actions: []Action{slackAction}},
+			{Name: "a", Match: Match{Levels: "warn"}, Actions: []Action{slackAction}}},
+			expectedError: "rule name [a] is not unique"},
+		{name: "no condition", rules: []Rule{{Name: "a", Match: Match{Logs: []string{"*.log"}}, Actions: []Action{slackAction}}},
+			expectedError: "rule [a] matches every entry, set a regex or a parsed field to match"},
+		{name: "invalid regex", rules: []Rule{{Name: "a", Match: Match{Regex: "("}, Actions: []Action{slackAction}}},
+			expectedError: "rule [a] has an invalid regex"},
+		{name: "invalid levels", rules: []Rule{{Name: "a", Match: Match{Levels: "severe"}, Actions: []Action{slackAction}}},
+			expectedError: "rule [a] has invalid levels"},
+		{name: "invalid log pattern", rules: []Rule{{Name: "a", Match: Match{Levels: "error", Logs: []string{"["}}, Actions: []Action{slackAction}}},
+			expectedError: "rule [a] has an invalid log pattern [[]"},
+		{name: "negative threshold", rules: []Rule{{Name: "a", Match: Match{Levels: "error"}, Threshold: -1, Actions: []Action{slackAction}}},
+			expectedError: "rule [a] has a negative threshold [-1]"},
+		{name: "invalid window", rules: []Rule{{Name: "a", Match: Match{Levels: "error"}, Window: "5", Actions: []Action{slackAction}}},
+			expectedError: "rule [a] has an invalid window [5], expected a positive duration, e.g. 5m"},
+		{name: "invalid cooldown", rules: []Rule{{Name: "a", Match: Match{Levels: "error"}, Cooldown: "-1m", Actions: []Action{slackAction}}},
+			expectedError: "rule [a] has an invalid cooldown [-1m], expected a positive duration, e.g. 5m"},
+		{name: "no actions", rules: []Rule{{Name: "a", Match: Match{Levels: "error"}}},
+			expectedError: "rule [a] has no actions"},
+		{name: "no url", rules: []Rule{{Name: "a", Match: Match{Levels: "error"}, Actions: []Action{{Type: WebhookAction}}}},
+			expectedError: "rule [a] action 1 has no url"},
+		{name: "no command", rules: []Rule{{Name: "a", Match: Match{Levels: "error"}, Actions: []Action{slackAction, {Type: CommandAction}}}},
+			expectedError: "rule [a] action 2 has no command"},
+		{name: "unknown action", rules: []Rule{{Name: "a", Match: Match{Levels: "error"}, Actions: []Action{{Type: "email"}}}},
+			expectedError: "rule [a] action 1 has an unknown type [email], expected one of [webhook,slack,command]"},
+		{name: "valid", rules: []Rule{{Name: "a", Match: Match{Regex: "pool exhausted"}, Actions: []Action{slackAction}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Rules{Rules: tt.rules}).Validate()
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	rules := &Rules{Rules: []Rule{
+		{Name: "a", Match: Match{Levels: "error"}, Actions: []Action{slackAction}},
+		{Name: "b", Match: Match{Levels: "error"}, Threshold: 5, Window: "10m", Actions: []Action{slackAction}},
+		{Name: "c", Match: Match{Levels: "error"}, Window: "10m", Cooldown: "1h", Actions: []Action{slackAction}},
+	}}
+	require.NoError(t, rules.Validate())
+	assert.Equal(t, 1, rules.Rules[0].Threshold)
+	assert.Equal(t, time.Minute, rules.Rules[0].window)
+	assert.Equal(t, time.Minute, rules.Rules[0].cooldown)
+	assert.Equal(t, 5, rules.Rules[1].Threshold)
+	assert.Equal(t, 10*time.Minute, rules.Rules[1].cooldown)
+	assert.Equal(t, time.Hour, rules.Rules[2].cooldown)
+}
+
+func TestLoadRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forest-alert")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rulesPath := filepath.Join(dir, "rules.json")
+	require.NoError(t, ioutil.WriteFile(rulesPath, []byte(`{"rules": [{"name": "db-pool", "match": {"regex": "connection pool exhausted"},
+		"threshold": 3, "window": "5m", "actions": [{"type": "command", "command": ["true"]}]}]}`), 0600))
+	rules, err := LoadRules(rulesPath)
+	require.NoError(t, err)
+	require.Len(t, rules.Rules, 1)
+	assert.Equal(t, 3, rules.Rules[0].Threshold)
+	assert.Equal(t, 5*time.Minute, rules.Rules[0].window)
+
+	require.NoError(t, ioutil.WriteFile(rulesPath, []byte(`{"rules": [{"name": "db-pool", "treshold": 3}]}`), 0600))
+	_, err = LoadRules(rulesPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid rules file ["+rulesPath+"]: json: unknown field \"treshold\"")
+}
+
+func TestMatches(t *testing.T) {
+	const errorLine = "2020-12-06T19:21:52.622Z [jfrt ] [ERROR] [152a442b8f87bacc] [o.a.DbPool:58] [http-nio-8081-exec-1] - Database connection pool exhausted\n\tat org.jfrog.DbPool.get(DbPool.java:58)"
+	const infoLine = "2020-12-06T19:21:52.622Z [jfac ] [INFO ] [152a442b8f87bacc] [o.a.Access:12] [main] - Database connection pool exhausted"
+	tests := []struct {
+		name     string
+		match    Match
+		nodeId   string
+		logName  string
+		raw      string
+		expected bool
+	}{
+		{name: "regex", match: Match{Regex: "pool exhausted"}, raw: errorLine, expected: true},
+		{name: "regex in stack trace", match: Match{Regex: `DbPool\.java`}, raw: errorLine, expected: true},
+		{name: "regex mismatch", match: Match{Regex: "disk full"}, raw: errorLine},
+		{name: "level and service", match: Match{Levels: "error", Services: []string{"jfrt"}}, raw: errorLine, expected: true},
+		{name: "level mismatch", match: Match{Levels: "warn+", Regex: "pool exhausted"}, raw: infoLine},
+		{name: "service mismatch", match: Match{Services: []string{"jfrt"}}, raw: infoLine},
+		{name: "logger and thread", match: Match{Loggers: []string{"DbPool"}, Threads: []string{"http-nio"}}, raw: errorLine, expected: true},
+		{name: "log pattern", match: Match{Levels: "error", Logs: []string{"*-service.log"}}, logName: "artifactory-service.log", raw: errorLine, expected: true},
+		{name: "log pattern mismatch", match: Match{Levels: "error", Logs: []string{"*-request.log"}}, logName: "artifactory-service.log", raw: errorLine},
+		{name: "node", match: Match{Levels: "error", Nodes: []string{"node-a"}}, nodeId: "node-a", raw: errorLine, expected: true},
+		{name: "node mismatch", match: Match{Levels: "error", Nodes: []string{"node-a"}}, nodeId: "node-b", raw: errorLine},
+		{name: "continuation", match: Match{Regex: "DbPool"}, raw: "\tat org.jfrog.DbPool.get(DbPool.java:58)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := &Rules{Rules: []Rule{{Name: "a", Match: tt.match, Actions: []Action{slackAction}}}}
+			require.NoError(t, rules.Validate())
+			assert.Equal(t, tt.expected, rules.Rules[0].Matches(tt.nodeId, tt.logName, parser.Parse(tt.raw)))
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package alert
+
+import (
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/format"
+	"github.com/hanoch-jfrog/forest/parser"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultActionTimeout = 30 * time.Second
+	// The number of characters of the sample of an alert that are written into notices and Slack messages, before it is cut.
+	maxSampleLength = 1000
+)
+
+// A fired rule.
+type Alert struct {
+	Rule     string `json:"rule"`
+	ServerId string `json:"server_id,omitempty"`
+	// The number of matching entries within the window, which is at least the threshold of the rule.
+	Count     int       `json:"count"`
+	Window    string    `json:"window"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	NodeIds   []string  `json:"node_ids"`
+	LogNames  []string  `json:"log_names"`
+	// The whole text of the latest matching entry.
+	Sample string `json:"sample"`
+}
+
+// Returns a single line that describes the alert.
+func (a *Alert) Text() string {
+	entries := "entries"
+	if a.Count == 1 {
+		entries = "entry"
+	}
+	text := fmt.Sprintf("Rule [%s] fired: %d matching %s within %s, of %s on %s", a.Rule, a.Count, entries, a.Window,
+		strings.Join(a.LogNames, ","), strings.Join(a.NodeIds, ","))
+	if a.ServerId != "" {
+		text += " of server " + a.ServerId
+	}
+	return text
+}
+
+// Evaluates the rules against the entries of every source written into its SourceOutput, and runs the actions of the rules that fire.
+// Actions run in the background, and failures to run them are written into the notice output, along with every alert.
+type Watcher struct {
+	rules         []*ruleState
+	serverId      string
+	noticeOutput  io.Writer
+	httpClient    *http.Client
+	actionTimeout time.Duration
+
+	noticeMu sync.Mutex
+	actions  sync.WaitGroup
+}
+
+type ruleState struct {
+	rule *Rule
+
+	mu sync.Mutex
+	// the matching entries within the window, in time order
+	matches   []ruleMatch
+	lastFired time.Time
+}
+
+type ruleMatch struct {
+	timestamp time.Time
+	nodeId    string
+	logName   string
+	raw       string
+}
+
+// Returns a Watcher of the passed rules, which must have been validated.
+func NewWatcher(rules *Rules) *Watcher {
+	watcher := &Watcher{
+		noticeOutput:  ioutil.Discard,
+		httpClient:    &http.Client{Timeout: defaultActionTimeout},
+		actionTimeout: defaultActionTimeout,
+	}
+	for idx := range rules.Rules {
+		watcher.rules = append(watcher.rules, &ruleState{rule: &rules.Rules[idx]})
+	}
+	return watcher
+}
+
+// Sets the server id that alerts are of.
+func (w *Watcher) SetServerId(serverId string) {
+	w.serverId = serverId
+}
+
+// Sets the io.Writer that alerts, and failures to run actions, are written into.
+func (w *Watcher) SetNoticeOutput(noticeOutput io.Writer) {
+	w.noticeOutput = noticeOutput
+}
+
+// Sets the http.Client of the webhook and slack actions.
+func (w *Watcher) SetHttpClient(httpClient *http.Client) {
+	w.httpClient = httpClient
+}
+
+// Sets the time an action may run before it is cancelled, defaulting to 30 seconds.
+func (w *Watcher) SetActionTimeout(actionTimeout time.Duration) {
+	w.actionTimeout = actionTimeout
+}
+
+// Returns a SourceOutput whose io.Writers evaluate the rules against every entry written into them.
+func (w *Watcher) SourceOutput() livelog.SourceOutput {
+	return func(source livelog.Source) io.Writer {
+		return parser.NewEntryWriter(func(logLine *parser.LogLine) error {
+			w.Add(source, logLine)
+			return nil
+		})
+	}
+}
+
+// Evaluates the rules against the passed entry of the passed source, running the actions of the rules that fire.
+func (w *Watcher) Add(source livelog.Source, logLine *parser.LogLine) {
+	for _, state := range w.rules {
+		if !state.rule.Matches(source.NodeId, source.LogName, logLine) {
+			continue
+		}
+		if alert := state.add(ruleMatch{timestamp: logLine.Timestamp, nodeId: source.NodeId, logName: source.LogName, raw: logLine.Raw}); alert != nil {
+			alert.ServerId = w.serverId
+			w.fire(state.rule, alert)
+		}
+	}
+}
+
+// Waits for the running actions to finish.
+func (w *Watcher) Close() error {
+	w.actions.Wait()
+	return nil
+}
+
+// Adds a matching entry, and returns the Alert if the rule fires.
+func (s *ruleState) add(match ruleMatch) *Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// entries of many sources interleave, so they are inserted in time order
+	idx := len(s.matches)
+	for idx > 0 && s.matches[idx-1].timestamp.After(match.timestamp) {
+		idx--
+	}
+	s.matches = append(s.matches, ruleMatch{})
+	copy(s.matches[idx+1:], s.matches[idx:])
+	s.matches[idx] = match
+
+	latest := s.matches[len(s.matches)-1].timestamp
+	windowStart := latest.Add(-s.rule.window)
+	dropped := 0
+	for dropped < len(s.matches) && !s.matches[dropped].timestamp.After(windowStart) {
+		dropped++
+	}
+	s.matches = s.matches[dropped:]
+	if len(s.matches) < s.rule.Threshold {
+		return nil
+	}
+	if !s.lastFired.IsZero() && latest.Before(s.lastFired.Add(s.rule.cooldown)) {
+		return nil
+	}
+
+	alert := &Alert{
+		Rule:      s.rule.Name,
+		Count:     len(s.matches),
+		Window:    s.rule.window.String(),
+		FirstSeen: s.matches[0].timestamp,
+		LastSeen:  latest,
+		Sample:    s.matches[len(s.matches)-1].raw,
+	}
+	for _, m := range s.matches {
+		alert.NodeIds = appendDistinct(alert.NodeIds, m.nodeId)
+		alert.LogNames = appendDistinct(alert.LogNames, m.logName)
+	}
+	sort.Strings(alert.NodeIds)
+	sort.Strings(alert.LogNames)
+	s.lastFired = latest
+	s.matches = nil
+	return alert
+}
+
+func (w *Watcher) fire(rule *Rule, alert *Alert) {
+	w.notice("- %s\n%s\n", alert.Text(), format.Cut(alert.Sample, maxSampleLength))
+	for _, action := range rule.Actions {
+		w.actions.Add(1)
+		go func(action Action) {
+			defer w.actions.Done()
+			if err := w.run(action, alert); err != nil {
+				w.notice("- Failed to run the %s action of rule [%s]: %v\n", action.Type, rule.Name, err)
+			}
+		}(action)
+	}
+}
+
+func (w *Watcher) notice(format string, args ...interface{}) {
+	w.noticeMu.Lock()
+	defer w.noticeMu.Unlock()
+	fmt.Fprintf(w.noticeOutput, format, args...)
+}
+
+func appendDistinct(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,179 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func poolLine(second int) string {
+	return fmt.Sprintf("2020-12-06T19:%02d:%02d.000Z [jfrt ] [ERROR] [152a442b8f87bacc] [o.a.DbPool:58] [main] - Database connection pool exhausted", second/60, second%60)
+}
+
+func serviceSource(nodeId string) livelog.Source {
+	return livelog.Source{NodeId: nodeId, LogName: "artifactory-service.log"}
+}
+
+// Collects the alerts that the webhook action posts.
+type alertReceiver struct {
+	mu      sync.Mutex
+	alerts  []Alert
+	headers []http.Header
+}
+
+func (r *alertReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	alert := Alert{}
+	if err := json.NewDecoder(req.Body).Decode(&alert); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+	r.headers = append(r.headers, req.Header)
+}
+
+func TestWatcherThresholdAndCooldown(t *testing.T) {
+	receiver := &alertReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	rules := &Rules{Rules: []Rule{{
+		Name:      "db-pool",
+		Match:     Match{Regex: "pool exhausted", Services: []string{"jfrt"}},
+		Threshold: 3,
+		Window:    "1m",
+		Cooldown:  "5m",
+		Actions:   []Action{{Type: WebhookAction, Url: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}},
+	}}}
+	require.NoError(t, rules.Validate())
+	notices := &bytes.Buffer{}
+	watcher := NewWatcher(rules)
+	watcher.SetServerId("local-arti")
+	watcher.SetNoticeOutput(notices)
+
+	steps := []struct {
+		name       string
+		nodeId     string
+		lines      []string
+		wantAlerts int
+	}{
+		{
+			name:   "2 matches, then a third that is out of the window of the first",
+			nodeId: "node-a",
+			lines:  []string{poolLine(0), poolLine(30), poolLine(61)},
+		},
+		{
+			name:       "a match of another node, whose entries interleave with the first, completes the threshold",
+			nodeId:     "node-b",
+			lines:      []string{poolLine(45), "2020-12-06T19:01:10.000Z [jfrt ] [INFO ] [] [o.a.DbPool:12] [main] - Pool is fine"},
+			wantAlerts: 1,
+		},
+		{
+			name:       "the matches of the alert do not count again, and the rule does not fire within the cooldown",
+			nodeId:     "node-a",
+			lines:      []string{poolLine(70), poolLine(80), poolLine(90)},
+			wantAlerts: 1,
+		},
+		{
+			name:       "once the cooldown has passed, the rule fires again",
+			nodeId:     "node-a",
+			lines:      []string{poolLine(361), poolLine(362), poolLine(363)},
+			wantAlerts: 2,
+		},
+	}
+	for _, step := range steps {
+		for _, line := range step.lines {
+			watcher.Add(serviceSource(step.nodeId), parser.Parse(line))
+		}
+		require.NoError(t, watcher.Close())
+		require.Len(t, receiver.alerts, step.wantAlerts, step.name)
+	}
+
+	alert := receiver.alerts[0]
+	assert.Equal(t, "db-pool", alert.Rule)
+	assert.Equal(t, "local-arti", alert.ServerId)
+	assert.Equal(t, 3, alert.Count)
+	assert.Equal(t, "1m0s", alert.Window)
+	assert.Equal(t, "2020-12-06T19:00:30Z", alert.FirstSeen.UTC().Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, "2020-12-06T19:01:01Z", alert.LastSeen.UTC().Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, []string{"node-a", "node-b"}, alert.NodeIds)
+	assert.Equal(t, []string{"artifactory-service.log"}, alert.LogNames)
+	assert.Equal(t, poolLine(61), alert.Sample)
+	assert.Equal(t, "Bearer token", receiver.headers[0].Get("Authorization"))
+	assert.Equal(t, "application/json", receiver.headers[0].Get("Content-Type"))
+	assert.Contains(t, notices.String(), "- Rule [db-pool] fired: 3 matching entries within 1m0s, of artifactory-service.log on node-a,node-b of server local-arti\n")
+	assert.Equal(t, []string{"node-a"}, receiver.alerts[1].NodeIds)
+}
+
+func TestWatcherSlackAction(t *testing.T) {
+	var messages []map[string]string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		message := make(map[string]string)
+		_ = json.NewDecoder(req.Body).Decode(&message)
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, message)
+	}))
+	defer server.Close()
+	rules := &Rules{Rules: []Rule{{Name: "errors", Match: Match{Levels: "error"}, Actions: []Action{{Type: SlackAction, Url: server.URL}}}}}
+	require.NoError(t, rules.Validate())
+	watcher := NewWatcher(rules)
+	watcher.Add(serviceSource("node-a"), parser.Parse("2020-12-06T19:00:00.000Z [jfrt ] [ERROR] [] [o.a.Some:1] [main] - Invalid <path> & more"))
+	require.NoError(t, watcher.Close())
+
+	require.Len(t, messages, 1)
+	assert.Equal(t, ":rotating_light: *Rule [errors] fired: 1 matching entry within 1m0s, of artifactory-service.log on node-a*\n"+
+		"```2020-12-06T19:00:00.000Z [jfrt ] [ERROR] [] [o.a.Some:1] [main] - Invalid &lt;path&gt; &amp; more```", messages[0]["text"])
+}
+
+func TestWatcherCommandAction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forest-alert")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	alertPath := filepath.Join(dir, "alert.json")
+	rules := &Rules{Rules: []Rule{{Name: "errors", Match: Match{Levels: "error"}, Actions: []Action{
+		{Type: CommandAction, Command: []string{"sh", "-c", `cat > "$0" && test "$FOREST_ALERT_RULE" = errors`, alertPath}},
+		{Type: CommandAction, Command: []string{"sh", "-c", "echo no route to host && exit 3"}},
+	}}}}
+	require.NoError(t, rules.Validate())
+	notices := &bytes.Buffer{}
+	watcher := NewWatcher(rules)
+	watcher.SetNoticeOutput(notices)
+	watcher.Add(serviceSource("node-a"), parser.Parse(poolLine(0)))
+	require.NoError(t, watcher.Close())
+
+	content, err := ioutil.ReadFile(alertPath)
+	require.NoError(t, err)
+	alert := Alert{}
+	require.NoError(t, json.Unmarshal(content, &alert))
+	assert.Equal(t, "errors", alert.Rule)
+	assert.Equal(t, 1, alert.Count)
+	assert.Contains(t, notices.String(), "- Failed to run the command action of rule [errors]: exit status 3: no route to host\n")
+}
+
+func TestWatcherWebhookFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	rules := &Rules{Rules: []Rule{{Name: "errors", Match: Match{Levels: "error"}, Actions: []Action{{Type: WebhookAction, Url: server.URL}}}}}
+	require.NoError(t, rules.Validate())
+	notices := &bytes.Buffer{}
+	watcher := NewWatcher(rules)
+	watcher.SetNoticeOutput(notices)
+	watcher.Add(serviceSource("node-a"), parser.Parse(poolLine(0)))
+	require.NoError(t, watcher.Close())
+	assert.Contains(t, notices.String(), fmt.Sprintf("- Failed to run the webhook action of rule [errors]: %s responded with status 503\n", server.URL))
+}
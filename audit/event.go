@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"github.com/hanoch-jfrog/forest/parser"
+	"strings"
+	"time"
+)
+
+// The layout of the timestamps of the access log of Artifactory 6, in the local time of the server.
+const legacyTimestampLayout = "2006-01-02 15:04:05,000"
+
+const (
+	accepted = "ACCEPTED"
+	denied   = "DENIED"
+)
+
+// The actions of the access log that read or write artifacts, or authenticate. Every other action, such as
+// CREATE, UPDATE or CONFIGURATION, changes the configuration, users or permissions, and is an admin action.
+var artifactActions = map[string]bool{
+	"LOGIN": true, "DOWNLOAD": true, "DEPLOY": true, "DELETE": true, "SEARCH": true, "ANNOTATE": true, "ANNOTATE_DELETE": true,
+}
+
+// A single event of the Artifactory access log, artifactory-access.log.
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	NodeId    string    `json:"node_id,omitempty"`
+	TraceId   string    `json:"trace_id,omitempty"`
+	// Either ACCEPTED or DENIED.
+	Outcome string `json:"outcome"`
+	// The action type, such as LOGIN, DEPLOY or CREATE.
+	Action string `json:"action"`
+	// What the action is applied to, such as repo:path for artifact actions, and empty for logins.
+	Target        string `json:"target,omitempty"`
+	Username      string `json:"username"`
+	RemoteAddress string `json:"remote_address"`
+}
+
+// Parses a line of the access log, either of the Artifactory 7 format:
+// timestamp [trace_id] [ACCEPTED|DENIED action] target for client : username / remote_address.
+// or of the Artifactory 6 format:
+// yyyy-MM-dd HH:mm:ss,SSS [ACCEPTED|DENIED action] target for username/remote_address.
+// Returns false if the line is of neither format.
+func ParseEvent(line string) (*Event, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	event := &Event{}
+	var rest string
+	if timestamp, ok := parser.ParseTimestamp(line); ok {
+		timestampEnd := strings.IndexAny(line, " [")
+		if timestampEnd < 0 {
+			return nil, false
+		}
+		event.Timestamp = timestamp
+		rest = line[timestampEnd:]
+	} else {
+		if len(line) < len(legacyTimestampLayout) {
+			return nil, false
+		}
+		timestamp, err := time.ParseInLocation(legacyTimestampLayout, line[:len(legacyTimestampLayout)], time.Local)
+		if err != nil {
+			return nil, false
+		}
+		event.Timestamp = timestamp
+		rest = line[len(legacyTimestampLayout):]
+	}
+
+	// the bracketed fields up to the outcome, where the one preceding it is the trace id
+	for {
+		rest = strings.TrimLeft(rest, " ")
+		if !strings.HasPrefix(rest, "[") {
+			return nil, false
+		}
+		fieldEnd := strings.IndexByte(rest, ']')
+		if fieldEnd < 0 {
+			return nil, false
+		}
+		field := strings.TrimSpace(rest[1:fieldEnd])
+		rest = rest[fieldEnd+1:]
+		fieldParts := strings.Fields(field)
+		if len(fieldParts) == 2 && (fieldParts[0] == accepted || fieldParts[0] == denied) {
+			event.Outcome, event.Action = fieldParts[0], fieldParts[1]
+			break
+		}
+		event.TraceId = field
+	}
+
+	// the principal follows the last " for ", and the target, if any, precedes it
+	rest = " " + strings.TrimSuffix(strings.TrimSpace(rest), ".")
+	principalStart, principalPrefixLen := strings.LastIndex(rest, " for client : "), len(" for client : ")
+	if principalStart < 0 {
+		principalStart, principalPrefixLen = strings.LastIndex(rest, " for "), len(" for ")
+	}
+	if principalStart < 0 {
+		return nil, false
+	}
+	event.Target = strings.TrimSpace(rest[:principalStart])
+	principal := rest[principalStart+principalPrefixLen:]
+	addressStart := strings.LastIndexByte(principal, '/')
+	if addressStart < 0 {
+		event.Username = strings.TrimSpace(principal)
+	} else {
+		event.Username = strings.TrimSpace(principal[:addressStart])
+		event.RemoteAddress = strings.TrimSpace(principal[addressStart+1:])
+	}
+	return event, true
+}
+
+// Returns whether the event failed to authenticate a user.
+func (e *Event) IsFailedLogin() bool {
+	return e.Outcome == denied && e.Action == "LOGIN"
+}
+
+// Returns whether the event is a deploy that was not permitted.
+func (e *Event) IsDeniedDeploy() bool {
+	return e.Outcome == denied && e.Action == "DEPLOY"
+}
+
+// Returns whether the event changes the configuration, users or permissions, whether it was accepted or denied.
+func (e *Event) IsAdminAction() bool {
+	return !artifactActions[e.Action]
+}
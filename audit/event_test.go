@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   *Event
+		wantOk bool
+	}{
+		{
+			name: "artifactory 7 deploy",
+			line: "2020-12-06T19:21:52.540Z [6469d8c8e2ece130] [ACCEPTED DEPLOY] libs-release-local:org/acme/app-1.0.jar for client : deployer / 10.0.0.1.",
+			want: &Event{
+				Timestamp:     time.Date(2020, 12, 6, 19, 21, 52, 540000000, time.UTC),
+				TraceId:       "6469d8c8e2ece130",
+				Outcome:       "ACCEPTED",
+				Action:        "DEPLOY",
+				Target:        "libs-release-local:org/acme/app-1.0.jar",
+				Username:      "deployer",
+				RemoteAddress: "10.0.0.1",
+			},
+			wantOk: true,
+		},
+		{
+			name: "artifactory 7 login",
+			line: "2020-12-06T19:21:52Z [6469d8c8e2ece130] [DENIED LOGIN]  for client : admin / 10.0.0.2.\r\n",
+			want: &Event{
+				Timestamp:     time.Date(2020, 12, 6, 19, 21, 52, 0, time.UTC),
+				TraceId:       "6469d8c8e2ece130",
+				Outcome:       "DENIED",
+				Action:        "LOGIN",
+				Username:      "admin",
+				RemoteAddress: "10.0.0.2",
+			},
+			wantOk: true,
+		},
+		{
+			name: "artifactory 7 ipv6 address",
+			line: "2020-12-06T19:21:52Z [6469d8c8e2ece130] [ACCEPTED CREATE] user:bob for client : admin / 0:0:0:0:0:0:0:1.",
+			want: &Event{
+				Timestamp:     time.Date(2020, 12, 6, 19, 21, 52, 0, time.UTC),
+				TraceId:       "6469d8c8e2ece130",
+				Outcome:       "ACCEPTED",
+				Action:        "CREATE",
+				Target:        "user:bob",
+				Username:      "admin",
+				RemoteAddress: "0:0:0:0:0:0:0:1",
+			},
+			wantOk: true,
+		},
+		{
+			name: "artifactory 6",
+			line: "2019-01-12 13:58:14,123 [DENIED DEPLOY] libs-release-local:a.jar for anonymous/10.0.0.3.",
+			want: &Event{
+				Timestamp:     time.Date(2019, 1, 12, 13, 58, 14, 123000000, time.Local),
+				Outcome:       "DENIED",
+				Action:        "DEPLOY",
+				Target:        "libs-release-local:a.jar",
+				Username:      "anonymous",
+				RemoteAddress: "10.0.0.3",
+			},
+			wantOk: true,
+		},
+		{
+			name: "unified log line",
+			line: "2020-12-06T19:21:52.540Z [jfrt ] [INFO ] [6469d8c8e2ece130] [a.b.C:12] [main] - message",
+		},
+		{
+			name: "request log line",
+			line: "2020-12-06T19:21:52Z|6469d8c8e2ece130|127.0.0.1|admin|GET|/api/system/ping|200|-1|0|3|",
+		},
+		{
+			name: "no principal",
+			line: "2020-12-06T19:21:52Z [6469d8c8e2ece130] [DENIED LOGIN] unknown",
+		},
+		{
+			name: "empty line",
+			line: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseEvent(tt.line)
+			require.Equal(t, tt.wantOk, ok)
+			if !tt.wantOk {
+				return
+			}
+			assert.True(t, tt.want.Timestamp.Equal(got.Timestamp))
+			got.Timestamp = tt.want.Timestamp
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvent_kinds(t *testing.T) {
+	tests := []struct {
+		outcome          string
+		action           string
+		wantFailedLogin  bool
+		wantDeniedDeploy bool
+		wantAdminAction  bool
+	}{
+		{"DENIED", "LOGIN", true, false, false},
+		{"ACCEPTED", "LOGIN", false, false, false},
+		{"DENIED", "DEPLOY", false, true, false},
+		{"ACCEPTED", "DOWNLOAD", false, false, false},
+		{"ACCEPTED", "UPDATE", false, false, true},
+		{"DENIED", "CONFIGURATION", false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.outcome+" "+tt.action, func(t *testing.T) {
+			event := &Event{Outcome: tt.outcome, Action: tt.action}
+			assert.Equal(t, tt.wantFailedLogin, event.IsFailedLogin())
+			assert.Equal(t, tt.wantDeniedDeploy, event.IsDeniedDeploy())
+			assert.Equal(t, tt.wantAdminAction, event.IsAdminAction())
+		})
+	}
+}
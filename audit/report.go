@@ -0,0 +1,269 @@
+package audit
+
+import (
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/hanoch-jfrog/forest/util"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBurstThreshold = 5
+	DefaultBurstWindow    = time.Minute
+)
+
+// What a burst of failed logins is grouped by.
+const (
+	BurstOfUser    = "user"
+	BurstOfAddress = "address"
+)
+
+// Aggregates the events of the access logs of many nodes. It is safe for concurrent use.
+type Report struct {
+	burstThreshold int
+	burstWindow    time.Duration
+
+	mu            sync.Mutex
+	events        int64
+	unparsed      int64
+	from          time.Time
+	to            time.Time
+	failedLogins  map[loginKey]*FailedLogins
+	userFailures  map[string][]time.Time
+	addrFailures  map[string][]time.Time
+	deniedDeploys []*Event
+	adminActions  []*Event
+}
+
+type loginKey struct {
+	username      string
+	remoteAddress string
+}
+
+func NewReport() *Report {
+	return &Report{
+		burstThreshold: DefaultBurstThreshold,
+		burstWindow:    DefaultBurstWindow,
+		failedLogins:   make(map[loginKey]*FailedLogins),
+		userFailures:   make(map[string][]time.Time),
+		addrFailures:   make(map[string][]time.Time),
+	}
+}
+
+// Sets the number of failed logins of a single user or address, within the burst window, that make a brute-force burst.
+func (r *Report) SetBurstThreshold(burstThreshold int) {
+	r.burstThreshold = burstThreshold
+}
+
+// Sets the time the failed logins of a burst must fall within.
+func (r *Report) SetBurstWindow(burstWindow time.Duration) {
+	r.burstWindow = burstWindow
+}
+
+// Adds a line of the access log of the passed node, counting it as unparsed if it is not an event.
+func (r *Report) AddLine(nodeId, line string) {
+	event, ok := ParseEvent(line)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !ok {
+		r.unparsed++
+		return
+	}
+	event.NodeId = nodeId
+	r.add(event)
+}
+
+// Adds a single event.
+func (r *Report) Add(event *Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(event)
+}
+
+// NOTE: the caller must hold the Report's lock.
+func (r *Report) add(event *Event) {
+	r.events++
+	if r.from.IsZero() || event.Timestamp.Before(r.from) {
+		r.from = event.Timestamp
+	}
+	if event.Timestamp.After(r.to) {
+		r.to = event.Timestamp
+	}
+	switch {
+	case event.IsFailedLogin():
+		r.addFailedLogin(event)
+	case event.IsDeniedDeploy():
+		r.deniedDeploys = append(r.deniedDeploys, event)
+	case event.IsAdminAction():
+		r.adminActions = append(r.adminActions, event)
+	}
+}
+
+// NOTE: the caller must hold the Report's lock.
+func (r *Report) addFailedLogin(event *Event) {
+	key := loginKey{username: event.Username, remoteAddress: event.RemoteAddress}
+	logins := r.failedLogins[key]
+	if logins == nil {
+		logins = &FailedLogins{Username: event.Username, RemoteAddress: event.RemoteAddress, FirstSeen: event.Timestamp, LastSeen: event.Timestamp}
+		r.failedLogins[key] = logins
+	}
+	logins.Failures++
+	if event.Timestamp.Before(logins.FirstSeen) {
+		logins.FirstSeen = event.Timestamp
+	}
+	if event.Timestamp.After(logins.LastSeen) {
+		logins.LastSeen = event.Timestamp
+	}
+	if event.NodeId != "" && !util.InSlice(logins.NodeIds, event.NodeId) {
+		logins.NodeIds = append(logins.NodeIds, event.NodeId)
+		sort.Strings(logins.NodeIds)
+	}
+	if event.Username != "" {
+		r.userFailures[event.Username] = append(r.userFailures[event.Username], event.Timestamp)
+	}
+	if event.RemoteAddress != "" {
+		r.addrFailures[event.RemoteAddress] = append(r.addrFailures[event.RemoteAddress], event.Timestamp)
+	}
+}
+
+// Returns a SourceOutput whose io.Writers add every line written into them to the Report.
+func (r *Report) SourceOutput() livelog.SourceOutput {
+	return func(source livelog.Source) io.Writer {
+		return parser.NewLineWriter(func(line string) error {
+			r.AddLine(source.NodeId, line)
+			return nil
+		})
+	}
+}
+
+// The failed logins of a single user from a single address.
+type FailedLogins struct {
+	Username      string    `json:"username"`
+	RemoteAddress string    `json:"remote_address"`
+	Failures      int64     `json:"failures"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+	NodeIds       []string  `json:"node_ids"`
+}
+
+// A run of failed logins of a single user or address, where every failure is within the burst window
+// of at least the burst threshold of failures. Overlapping runs are merged into a single burst.
+type Burst struct {
+	// Either BurstOfUser or BurstOfAddress.
+	Of       string    `json:"of"`
+	Name     string    `json:"name"`
+	Failures int       `json:"failures"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+// A snapshot of a Report.
+type Summary struct {
+	Events int64 `json:"events"`
+	// The number of lines that are not events, such as lines of another log format.
+	UnparsedLines int64      `json:"unparsed_lines"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	// Ordered by the number of failures, descending.
+	FailedLogins []FailedLogins `json:"failed_logins"`
+	// Ordered by start time.
+	Bursts []Burst `json:"bursts"`
+	// Ordered by time.
+	DeniedDeploys []Event `json:"denied_deploys"`
+	AdminActions  []Event `json:"admin_actions"`
+}
+
+// Returns a Summary of the events added so far.
+func (r *Report) Summary() *Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	summary := &Summary{
+		Events:        r.events,
+		UnparsedLines: r.unparsed,
+		FailedLogins:  []FailedLogins{},
+		Bursts:        []Burst{},
+		DeniedDeploys: sortedEvents(r.deniedDeploys),
+		AdminActions:  sortedEvents(r.adminActions),
+	}
+	if r.events > 0 {
+		from, to := r.from, r.to
+		summary.From, summary.To = &from, &to
+	}
+	for _, logins := range r.failedLogins {
+		loginsCopy := *logins
+		loginsCopy.NodeIds = append([]string(nil), logins.NodeIds...)
+		summary.FailedLogins = append(summary.FailedLogins, loginsCopy)
+	}
+	sort.Slice(summary.FailedLogins, func(i, j int) bool {
+		first, second := summary.FailedLogins[i], summary.FailedLogins[j]
+		if first.Failures != second.Failures {
+			return first.Failures > second.Failures
+		}
+		if first.Username != second.Username {
+			return first.Username < second.Username
+		}
+		return first.RemoteAddress < second.RemoteAddress
+	})
+	for username, failures := range r.userFailures {
+		summary.Bursts = append(summary.Bursts, findBursts(BurstOfUser, username, failures, r.burstThreshold, r.burstWindow)...)
+	}
+	for address, failures := range r.addrFailures {
+		summary.Bursts = append(summary.Bursts, findBursts(BurstOfAddress, address, failures, r.burstThreshold, r.burstWindow)...)
+	}
+	sort.Slice(summary.Bursts, func(i, j int) bool {
+		first, second := summary.Bursts[i], summary.Bursts[j]
+		if !first.Start.Equal(second.Start) {
+			return first.Start.Before(second.Start)
+		}
+		if first.Of != second.Of {
+			return first.Of > second.Of
+		}
+		return first.Name < second.Name
+	})
+	return summary
+}
+
+// Finds the bursts in the passed failure times, which are sorted in place, as the logs of many nodes interleave.
+func findBursts(of, name string, failures []time.Time, threshold int, window time.Duration) []Burst {
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Before(failures[j]) })
+	var bursts []Burst
+	var burstStart int
+	windowEnd := 0
+	for windowStart := range failures {
+		if windowEnd < windowStart {
+			windowEnd = windowStart
+		}
+		for windowEnd+1 < len(failures) && failures[windowEnd+1].Sub(failures[windowStart]) <= window {
+			windowEnd++
+		}
+		if windowEnd-windowStart+1 < threshold {
+			continue
+		}
+		if len(bursts) > 0 && !failures[windowStart].After(bursts[len(bursts)-1].End) {
+			last := &bursts[len(bursts)-1]
+			last.End = failures[windowEnd]
+			last.Failures = windowEnd - burstStart + 1
+			continue
+		}
+		burstStart = windowStart
+		bursts = append(bursts, Burst{Of: of, Name: name, Failures: windowEnd - windowStart + 1, Start: failures[windowStart], End: failures[windowEnd]})
+	}
+	return bursts
+}
+
+func sortedEvents(events []*Event) []Event {
+	sorted := make([]Event, 0, len(events))
+	for _, event := range events {
+		sorted = append(sorted, *event)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Timestamp.Equal(sorted[j].Timestamp) {
+			return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+		}
+		return sorted[i].NodeId < sorted[j].NodeId
+	})
+	return sorted
+}
@@ -0,0 +1,192 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func accessLine(second int, outcome, action, target, user, address string) string {
+	return fmt.Sprintf("2020-12-06T19:%02d:%02dZ [trace] [%s %s] %s for client : %s / %s.\n", second/60, second%60, outcome, action, target, user, address)
+}
+
+func TestReport(t *testing.T) {
+	report := NewReport()
+	report.SetBurstThreshold(3)
+	report.SetBurstWindow(10 * time.Second)
+	// admin fails 4 times within 10 seconds over two nodes, and again a minute later
+	report.AddLine("node-1", accessLine(0, "DENIED", "LOGIN", "", "admin", "10.0.0.1"))
+	report.AddLine("node-1", accessLine(4, "DENIED", "LOGIN", "", "admin", "10.0.0.1"))
+	report.AddLine("node-1", accessLine(12, "DENIED", "LOGIN", "", "admin", "10.0.0.2"))
+	report.AddLine("node-1", accessLine(15, "DENIED", "DEPLOY", "libs-release-local:a.jar", "deployer", "10.0.0.3"))
+	report.AddLine("node-1", accessLine(20, "ACCEPTED", "DOWNLOAD", "libs-release-local:a.jar", "deployer", "10.0.0.3"))
+	report.AddLine("node-1", "not an event")
+	report.AddLine("node-1", accessLine(80, "DENIED", "LOGIN", "", "admin", "10.0.0.1"))
+	report.AddLine("node-2", accessLine(8, "DENIED", "LOGIN", "", "admin", "10.0.0.1"))
+	report.AddLine("node-2", accessLine(30, "ACCEPTED", "LOGIN", "", "admin", "10.0.0.4"))
+	report.AddLine("node-2", accessLine(40, "ACCEPTED", "UPDATE", "permission:readers", "admin", "10.0.0.4"))
+
+	summary := report.Summary()
+	assert.Equal(t, int64(9), summary.Events)
+	assert.Equal(t, int64(1), summary.UnparsedLines)
+	assert.Equal(t, "2020-12-06T19:00:00Z", format.Time(*summary.From))
+	assert.Equal(t, "2020-12-06T19:01:20Z", format.Time(*summary.To))
+
+	require.Len(t, summary.FailedLogins, 2)
+	assert.Equal(t, "admin", summary.FailedLogins[0].Username)
+	assert.Equal(t, "10.0.0.1", summary.FailedLogins[0].RemoteAddress)
+	assert.Equal(t, int64(4), summary.FailedLogins[0].Failures)
+	assert.Equal(t, "2020-12-06T19:00:00Z", format.Time(summary.FailedLogins[0].FirstSeen))
+	assert.Equal(t, "2020-12-06T19:01:20Z", format.Time(summary.FailedLogins[0].LastSeen))
+	assert.Equal(t, []string{"node-1", "node-2"}, summary.FailedLogins[0].NodeIds)
+	assert.Equal(t, "10.0.0.2", summary.FailedLogins[1].RemoteAddress)
+	assert.Equal(t, int64(1), summary.FailedLogins[1].Failures)
+
+	require.Len(t, summary.Bursts, 2)
+	assert.Equal(t, BurstOfUser, summary.Bursts[0].Of)
+	assert.Equal(t, "admin", summary.Bursts[0].Name)
+	assert.Equal(t, 4, summary.Bursts[0].Failures)
+	assert.Equal(t, "2020-12-06T19:00:12Z", format.Time(summary.Bursts[0].End))
+	assert.Equal(t, BurstOfAddress, summary.Bursts[1].Of)
+	assert.Equal(t, "10.0.0.1", summary.Bursts[1].Name)
+	assert.Equal(t, 3, summary.Bursts[1].Failures)
+	assert.Equal(t, "2020-12-06T19:00:08Z", format.Time(summary.Bursts[1].End))
+
+	require.Len(t, summary.DeniedDeploys, 1)
+	assert.Equal(t, "libs-release-local:a.jar", summary.DeniedDeploys[0].Target)
+	assert.Equal(t, "node-1", summary.DeniedDeploys[0].NodeId)
+	require.Len(t, summary.AdminActions, 1)
+	assert.Equal(t, "UPDATE", summary.AdminActions[0].Action)
+	assert.Equal(t, "node-2", summary.AdminActions[0].NodeId)
+
+	_, err := json.Marshal(summary)
+	require.NoError(t, err)
+}
+
+func TestReport_SourceOutput(t *testing.T) {
+	deniedLogin := accessLine(0, "DENIED", "LOGIN", "", "admin", "10.0.0.1")
+	tests := []struct {
+		name         string
+		writes       []string
+		wantEvents   int64
+		wantUnparsed int64
+	}{
+		{name: "single write", writes: []string{deniedLogin + "not an event\n" + deniedLogin}, wantEvents: 2, wantUnparsed: 1},
+		{name: "line split over writes", writes: []string{deniedLogin[:30], deniedLogin[30:]}, wantEvents: 1},
+		{name: "partial line added once closed", writes: []string{strings.TrimSuffix(deniedLogin, "\n")}, wantEvents: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewReport()
+			output := report.SourceOutput()(livelog.Source{NodeId: "node-1", LogName: "artifactory-access.log"})
+			for _, content := range tt.writes {
+				_, err := output.Write([]byte(content))
+				require.NoError(t, err)
+			}
+			require.NoError(t, output.(io.Closer).Close())
+			summary := report.Summary()
+			assert.Equal(t, tt.wantEvents, summary.Events)
+			assert.Equal(t, tt.wantUnparsed, summary.UnparsedLines)
+			require.Len(t, summary.FailedLogins, 1)
+			assert.Equal(t, []string{"node-1"}, summary.FailedLogins[0].NodeIds)
+		})
+	}
+}
+
+func TestFindBursts(t *testing.T) {
+	start := time.Date(2020, 12, 6, 19, 0, 0, 0, time.UTC)
+	at := func(seconds ...int) []time.Time {
+		var times []time.Time
+		for _, second := range seconds {
+			times = append(times, start.Add(time.Duration(second)*time.Second))
+		}
+		return times
+	}
+	tests := []struct {
+		name     string
+		failures []time.Time
+		want     []Burst
+	}{
+		{
+			name:     "below the threshold",
+			failures: at(0, 5, 20, 25),
+		},
+		{
+			name:     "unsorted",
+			failures: at(9, 0, 5),
+			want:     []Burst{{Of: BurstOfUser, Name: "admin", Failures: 3, Start: start, End: start.Add(9 * time.Second)}},
+		},
+		{
+			name:     "overlapping windows are merged",
+			failures: at(0, 5, 10, 15, 20, 40),
+			want:     []Burst{{Of: BurstOfUser, Name: "admin", Failures: 5, Start: start, End: start.Add(20 * time.Second)}},
+		},
+		{
+			name:     "separate bursts",
+			failures: at(0, 1, 2, 30, 31, 32),
+			want: []Burst{
+				{Of: BurstOfUser, Name: "admin", Failures: 3, Start: start, End: start.Add(2 * time.Second)},
+				{Of: BurstOfUser, Name: "admin", Failures: 3, Start: start.Add(30 * time.Second), End: start.Add(32 * time.Second)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, findBursts(BurstOfUser, "admin", tt.failures, 3, 10*time.Second))
+		})
+	}
+}
+
+func TestReport_emptySummary(t *testing.T) {
+	summaryJson, err := json.Marshal(NewReport().Summary())
+	require.NoError(t, err)
+	assert.Equal(t, `{"events":0,"unparsed_lines":0,"failed_logins":[],"bursts":[],"denied_deploys":[],"admin_actions":[]}`, string(summaryJson))
+}
+
+func testSummary() *Summary {
+	report := NewReport()
+	report.SetBurstThreshold(2)
+	report.AddLine("node-1", accessLine(1, "DENIED", "LOGIN", "", "admin", "10.0.0.1"))
+	report.AddLine("node-1", accessLine(2, "DENIED", "LOGIN", "", "admin", "10.0.0.1"))
+	report.AddLine("node-2", accessLine(3, "DENIED", "DEPLOY", "libs-release-local:a.jar", "deployer", "10.0.0.3"))
+	report.AddLine("node-2", accessLine(4, "ACCEPTED", "CREATE", "user:bob", "admin", "10.0.0.4"))
+	return report.Summary()
+}
+
+func TestWriteText(t *testing.T) {
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteText(out, testSummary()))
+	assert.Equal(t, "Events: 4, failed logins: 2, bursts: 2, denied deploys: 1, admin actions: 1, from 2020-12-06T19:00:01Z to 2020-12-06T19:00:04Z\n"+
+		"\n"+
+		"FAILED LOGINS  REMOTE ADDRESS  FAILURES  FIRST SEEN            LAST SEEN             NODES\n"+
+		"admin          10.0.0.1        2         2020-12-06T19:00:01Z  2020-12-06T19:00:02Z  node-1\n"+
+		"\n"+
+		"BURSTS OF  NAME      FAILURES  START                 END\n"+
+		"user       admin     2         2020-12-06T19:00:01Z  2020-12-06T19:00:02Z\n"+
+		"address    10.0.0.1  2         2020-12-06T19:00:01Z  2020-12-06T19:00:02Z\n"+
+		"\n"+
+		"DENIED DEPLOYS        OUTCOME  ACTION  USER      REMOTE ADDRESS  TARGET                    NODE\n"+
+		"2020-12-06T19:00:03Z  DENIED   DEPLOY  deployer  10.0.0.3        libs-release-local:a.jar  node-2\n"+
+		"\n"+
+		"ADMIN ACTIONS         OUTCOME   ACTION  USER   REMOTE ADDRESS  TARGET    NODE\n"+
+		"2020-12-06T19:00:04Z  ACCEPTED  CREATE  admin  10.0.0.4        user:bob  node-2\n", out.String())
+}
+
+func TestWriteCsv(t *testing.T) {
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteCsv(out, testSummary()))
+	assert.Equal(t, "kind,first_seen,last_seen,username,remote_address,outcome,action,target,count,node_ids\n"+
+		"failed_login,2020-12-06T19:00:01Z,2020-12-06T19:00:02Z,admin,10.0.0.1,DENIED,LOGIN,,2,node-1\n"+
+		"burst,2020-12-06T19:00:01Z,2020-12-06T19:00:02Z,admin,,DENIED,LOGIN,,2,\n"+
+		"burst,2020-12-06T19:00:01Z,2020-12-06T19:00:02Z,,10.0.0.1,DENIED,LOGIN,,2,\n"+
+		"denied_deploy,2020-12-06T19:00:03Z,2020-12-06T19:00:03Z,deployer,10.0.0.3,DENIED,DEPLOY,libs-release-local:a.jar,1,node-2\n"+
+		"admin_action,2020-12-06T19:00:04Z,2020-12-06T19:00:04Z,admin,10.0.0.4,ACCEPTED,CREATE,user:bob,1,node-2\n", out.String())
+}
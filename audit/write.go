@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/format"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// The columns of the CSV form of a Summary, where every row is a failed login, a burst, a denied deploy or an admin action,
+// as told by its kind. Columns that do not apply to a kind are empty.
+var CsvHeader = []string{"kind", "first_seen", "last_seen", "username", "remote_address", "outcome", "action", "target", "count", "node_ids"}
+
+// Writes the passed Summary as aligned tables, separated by empty lines.
+func WriteText(output io.Writer, summary *Summary) error {
+	table := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "Events: %d, failed logins: %d, bursts: %d, denied deploys: %d, admin actions: %d",
+		summary.Events, countFailures(summary.FailedLogins), len(summary.Bursts), len(summary.DeniedDeploys), len(summary.AdminActions))
+	if summary.From != nil {
+		fmt.Fprintf(table, ", from %s to %s", format.Time(*summary.From), format.Time(*summary.To))
+	}
+	if summary.UnparsedLines > 0 {
+		fmt.Fprintf(table, ", unparsed lines: %d", summary.UnparsedLines)
+	}
+	fmt.Fprint(table, "\n")
+
+	if len(summary.FailedLogins) > 0 {
+		fmt.Fprint(table, "\nFAILED LOGINS\tREMOTE ADDRESS\tFAILURES\tFIRST SEEN\tLAST SEEN\tNODES\n")
+		for _, logins := range summary.FailedLogins {
+			fmt.Fprintf(table, "%s\t%s\t%d\t%s\t%s\t%s\n", logins.Username, logins.RemoteAddress, logins.Failures,
+				format.Time(logins.FirstSeen), format.Time(logins.LastSeen), strings.Join(logins.NodeIds, ","))
+		}
+	}
+	if len(summary.Bursts) > 0 {
+		fmt.Fprint(table, "\nBURSTS OF\tNAME\tFAILURES\tSTART\tEND\n")
+		for _, burst := range summary.Bursts {
+			fmt.Fprintf(table, "%s\t%s\t%d\t%s\t%s\n", burst.Of, burst.Name, burst.Failures, format.Time(burst.Start), format.Time(burst.End))
+		}
+	}
+	writeEventTable(table, "DENIED DEPLOYS", summary.DeniedDeploys)
+	writeEventTable(table, "ADMIN ACTIONS", summary.AdminActions)
+	return table.Flush()
+}
+
+func writeEventTable(table io.Writer, header string, events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	fmt.Fprintf(table, "\n%s\tOUTCOME\tACTION\tUSER\tREMOTE ADDRESS\tTARGET\tNODE\n", header)
+	for _, event := range events {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", format.Time(event.Timestamp), event.Outcome, event.Action,
+			event.Username, event.RemoteAddress, event.Target, event.NodeId)
+	}
+}
+
+// Writes the passed Summary as CSV rows, following a CsvHeader row.
+func WriteCsv(output io.Writer, summary *Summary) error {
+	rows := [][]string{CsvHeader}
+	for _, logins := range summary.FailedLogins {
+		rows = append(rows, []string{"failed_login", format.Time(logins.FirstSeen), format.Time(logins.LastSeen), logins.Username, logins.RemoteAddress,
+			denied, "LOGIN", "", strconv.FormatInt(logins.Failures, 10), strings.Join(logins.NodeIds, ";")})
+	}
+	for _, burst := range summary.Bursts {
+		row := []string{"burst", format.Time(burst.Start), format.Time(burst.End), "", "", denied, "LOGIN", "", strconv.Itoa(burst.Failures), ""}
+		if burst.Of == BurstOfUser {
+			row[3] = burst.Name
+		} else {
+			row[4] = burst.Name
+		}
+		rows = append(rows, row)
+	}
+	for _, event := range summary.DeniedDeploys {
+		rows = append(rows, eventRow("denied_deploy", event))
+	}
+	for _, event := range summary.AdminActions {
+		rows = append(rows, eventRow("admin_action", event))
+	}
+	return csv.NewWriter(output).WriteAll(rows)
+}
+
+func eventRow(kind string, event Event) []string {
+	timestamp := format.Time(event.Timestamp)
+	return []string{kind, timestamp, timestamp, event.Username, event.RemoteAddress, event.Outcome, event.Action, event.Target, "1", event.NodeId}
+}
+
+func countFailures(failedLogins []FailedLogins) int64 {
+	var failures int64
+	for _, logins := range failedLogins {
+		failures += logins.Failures
+	}
+	return failures
+}
@@ -0,0 +1,115 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/util"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Identifies the checkpoint of a single log of a single node of a remote service.
+type Key struct {
+	ServerId string
+	NodeId   string
+	LogName  string
+}
+
+// The content of a checkpoint file.
+type Entry struct {
+	ServerId   string    `json:"server_id"`
+	NodeId     string    `json:"node_id"`
+	LogName    string    `json:"log_name"`
+	PageMarker int64     `json:"page_marker"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Keeps a checkpoint file per key, laid out as <dir>/<server_id>/<node_id>/<log_name>.json.
+// Every save replaces its file atomically, so a crash leaves either the previous checkpoint or the new one,
+// and concurrent processes following different logs never overwrite each other's checkpoints.
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{
+		dir: dir,
+	}
+}
+
+// Returns the checkpoint of the passed key, or nil when none was saved.
+func (s *Store) Load(key Key) (*Entry, error) {
+	content, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := &Entry{}
+	if err = json.Unmarshal(content, entry); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file [%v]: %w", s.path(key), err)
+	}
+	return entry, nil
+}
+
+// Saves the passed page marker as the checkpoint of the passed key.
+func (s *Store) Save(key Key, pageMarker int64) error {
+	content, err := json.Marshal(&Entry{
+		ServerId:   key.ServerId,
+		NodeId:     key.NodeId,
+		LogName:    key.LogName,
+		PageMarker: pageMarker,
+		UpdatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	path := s.path(key)
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return util.WriteFileAtomically(path, content, 0600)
+}
+
+// Returns the Checkpoint of the passed key, which may be set on a livelog.Client.
+func (s *Store) Checkpoint(key Key) *keyCheckpoint {
+	return &keyCheckpoint{
+		store: s,
+		key:   key,
+	}
+}
+
+func (s *Store) path(key Key) string {
+	return filepath.Join(s.dir, escapePathElement(key.ServerId), escapePathElement(key.NodeId), escapePathElement(key.LogName)+".json")
+}
+
+// Escapes a key element into a single path element, which cannot be "." or "..".
+func escapePathElement(element string) string {
+	escaped := url.PathEscape(element)
+	if strings.HasPrefix(escaped, ".") {
+		escaped = "%2E" + escaped[1:]
+	}
+	return escaped
+}
+
+type keyCheckpoint struct {
+	store *Store
+	key   Key
+}
+
+func (c *keyCheckpoint) Load() (int64, bool, error) {
+	entry, err := c.store.Load(c.key)
+	if err != nil || entry == nil {
+		return 0, false, err
+	}
+	return entry.PageMarker, true, nil
+}
+
+func (c *keyCheckpoint) Save(pageMarker int64) error {
+	return c.store.Save(c.key, pageMarker)
+}
@@ -0,0 +1,81 @@
+package checkpoint
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	store := NewStore(dir)
+	key := Key{ServerId: "local-arti", NodeId: "node-1", LogName: "console.log"}
+
+	entry, err := store.Load(key)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	require.NoError(t, store.Save(key, 100))
+	require.NoError(t, store.Save(key, 200))
+	require.NoError(t, store.Save(Key{ServerId: "local-arti", NodeId: "node-2", LogName: "console.log"}, 300))
+	entry, err = store.Load(key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), entry.PageMarker)
+	assert.Equal(t, "node-1", entry.NodeId)
+	assert.False(t, entry.UpdatedAt.IsZero())
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "local-arti", "node-1"))
+	require.NoError(t, err)
+	require.Len(t, files, 1, "no temporary files are left behind")
+	assert.Equal(t, "console.log.json", files[0].Name())
+}
+
+func TestStore_Checkpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	checkpoint := NewStore(dir).Checkpoint(Key{ServerId: "local-arti", NodeId: "node-1", LogName: "console.log"})
+
+	_, ok, err := checkpoint.Load()
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, checkpoint.Save(42))
+	pageMarker, ok, err := checkpoint.Load()
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), pageMarker)
+}
+
+func TestStore_corruptedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	key := Key{ServerId: "local-arti", NodeId: "node-1", LogName: "console.log"}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "local-arti", "node-1"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "local-arti", "node-1", "console.log.json"), []byte("{"), 0600))
+
+	_, err = NewStore(dir).Load(key)
+	assert.Error(t, err)
+}
+
+func TestEscapePathElement(t *testing.T) {
+	tests := []struct {
+		element string
+		want    string
+	}{
+		{element: "console.log", want: "console.log"},
+		{element: "a/b", want: "a%2Fb"},
+		{element: "..", want: "%2E."},
+		{element: ".hidden", want: "%2Ehidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.element, func(t *testing.T) {
+			assert.Equal(t, tt.want, escapePathElement(tt.element))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package livelog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+)
+
+// The complete lines of a single log data snapshot, as sent by Client.Stream.
+type Chunk struct {
+	NodeId  string
+	LogName string
+	// The page marker of the first byte of the first line.
+	StartOffset int64
+	// The page marker following the new line of the last line.
+	EndOffset int64
+	// When the log data snapshot completing the last line was fetched.
+	FetchTime time.Time
+	// The lines, without their new lines.
+	Lines []string
+}
+
+// Sends every write as a Chunk of its complete lines, including a partial line held back from the writes before it.
+type chunkWriter struct {
+	ctx         context.Context
+	nodeId      string
+	logFileName string
+	chunks      chan<- Chunk
+
+	partialLine []byte
+	// the page marker of the first byte held back
+	offset int64
+}
+
+// Starts the log data of the following writes at the passed page marker, sending a partial line held back until then as is.
+func (w *chunkWriter) startAt(pageMarker int64) {
+	if len(w.partialLine) > 0 && w.offset+int64(len(w.partialLine)) != pageMarker {
+		// a partial line of a rotated log is never completed, so it could only be sent along with unrelated log data
+		_ = w.flush()
+	}
+	if len(w.partialLine) == 0 {
+		w.offset = pageMarker
+	}
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.partialLine = append(w.partialLine, p...)
+	lastNewLine := bytes.LastIndexByte(w.partialLine, '\n')
+	if lastNewLine < 0 {
+		return len(p), nil
+	}
+	if err := w.send(w.partialLine[:lastNewLine+1]); err != nil {
+		return 0, err
+	}
+	w.partialLine = append(w.partialLine[:0], w.partialLine[lastNewLine+1:]...)
+	return len(p), nil
+}
+
+// Sends the held back partial line, if any.
+func (w *chunkWriter) flush() error {
+	if len(w.partialLine) == 0 {
+		return nil
+	}
+	err := w.send(w.partialLine)
+	w.partialLine = nil
+	return err
+}
+
+// Sends the passed log data, which starts at the offset, and advances the offset past it.
+func (w *chunkWriter) send(content []byte) error {
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.TrimSuffix(line, "\r")
+	}
+	chunk := Chunk{
+		NodeId:      w.nodeId,
+		LogName:     w.logFileName,
+		StartOffset: w.offset,
+		EndOffset:   w.offset + int64(len(content)),
+		FetchTime:   time.Now(),
+		Lines:       lines,
+	}
+	w.offset = chunk.EndOffset
+	select {
+	case w.chunks <- chunk:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
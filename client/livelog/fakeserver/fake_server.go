@@ -0,0 +1,353 @@
+package fakeserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/constants"
+	"github.com/hanoch-jfrog/forest/client/livelog/model"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	nodesEndpoint            = "api/system/nodes"
+	defaultRefreshRateMillis = 1000
+	defaultErrorStatusCode   = http.StatusBadGateway
+)
+
+// Configures a fake server, which serves the live log endpoints of a remote service out of a local directory.
+type Config struct {
+	// A directory holding a directory per node, each holding that node's log files: <LogsDir>/<node_id>/<log_name>
+	LogsDir string
+	// The refresh rate returned by the config endpoint, defaulting to 1000.
+	RefreshRateMillis int64
+
+	// Delays every response, simulating a slow remote service.
+	Latency time.Duration
+	// The fraction of log data requests, between 0 and 1, that randomly fail with ErrorStatusCode.
+	ErrorRate float64
+	// The status code of failed requests, defaulting to 502.
+	ErrorStatusCode int
+
+	// When set, Run appends a generated line to every log on this interval, simulating log growth.
+	GrowthInterval time.Duration
+	// When set, Run rotates any log that grows beyond this size.
+	RotateAfterBytes int64
+}
+
+// An http.Handler serving the nodes, live log config and live log data endpoints,
+// under any base path, such as http://localhost:8082/artifactory/.
+type Server struct {
+	config Config
+
+	mu          sync.Mutex
+	failures    int
+	failureCode int
+	linesCount  int
+}
+
+func New(config Config) *Server {
+	if config.RefreshRateMillis <= 0 {
+		config.RefreshRateMillis = defaultRefreshRateMillis
+	}
+	if config.ErrorStatusCode == 0 {
+		config.ErrorStatusCode = defaultErrorStatusCode
+	}
+	return &Server{
+		config: config,
+	}
+}
+
+// Makes the next count requests fail with the passed status code.
+func (s *Server) FailNext(count, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = count
+	s.failureCode = statusCode
+}
+
+// Appends the passed content to the log of the passed node, creating both if needed.
+func (s *Server) AppendLog(nodeId, logName, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLog(nodeId, logName, content)
+}
+
+// Rotates the log of the passed node, leaving it empty.
+// The previous content is kept in the node's "rotated" directory, which is not served.
+func (s *Server) RotateLog(nodeId, logName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rotateLog(nodeId, logName)
+}
+
+// Simulates log growth and rotation according to the Config, until the passed context is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	if s.config.GrowthInterval <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+	ticker := time.NewTicker(s.config.GrowthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if err := s.grow(now); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) grow(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nodeIds, err := s.nodeIds()
+	if err != nil {
+		return err
+	}
+	for _, nodeId := range nodeIds {
+		logNames, err := s.logNames(nodeId)
+		if err != nil {
+			return err
+		}
+		for _, logName := range logNames {
+			s.linesCount++
+			if err = s.appendLog(nodeId, logName, generateLine(now, nodeId, logName, s.linesCount)); err != nil {
+				return err
+			}
+			if s.config.RotateAfterBytes <= 0 {
+				continue
+			}
+			info, err := os.Stat(s.logPath(nodeId, logName))
+			if err != nil {
+				return err
+			}
+			if info.Size() > s.config.RotateAfterBytes {
+				if err = s.rotateLog(nodeId, logName); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.config.Latency > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(s.config.Latency):
+		}
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if statusCode, fail := s.nextFailure(r); fail {
+		http.Error(w, "simulated failure", statusCode)
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/"+nodesEndpoint):
+		s.serveNodes(w)
+	case strings.HasSuffix(r.URL.Path, "/"+constants.ConfigEndpoint):
+		s.serveConfig(w, r)
+	case strings.HasSuffix(r.URL.Path, "/"+constants.DataEndpoint):
+		s.serveData(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) nextFailure(r *http.Request) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return s.failureCode, true
+	}
+	if s.config.ErrorRate > 0 && strings.HasSuffix(r.URL.Path, "/"+constants.DataEndpoint) && rand.Float64() < s.config.ErrorRate {
+		return s.config.ErrorStatusCode, true
+	}
+	return 0, false
+}
+
+func (s *Server) serveNodes(w http.ResponseWriter) {
+	s.mu.Lock()
+	nodeIds, err := s.nodeIds()
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	serviceNodes := model.ServiceNodes{Nodes: []model.ServiceNode{}}
+	for _, nodeId := range nodeIds {
+		serviceNodes.Nodes = append(serviceNodes.Nodes, model.ServiceNode{NodeId: nodeId})
+	}
+	writeJson(w, serviceNodes)
+}
+
+func (s *Server) serveConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nodeId, ok := s.requestNodeId(w, r)
+	if !ok {
+		return
+	}
+	logNames, err := s.logNames(nodeId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJson(w, model.Config{LogFileNames: logNames, RefreshRateMillis: s.config.RefreshRateMillis})
+}
+
+// Serves the log data following the requested $file_size page marker.
+// A page marker beyond the end of the log is answered with no content and the current log size.
+func (s *Server) serveData(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nodeId, ok := s.requestNodeId(w, r)
+	if !ok {
+		return
+	}
+	logName := r.URL.Query().Get("id")
+	if logName == "" || logName != filepath.Base(logName) {
+		http.Error(w, "invalid log name", http.StatusBadRequest)
+		return
+	}
+	pageMarker, err := strconv.ParseInt(r.URL.Query().Get("$file_size"), 10, 64)
+	if err != nil || pageMarker < 0 {
+		http.Error(w, "invalid $file_size", http.StatusBadRequest)
+		return
+	}
+
+	logFile, err := os.Open(s.logPath(nodeId, logName))
+	if os.IsNotExist(err) {
+		http.Error(w, "log not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer logFile.Close()
+	info, err := logFile.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logData := model.Data{PageMarker: info.Size()}
+	if pageMarker < info.Size() {
+		if _, err = logFile.Seek(pageMarker, io.SeekStart); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		content, err := ioutil.ReadAll(io.LimitReader(logFile, info.Size()-pageMarker))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logData.Content = string(content)
+	}
+	writeJson(w, logData)
+}
+
+// Returns the node id of the X-JFrog-Node-Id header, defaulting to the first node, as a remote service would serve its own logs.
+// NOTE: the caller must hold the Server's lock.
+func (s *Server) requestNodeId(w http.ResponseWriter, r *http.Request) (string, bool) {
+	nodeIds, err := s.nodeIds()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return "", false
+	}
+	nodeId := r.Header.Get(constants.NodeIdHeader)
+	if nodeId == "" && len(nodeIds) > 0 {
+		return nodeIds[0], true
+	}
+	for _, id := range nodeIds {
+		if id == nodeId {
+			return nodeId, true
+		}
+	}
+	http.Error(w, fmt.Sprintf("node not found [%v]", nodeId), http.StatusNotFound)
+	return "", false
+}
+
+func (s *Server) nodeIds() ([]string, error) {
+	return listDir(s.config.LogsDir, true)
+}
+
+func (s *Server) logNames(nodeId string) ([]string, error) {
+	return listDir(filepath.Join(s.config.LogsDir, nodeId), false)
+}
+
+func (s *Server) logPath(nodeId, logName string) string {
+	return filepath.Join(s.config.LogsDir, nodeId, logName)
+}
+
+func (s *Server) appendLog(nodeId, logName, content string) error {
+	if err := os.MkdirAll(filepath.Join(s.config.LogsDir, nodeId), 0755); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(s.logPath(nodeId, logName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = logFile.WriteString(content); err != nil {
+		logFile.Close()
+		return err
+	}
+	return logFile.Close()
+}
+
+func (s *Server) rotateLog(nodeId, logName string) error {
+	rotatedDir := filepath.Join(s.config.LogsDir, nodeId, "rotated")
+	if err := os.MkdirAll(rotatedDir, 0755); err != nil {
+		return err
+	}
+	rotatedName := fmt.Sprintf("%s.%d", logName, time.Now().UnixNano())
+	if err := os.Rename(s.logPath(nodeId, logName), filepath.Join(rotatedDir, rotatedName)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.logPath(nodeId, logName), nil, 0644)
+}
+
+// Returns the sorted names of the directories, or of the regular files, in the passed directory.
+func listDir(dir string, dirs bool) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, info := range infos {
+		if info.IsDir() == dirs && !strings.HasPrefix(info.Name(), ".") {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
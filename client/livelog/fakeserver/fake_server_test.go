@@ -0,0 +1,151 @@
+package fakeserver
+
+import (
+	"bytes"
+	"context"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startServer(t *testing.T, config Config) (*Server, strategy.Http, func()) {
+	logsDir, err := ioutil.TempDir("", "fakeserver")
+	require.NoError(t, err)
+	config.LogsDir = logsDir
+	s := New(config)
+	require.NoError(t, s.AppendLog("node-1", "console.log", "one\n"))
+	require.NoError(t, s.AppendLog("node-1", "access-service.log", "access\n"))
+	require.NoError(t, s.AppendLog("node-2", "console.log", "two\n"))
+	httpServer := httptest.NewServer(s)
+	httpStrategy, err := strategy.NewStandaloneHttpStrategy(strategy.StandaloneHttpConfig{BaseUrl: httpServer.URL + "/artifactory/"})
+	require.NoError(t, err)
+	return s, httpStrategy, func() {
+		httpServer.Close()
+		os.RemoveAll(logsDir)
+	}
+}
+
+func TestServer_nodesAndConfig(t *testing.T) {
+	_, httpStrategy, closeServer := startServer(t, Config{RefreshRateMillis: 100})
+	defer closeServer()
+
+	client := livelog.NewClient(httpStrategy)
+	nodeIds, err := client.GetServiceNodeIds(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"node-1", "node-2"}, nodeIds)
+
+	client.SetNodeId("node-1")
+	config, err := client.GetConfig(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"access-service.log", "console.log"}, config.LogFileNames)
+	require.Equal(t, int64(100), config.RefreshRateMillis)
+
+	client.SetNodeId("node-3")
+	_, err = client.GetConfig(context.Background())
+	require.Error(t, err)
+}
+
+func TestServer_tailGrowthAndRotation(t *testing.T) {
+	s, httpStrategy, closeServer := startServer(t, Config{})
+	defer closeServer()
+
+	client := livelog.NewClient(httpStrategy)
+	client.SetNodeId("node-2")
+	client.SetLogFileName("console.log")
+	client.SetLogsRefreshRate(20 * time.Millisecond)
+	notices := &syncBuffer{}
+	client.SetNoticeOutput(notices)
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, s.AppendLog("node-2", "console.log", "three\nfour\n"))
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, s.RotateLog("node-2", "console.log"))
+		require.NoError(t, s.AppendLog("node-2", "console.log", "new\n"))
+	}()
+	out := &syncBuffer{}
+	require.NoError(t, client.TailLog(timeoutCtx, out))
+	require.Equal(t, "two\nthree\nfour\nnew\n", out.String())
+	require.Contains(t, notices.String(), "rotated")
+}
+
+func TestServer_failures(t *testing.T) {
+	s, httpStrategy, closeServer := startServer(t, Config{})
+	defer closeServer()
+
+	client := livelog.NewClient(httpStrategy)
+	client.SetNodeId("node-1")
+	client.SetLogFileName("console.log")
+	s.FailNext(1, http.StatusServiceUnavailable)
+	err := client.CatLog(context.Background(), &bytes.Buffer{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "503")
+
+	out := &bytes.Buffer{}
+	require.NoError(t, client.CatLog(context.Background(), out))
+	require.Equal(t, "one\n", out.String())
+}
+
+func TestServer_latency(t *testing.T) {
+	_, httpStrategy, closeServer := startServer(t, Config{Latency: 50 * time.Millisecond})
+	defer closeServer()
+
+	start := time.Now()
+	_, err := httpStrategy.SendGet(context.Background(), "api/system/nodes", "")
+	require.NoError(t, err)
+	require.True(t, time.Since(start) >= 50*time.Millisecond)
+}
+
+func TestServer_Run(t *testing.T) {
+	s, httpStrategy, closeServer := startServer(t, Config{GrowthInterval: 5 * time.Millisecond, RotateAfterBytes: 1024})
+	defer closeServer()
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	require.NoError(t, s.Run(timeoutCtx))
+
+	client := livelog.NewClient(httpStrategy)
+	client.SetNodeId("node-1")
+	client.SetLogFileName("console.log")
+	out := &bytes.Buffer{}
+	require.NoError(t, client.CatLog(context.Background(), out))
+	require.True(t, len(out.String()) <= 1024+512)
+
+	scanner := parser.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		logLine := scanner.LogLine()
+		require.False(t, logLine.IsContinuation(), logLine.Raw)
+		require.Equal(t, "jfrt", logLine.Service)
+	}
+	rotated, err := ioutil.ReadDir(s.config.LogsDir + "/node-1/rotated")
+	require.NoError(t, err)
+	require.NotEmpty(t, rotated)
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,38 @@
+package fakeserver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Generates a line of the JFrog unified log format, where every 10th line is a warning,
+// and every 25th line is an error followed by a stack trace.
+func generateLine(now time.Time, nodeId, logName string, lineNumber int) string {
+	level, message := "INFO ", fmt.Sprintf("Fake log line %d of node %s", lineNumber, nodeId)
+	switch {
+	case lineNumber%25 == 0:
+		level, message = "ERROR", fmt.Sprintf("Fake failure %d\njava.lang.IllegalStateException: fake failure\n"+
+			"\tat org.jfrog.fake.FakeServer.grow(FakeServer.java:%d)\n\tat java.lang.Thread.run(Thread.java:834)", lineNumber, lineNumber)
+	case lineNumber%10 == 0:
+		level, message = "WARN ", fmt.Sprintf("Fake warning %d", lineNumber)
+	}
+	return fmt.Sprintf("%s [%-5s] [%s] [%016x] [o.j.f.FakeServer:%-3d] [fake-thread-%d] - %s\n",
+		now.UTC().Format("2006-01-02T15:04:05.000Z"), serviceType(logName), level, lineNumber, lineNumber%1000, lineNumber%4, message)
+}
+
+// Returns the service type a log of the passed name would be written by.
+func serviceType(logName string) string {
+	switch {
+	case strings.HasPrefix(logName, "access"):
+		return "jfac"
+	case strings.HasPrefix(logName, "router"):
+		return "jfrou"
+	case strings.HasPrefix(logName, "event"):
+		return "jfevt"
+	case strings.HasPrefix(logName, "metadata"):
+		return "jfmd"
+	default:
+		return "jfrt"
+	}
+}
@@ -0,0 +1,494 @@
+package livelog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHubWindowSize        = 1 << 20
+	defaultHubIdleTimeout       = time.Minute
+	defaultSubscriberBufferSize = 1 << 20
+)
+
+// Identifies a single log that a Hub polls.
+type HubKey struct {
+	ServerId string
+	NodeId   string
+	LogName  string
+}
+
+// What a Hub does with log data that a subscriber has no room left for in its buffer.
+type SlowConsumerPolicy int
+
+const (
+	// Drops the log data, and writes a notice of the number of dropped bytes before the log data following it.
+	DropSlowConsumer SlowConsumerPolicy = iota
+	// Blocks the poller until the subscriber makes room, which holds back every other subscriber of the log.
+	BlockSlowConsumer
+)
+
+// How a subscriber of a Hub is fed.
+type SubscriberConfig struct {
+	// Where the subscriber starts within the log data the poller holds, which is up to the window size of the Hub.
+	// The zero value starts from the oldest complete line held.
+	TailStart TailStart
+	// The number of bytes the subscriber may fall behind by, before the SlowConsumerPolicy applies.
+	// Zero or less defaults to 1MB.
+	BufferSize int64
+	// What is done with log data that the subscriber has no room left for, defaulting to DropSlowConsumer.
+	SlowConsumerPolicy SlowConsumerPolicy
+	// The io.Writer that notices, such as dropped log data, are written into. Notices are discarded by default.
+	NoticeOutput io.Writer
+}
+
+// Fans out the log data of every log to all of its subscribers, so that a single poller sends requests to the remote service
+// no matter how many subscribers follow the log.
+// The poller of a log starts with its first subscriber or ReadLog, holds the latest log data it read, up to the window size,
+// and stops once it has had no subscribers and no ReadLog for the idle timeout.
+type Hub struct {
+	newClient    func(key HubKey) (Client, error)
+	windowSize   int64
+	idleTimeout  time.Duration
+	noticeOutput io.Writer
+
+	mu      sync.Mutex
+	pollers map[HubKey]*hubPoller
+	closed  bool
+}
+
+// Creates a Hub whose pollers read logs using the clients created by the passed function, which may send requests.
+// The Hub sets the TailStart, RetryPolicy and notice output of every created client.
+func NewHub(newClient func(key HubKey) (Client, error)) *Hub {
+	return &Hub{
+		newClient:    newClient,
+		windowSize:   defaultHubWindowSize,
+		idleTimeout:  defaultHubIdleTimeout,
+		noticeOutput: ioutil.Discard,
+		pollers:      make(map[HubKey]*hubPoller),
+	}
+}
+
+// Sets the number of the latest bytes of log data every poller holds, defaulting to 1MB.
+func (h *Hub) SetWindowSize(windowSize int64) {
+	h.windowSize = windowSize
+}
+
+// Sets how long a poller keeps polling once it has no subscribers, defaulting to 1 minute.
+func (h *Hub) SetIdleTimeout(idleTimeout time.Duration) {
+	h.idleTimeout = idleTimeout
+}
+
+// Sets the io.Writer that notices of the pollers, such as a log being rotated, are written into.
+// Notices are discarded by default.
+func (h *Hub) SetNoticeOutput(noticeOutput io.Writer) {
+	h.noticeOutput = noticeOutput
+}
+
+// Stops every poller and closes its subscribers, after which subscribing fails.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for key, p := range h.pollers {
+		p.cancel()
+		// closed right away, since a poller blocked by a slow subscriber only stops once the subscriber makes room
+		p.stop(nil)
+		delete(h.pollers, key)
+	}
+}
+
+// Writes the log data of the passed key into the passed io.Writer, starting with the log data held by its poller,
+// according to the passed SubscriberConfig, and continuing with the log data the poller reads from then on.
+// When the log is rotated, the poller follows the new log from its beginning.
+// Any error of the poller, after it exhausted its retries, or during write is returned.
+// NOTE: this call blocks until cancellation of the passed context.Context.
+func (h *Hub) Subscribe(ctx context.Context, key HubKey, config SubscriberConfig, output io.Writer) error {
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultSubscriberBufferSize
+	}
+	if config.NoticeOutput == nil {
+		config.NoticeOutput = ioutil.Discard
+	}
+	sub := &hubSubscriber{config: config}
+	sub.cond = sync.NewCond(&sub.mu)
+	p, err := h.acquirePoller(key, true)
+	if err != nil {
+		return err
+	}
+	p.addSubscriber(sub)
+	defer h.releasePoller(p, sub)
+
+	stopWatching := make(chan struct{})
+	defer close(stopWatching)
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.close(nil)
+		case <-stopWatching:
+		}
+	}()
+	for {
+		chunks, dropped, ok, err := sub.next()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if dropped > 0 {
+			fmt.Fprintf(config.NoticeOutput, "- Dropped %d bytes of log %s of node %s, as the subscriber fell behind\n", dropped, key.LogName, key.NodeId)
+		}
+		for _, chunk := range chunks {
+			if _, writeErr := output.Write(chunk); writeErr != nil {
+				return writeErr
+			}
+		}
+		if !ok {
+			return err
+		}
+	}
+}
+
+// Writes the log data of the passed key following the passed page marker into the passed io.Writer,
+// and returns the page marker following that data, like Client.ReadLog.
+// Log data held by the poller of the log is written without sending a request, and other log data is read from the remote service,
+// after which a poller of the log is started if there is none.
+func (h *Hub) ReadLog(ctx context.Context, key HubKey, pageMarker int64, output io.Writer) (int64, error) {
+	if p, err := h.acquirePoller(key, false); err != nil {
+		return 0, err
+	} else if p != nil {
+		content, newPageMarker, ok := p.readWindow(pageMarker)
+		h.releasePoller(p, nil)
+		if ok {
+			_, err = output.Write(content)
+			return newPageMarker, err
+		}
+	}
+	client, err := h.newClient(key)
+	if err != nil {
+		return 0, err
+	}
+	client.SetNodeId(key.NodeId)
+	client.SetLogFileName(key.LogName)
+	newPageMarker, err := client.ReadLog(ctx, pageMarker, output)
+	if err != nil {
+		return 0, err
+	}
+	// started only once the log is known to be readable, so that requests of missing logs do not start pollers
+	p, err := h.acquirePoller(key, true)
+	if err == nil {
+		h.releasePoller(p, nil)
+	}
+	return newPageMarker, nil
+}
+
+// Returns the poller of the passed key, starting it if there is none and start is set, or nil otherwise.
+// The returned poller is kept running until it is passed to releasePoller.
+func (h *Hub) acquirePoller(key HubKey, start bool) (*hubPoller, error) {
+	var client Client
+	for {
+		h.mu.Lock()
+		if h.closed {
+			h.mu.Unlock()
+			return nil, errors.New("the hub is closed")
+		}
+		p := h.pollers[key]
+		if p == nil && start && client != nil {
+			p = h.startPoller(key, client)
+			h.pollers[key] = p
+		}
+		if p != nil {
+			p.users++
+		}
+		h.mu.Unlock()
+		if p != nil || !start {
+			return p, nil
+		}
+		// created without holding the lock, since creating a client may send requests
+		var err error
+		if client, err = h.newClient(key); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Removes the passed subscriber, if any, from the passed poller, which is stopped once it stays idle for the idle timeout.
+func (h *Hub) releasePoller(p *hubPoller, sub *hubSubscriber) {
+	if sub != nil {
+		p.removeSubscriber(sub)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p.users--
+	p.lastUsed = time.Now()
+	if p.users == 0 && p.idleTimer == nil {
+		p.idleTimer = time.AfterFunc(h.idleTimeout, func() { h.stopIfIdle(p) })
+	}
+}
+
+func (h *Hub) stopIfIdle(p *hubPoller) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p.idleTimer = nil
+	if p.users > 0 {
+		return
+	}
+	if idle := time.Since(p.lastUsed); idle < h.idleTimeout {
+		p.idleTimer = time.AfterFunc(h.idleTimeout-idle, func() { h.stopIfIdle(p) })
+		return
+	}
+	p.cancel()
+	if h.pollers[p.key] == p {
+		delete(h.pollers, p.key)
+	}
+}
+
+func (h *Hub) removePoller(p *hubPoller) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.pollers[p.key] == p {
+		delete(h.pollers, p.key)
+	}
+}
+
+// NOTE: the caller must hold the Hub's lock.
+func (h *Hub) startPoller(key HubKey, client Client) *hubPoller {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &hubPoller{
+		key:         key,
+		cancel:      cancel,
+		windowSize:  h.windowSize,
+		subscribers: make(map[*hubSubscriber]struct{}),
+	}
+	client.SetNodeId(key.NodeId)
+	client.SetLogFileName(key.LogName)
+	client.SetTailStart(TailStart{Bytes: h.windowSize})
+	client.SetRetryPolicy(DefaultRetryPolicy())
+	client.SetNoticeOutput(h.noticeOutput)
+	go func() {
+		err := client.TailLog(ctx, p)
+		if ctx.Err() == nil && err == nil {
+			// TailLog only returns without an error once the set TimeRange ended
+			err = errors.New("the log ended")
+		}
+		if ctx.Err() == nil {
+			fmt.Fprintf(h.noticeOutput, "- Stopped polling log %s of node %s: %v\n", key.LogName, key.NodeId, err)
+			h.removePoller(p)
+		} else {
+			err = nil
+		}
+		p.stop(err)
+	}()
+	return p
+}
+
+// Tails a single log on behalf of all of its subscribers, holding the latest log data it read.
+// It is the PollWriter of its client's TailLog, which tells it the page marker following the log data of every poll,
+// so that the page marker of every held byte is known.
+type hubPoller struct {
+	key        HubKey
+	cancel     context.CancelFunc
+	windowSize int64
+
+	// guarded by the Hub's lock
+	users     int
+	lastUsed  time.Time
+	idleTimer *time.Timer
+
+	mu sync.Mutex
+	// log data written by TailLog, whose poll is not done yet
+	pending     []byte
+	window      []byte
+	windowStart int64
+	hasWindow   bool
+	subscribers map[*hubSubscriber]struct{}
+	stopped     bool
+	err         error
+}
+
+func (p *hubPoller) Write(content []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = append(p.pending, content...)
+	return len(content), nil
+}
+
+// Moves the pending log data, which the passed page marker follows, into the window, and sends it to every subscriber.
+func (p *hubPoller) PollDone(pageMarker int64) error {
+	p.mu.Lock()
+	chunk := p.pending
+	p.pending = nil
+	chunkStart := pageMarker - int64(len(chunk))
+	if !p.hasWindow || chunkStart != p.windowStart+int64(len(p.window)) {
+		// the first log data, or the log data of a rotated log
+		p.window = nil
+		p.windowStart = chunkStart
+	}
+	p.window = append(p.window, chunk...)
+	if excess := int64(len(p.window)) - p.windowSize; excess > 0 {
+		p.window = append([]byte(nil), p.window[excess:]...)
+		p.windowStart += excess
+	}
+	firstWindow := !p.hasWindow
+	p.hasWindow = true
+	var waiting []*hubSubscriber
+	var waitingContent [][]byte
+	for sub := range p.subscribers {
+		if firstWindow {
+			sub.start(p.windowTail(sub.config.TailStart))
+			continue
+		}
+		waiting = append(waiting, sub)
+		waitingContent = append(waitingContent, chunk)
+	}
+	p.mu.Unlock()
+
+	// sent without holding the lock, so that blocking subscribers do not block reading the window
+	for idx, sub := range waiting {
+		sub.push(waitingContent[idx])
+	}
+	return nil
+}
+
+// Returns the held log data following the passed page marker, the page marker following it, and whether it is held.
+func (p *hubPoller) readWindow(pageMarker int64) ([]byte, int64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	windowEnd := p.windowStart + int64(len(p.window))
+	if !p.hasWindow || pageMarker < p.windowStart || pageMarker > windowEnd {
+		return nil, 0, false
+	}
+	return append([]byte(nil), p.window[pageMarker-p.windowStart:]...), windowEnd, true
+}
+
+// Returns the held log data the passed TailStart starts from, skipping a partial first line.
+// NOTE: the caller must hold the poller's lock.
+func (p *hubPoller) windowTail(tailStart TailStart) []byte {
+	content := p.window
+	if p.windowStart > 0 {
+		if idx := bytes.IndexByte(content, '\n'); idx >= 0 {
+			content = content[idx+1:]
+		} else {
+			content = nil
+		}
+	}
+	if tailStart.Lines > 0 {
+		content, _ = lastLines(content, tailStart.Lines)
+	} else if tailStart.Bytes > 0 && tailStart.Bytes < int64(len(content)) {
+		content = content[int64(len(content))-tailStart.Bytes:]
+	}
+	return append([]byte(nil), content...)
+}
+
+func (p *hubPoller) addSubscriber(sub *hubSubscriber) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		sub.close(p.err)
+		return
+	}
+	if p.hasWindow {
+		sub.start(p.windowTail(sub.config.TailStart))
+	}
+	p.subscribers[sub] = struct{}{}
+}
+
+func (p *hubPoller) removeSubscriber(sub *hubSubscriber) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.subscribers, sub)
+	sub.close(nil)
+}
+
+// Closes every subscriber with the passed error, once they were sent the log data before it.
+func (p *hubPoller) stop(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	p.err = err
+	for sub := range p.subscribers {
+		delete(p.subscribers, sub)
+		sub.close(err)
+	}
+}
+
+// The bounded buffer of log data a subscriber has yet to write.
+type hubSubscriber struct {
+	config SubscriberConfig
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	chunks   [][]byte
+	buffered int64
+	dropped  int64
+	closed   bool
+	err      error
+}
+
+// Buffers the log data the subscriber starts with, regardless of the buffer size.
+func (s *hubSubscriber) start(content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(content) > 0 {
+		s.chunks = append(s.chunks, content)
+		s.buffered += int64(len(content))
+	}
+	s.cond.Broadcast()
+}
+
+// Buffers the passed log data, applying the SlowConsumerPolicy when there is no room for it.
+// A chunk larger than the whole buffer is buffered once the buffer is empty.
+func (s *hubSubscriber) push(chunk []byte) {
+	if len(chunk) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for !s.closed && s.buffered > 0 && s.buffered+int64(len(chunk)) > s.config.BufferSize {
+		if s.config.SlowConsumerPolicy == DropSlowConsumer {
+			s.dropped += int64(len(chunk))
+			return
+		}
+		s.cond.Wait()
+	}
+	if s.closed {
+		return
+	}
+	s.chunks = append(s.chunks, chunk)
+	s.buffered += int64(len(chunk))
+	s.cond.Broadcast()
+}
+
+// Waits for buffered log data, and returns it along with the number of bytes dropped before it.
+// Once the subscriber is closed and its log data was returned, returns false with the error it was closed with.
+func (s *hubSubscriber) next() ([][]byte, int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.chunks) == 0 && s.dropped == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	chunks, dropped := s.chunks, s.dropped
+	s.chunks, s.buffered, s.dropped = nil, 0, 0
+	// room was made for blocked pushes
+	s.cond.Broadcast()
+	if len(chunks) > 0 || dropped > 0 {
+		return chunks, dropped, true, nil
+	}
+	return nil, 0, false, s.err
+}
+
+// Closes the subscriber with the passed error, unless it is already closed.
+func (s *hubSubscriber) close(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.err = err
+	s.cond.Broadcast()
+}
@@ -0,0 +1,380 @@
+package livelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/constants"
+	"github.com/hanoch-jfrog/forest/client/livelog/model"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testHubKey = HubKey{ServerId: "local-arti", NodeId: "node-1", LogName: "console.log"}
+
+// Serves a single growing log, counting the log data requests of every page marker.
+type growingLogHttpStrategy struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	content  string
+	requests map[int64]int
+}
+
+func (s *growingLogHttpStrategy) NodesEndpoint() string {
+	return mockHttpStrategyNodesEndpoint
+}
+
+func (s *growingLogHttpStrategy) SendGet(_ context.Context, endpoint, _ string) ([]byte, error) {
+	var pageMarker int64
+	_, err := fmt.Sscanf(endpoint, constants.DataEndpoint+"?$file_size=%d&", &pageMarker)
+	require.NoError(s.t, err)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[pageMarker]++
+	data := model.Data{PageMarker: int64(len(s.content))}
+	if pageMarker < int64(len(s.content)) {
+		data.Content = s.content[pageMarker:]
+	}
+	return json.Marshal(data)
+}
+
+func (s *growingLogHttpStrategy) append(content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content += content
+}
+
+func (s *growingLogHttpStrategy) requestCount(pageMarker int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[pageMarker]
+}
+
+func newTestHub(t *testing.T, content string, logsRefreshRate time.Duration) (*Hub, *growingLogHttpStrategy) {
+	httpStrategy := &growingLogHttpStrategy{t: t, content: content, requests: make(map[int64]int)}
+	hub := NewHub(func(key HubKey) (Client, error) {
+		require.Equal(t, testHubKey, key)
+		client := NewClient(httpStrategy)
+		client.SetLogsRefreshRate(logsRefreshRate)
+		// the requests of every page marker are counted, so no log data is read again to check for rotations
+		client.rotationCheckSize = 0
+		return client, nil
+	})
+	return hub, httpStrategy
+}
+
+// An io.Writer safe for concurrent use, so that it can be read while written.
+type hubTestBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *hubTestBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *hubTestBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	hub, httpStrategy := newTestHub(t, "one\ntwo\nthree\n", 10*time.Millisecond)
+	defer hub.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tailStarts := []TailStart{{}, {Lines: 1}, {Bytes: 6}}
+	outputs := make([]*hubTestBuffer, len(tailStarts))
+	errs := make(chan error, len(tailStarts))
+	for idx, tailStart := range tailStarts {
+		outputs[idx] = &hubTestBuffer{}
+		go func(config SubscriberConfig, output *hubTestBuffer) {
+			errs <- hub.Subscribe(ctx, testHubKey, config, output)
+		}(SubscriberConfig{TailStart: tailStart}, outputs[idx])
+	}
+	require.Eventually(t, func() bool { return outputs[1].String() != "" }, time.Second, 5*time.Millisecond)
+	httpStrategy.append("four\n")
+	expected := []string{"one\ntwo\nthree\nfour\n", "three\nfour\n", "three\nfour\n"}
+	require.Eventually(t, func() bool {
+		for idx, output := range outputs {
+			if output.String() != expected[idx] {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 5*time.Millisecond)
+	// a single poller probed the log size once, and read the new log data once
+	require.Equal(t, 1, httpStrategy.requestCount(probePageMarker))
+	require.Equal(t, 1, httpStrategy.requestCount(14))
+
+	cancel()
+	for range tailStarts {
+		require.NoError(t, <-errs)
+	}
+}
+
+func TestHub_ReadLog(t *testing.T) {
+	hub, httpStrategy := newTestHub(t, "one\ntwo\n", time.Hour)
+	defer hub.Close()
+	ctx := context.Background()
+
+	// the first read is sent to the remote service, and starts a poller
+	out := &bytes.Buffer{}
+	pageMarker, err := hub.ReadLog(ctx, testHubKey, 4, out)
+	require.NoError(t, err)
+	require.Equal(t, int64(8), pageMarker)
+	require.Equal(t, "two\n", out.String())
+	require.Eventually(t, func() bool { return httpStrategy.requestCount(0) == 1 }, time.Second, 5*time.Millisecond)
+
+	// reads within the window of the poller are served without requests
+	for _, pageMarker := range []int64{0, 4, 8} {
+		out.Reset()
+		newPageMarker, err := hub.ReadLog(ctx, testHubKey, pageMarker, out)
+		require.NoError(t, err)
+		require.Equal(t, int64(8), newPageMarker)
+		require.Equal(t, "one\ntwo\n"[pageMarker:], out.String())
+	}
+	require.Equal(t, 1, httpStrategy.requestCount(0))
+	require.Equal(t, 1, httpStrategy.requestCount(4))
+	require.Equal(t, 0, httpStrategy.requestCount(8))
+
+	// reads beyond the window are sent to the remote service
+	_, err = hub.ReadLog(ctx, testHubKey, probePageMarker, out)
+	require.NoError(t, err)
+	require.Equal(t, 2, httpStrategy.requestCount(probePageMarker))
+}
+
+func TestHub_idleTimeout(t *testing.T) {
+	hub, _ := newTestHub(t, "one\n", 10*time.Millisecond)
+	defer hub.Close()
+	hub.SetIdleTimeout(20 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- hub.Subscribe(ctx, testHubKey, SubscriberConfig{}, &hubTestBuffer{})
+	}()
+	require.Eventually(t, func() bool { return hubPollerCount(hub) == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	require.Eventually(t, func() bool { return hubPollerCount(hub) == 0 }, time.Second, 5*time.Millisecond)
+}
+
+// A Checkpoint safe for concurrent use, so that it can be read while the poller saves into it.
+type lockedCheckpoint struct {
+	mu         sync.Mutex
+	checkpoint memoryCheckpoint
+}
+
+func (c *lockedCheckpoint) Load() (int64, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkpoint.Load()
+}
+
+func (c *lockedCheckpoint) Save(pageMarker int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkpoint.Save(pageMarker)
+}
+
+func (c *lockedCheckpoint) pageMarkers() []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int64(nil), c.checkpoint.pageMarkers...)
+}
+
+func TestHub_Subscribe_checkpoint(t *testing.T) {
+	httpStrategy := &growingLogHttpStrategy{t: t, content: "one\ntwo\n", requests: make(map[int64]int)}
+	// the checkpoint of a created client is kept, and saved into after every poll
+	checkpoint := &lockedCheckpoint{}
+	hub := NewHub(func(key HubKey) (Client, error) {
+		client := NewClient(httpStrategy)
+		client.SetLogsRefreshRate(10 * time.Millisecond)
+		client.SetCheckpoint(checkpoint)
+		return client, nil
+	})
+	defer hub.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	output := &hubTestBuffer{}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- hub.Subscribe(ctx, testHubKey, SubscriberConfig{}, output)
+	}()
+	require.Eventually(t, func() bool { return output.String() == "one\ntwo\n" }, time.Second, 5*time.Millisecond)
+	httpStrategy.append("three\n")
+	require.Eventually(t, func() bool { return output.String() == "one\ntwo\nthree\n" }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		pageMarkers := checkpoint.pageMarkers()
+		return len(pageMarkers) > 0 && pageMarkers[len(pageMarkers)-1] == 14
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, []int64{8, 14}, checkpoint.pageMarkers())
+	cancel()
+	require.NoError(t, <-errs)
+}
+
+func TestHub_Close(t *testing.T) {
+	hub, _ := newTestHub(t, "one\n", 10*time.Millisecond)
+	hub.Close()
+	err := hub.Subscribe(context.Background(), testHubKey, SubscriberConfig{}, &hubTestBuffer{})
+	require.EqualError(t, err, "the hub is closed")
+}
+
+func hubPollerCount(hub *Hub) int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return len(hub.pollers)
+}
+
+func TestHubPoller_window(t *testing.T) {
+	p := &hubPoller{windowSize: 10, subscribers: make(map[*hubSubscriber]struct{})}
+	write := func(content string, pageMarker int64) {
+		_, err := p.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, p.PollDone(pageMarker))
+	}
+	read := func(pageMarker int64) (string, int64, bool) {
+		content, newPageMarker, ok := p.readWindow(pageMarker)
+		return string(content), newPageMarker, ok
+	}
+
+	_, _, ok := read(0)
+	require.False(t, ok)
+	write("two\nthree\n", 14)
+	content, pageMarker, ok := read(8)
+	require.True(t, ok)
+	require.Equal(t, "three\n", content)
+	require.Equal(t, int64(14), pageMarker)
+	_, _, ok = read(3)
+	require.False(t, ok)
+
+	// the oldest log data is dropped to fit the window size
+	write("four\n", 19)
+	content, _, ok = read(9)
+	require.True(t, ok)
+	require.Equal(t, "hree\nfour\n", content)
+	_, _, ok = read(8)
+	require.False(t, ok)
+	require.Equal(t, "four\n", string(p.windowTail(TailStart{})))
+	require.Equal(t, "our\n", string(p.windowTail(TailStart{Bytes: 4})))
+
+	// log data that does not follow the window, as the log was rotated, replaces it
+	write("new\n", 4)
+	content, pageMarker, ok = read(0)
+	require.True(t, ok)
+	require.Equal(t, "new\n", content)
+	require.Equal(t, int64(4), pageMarker)
+}
+
+func TestHubSubscriber_slowConsumerPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SlowConsumerPolicy
+	}{
+		{"drop", DropSlowConsumer},
+		{"block", BlockSlowConsumer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &hubSubscriber{config: SubscriberConfig{BufferSize: 8, SlowConsumerPolicy: tt.policy}}
+			sub.cond = sync.NewCond(&sub.mu)
+			sub.push([]byte("one\n"))
+			sub.push([]byte("two\n"))
+			pushed := make(chan struct{})
+			go func() {
+				sub.push([]byte("three\n"))
+				close(pushed)
+			}()
+
+			if tt.policy == DropSlowConsumer {
+				<-pushed
+				chunks, dropped, ok, err := sub.next()
+				require.True(t, ok)
+				require.NoError(t, err)
+				require.Equal(t, int64(6), dropped)
+				require.Equal(t, "one\ntwo\n", string(bytes.Join(chunks, nil)))
+				return
+			}
+			select {
+			case <-pushed:
+				t.Fatal("push did not block on a full buffer")
+			case <-time.After(20 * time.Millisecond):
+			}
+			chunks, dropped, ok, err := sub.next()
+			require.True(t, ok)
+			require.NoError(t, err)
+			require.Zero(t, dropped)
+			require.Equal(t, "one\ntwo\n", string(bytes.Join(chunks, nil)))
+			<-pushed
+			chunks, _, _, _ = sub.next()
+			require.Equal(t, "three\n", string(bytes.Join(chunks, nil)))
+		})
+	}
+}
+
+func TestHub_Subscribe_droppedNotice(t *testing.T) {
+	hub, _ := newTestHub(t, strings.Repeat("line\n", 4), 10*time.Millisecond)
+	defer hub.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the output blocks until released, so that the subscriber falls behind
+	release := make(chan struct{})
+	output := &blockingWriter{writing: make(chan struct{}, 1), release: release, output: &hubTestBuffer{}}
+	notices := &hubTestBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- hub.Subscribe(ctx, testHubKey, SubscriberConfig{BufferSize: 5, NoticeOutput: notices}, output)
+	}()
+	// the subscriber is blocked writing the log data it started with
+	<-output.writing
+	hub.mu.Lock()
+	p := hub.pollers[testHubKey]
+	hub.mu.Unlock()
+	// pushed directly, as the log data the poller would read while the subscriber is blocked
+	for _, sub := range subscribersOf(p) {
+		sub.push([]byte("more\n"))
+		sub.push([]byte("dropped\n"))
+	}
+	close(release)
+	require.Eventually(t, func() bool {
+		return notices.String() == "- Dropped 8 bytes of log console.log of node node-1, as the subscriber fell behind\n"
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func subscribersOf(p *hubPoller) []*hubSubscriber {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var subs []*hubSubscriber
+	for sub := range p.subscribers {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// Signals every write on the writing channel, when it has room, and blocks it until release is closed.
+type blockingWriter struct {
+	writing chan struct{}
+	release chan struct{}
+	output  *hubTestBuffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	select {
+	case w.writing <- struct{}{}:
+	default:
+	}
+	<-w.release
+	return w.output.Write(p)
+}
@@ -7,6 +7,35 @@ import (
 	"time"
 )
 
+// Where TailLog starts following the log from.
+// The zero value starts from the beginning of the log.
+type TailStart struct {
+	// Starts from the last Lines lines of the log.
+	Lines int64
+	// Starts from the last Bytes bytes of the log. Ignored when Lines is set.
+	Bytes int64
+}
+
+func (t TailStart) isFromBeginning() bool {
+	return t.Lines <= 0 && t.Bytes <= 0
+}
+
+// Persists the page marker TailLog has read a single log up to, so that a later TailLog can resume from it.
+type Checkpoint interface {
+	// Returns the saved page marker, and false when none was saved.
+	Load() (pageMarker int64, ok bool, err error)
+	// Saves the passed page marker, replacing the one saved before it.
+	Save(pageMarker int64) error
+}
+
+// An io.Writer of TailLog that is also told where the log data of every poll ends.
+type PollWriter interface {
+	io.Writer
+	// Called once the log data of a poll, if any, was written, with the page marker following the log data written so far.
+	// Called for every poll, including the one reading where tailing starts from, and polls without new log data.
+	PollDone(pageMarker int64) error
+}
+
 type Client interface {
 	// Queries and returns the available nodes from the remote service.
 	GetServiceNodeIds(ctx context.Context) ([]string, error)
@@ -23,15 +52,49 @@ type Client interface {
 	// Sets the refresh rate interval between each log request.
 	SetLogsRefreshRate(logsRefreshRate time.Duration)
 
+	// Sets where TailLog starts following the log from, defaulting to the beginning of the log.
+	SetTailStart(tailStart TailStart)
+
+	// Sets how failed log requests are retried by TailLog, defaulting to no retries.
+	SetRetryPolicy(retryPolicy RetryPolicy)
+
+	// Sets the TimeRange of the log data written by CatLog and TailLog, defaulting to the whole log.
+	// A Since takes precedence over the TailStart, and TailLog returns once the Until has passed.
+	SetTimeRange(timeRange TimeRange)
+
+	// Sets the Checkpoint that TailLog resumes from and saves the page marker it has read up to into.
+	// A saved page marker takes precedence over the TailStart. No checkpoint is used by default.
+	SetCheckpoint(checkpoint Checkpoint)
+
+	// Sets the io.Writer that notices, such as the log being rotated, are written into.
+	// Notices are discarded by default.
+	SetNoticeOutput(noticeOutput io.Writer)
+
 	// Writes a single log data snapshot from the remote service into the passed io.Writer.
 	// The configured node id and log file name are used.
 	// Any error during read or write is returned.
 	CatLog(ctx context.Context, output io.Writer) error
 
+	// Writes the log data following the passed page marker from the remote service into the passed io.Writer,
+	// and returns the page marker following that data, which is the size of the log.
+	// The configured node id and log file name are used.
+	// Any error during read or write is returned.
+	ReadLog(ctx context.Context, pageMarker int64, output io.Writer) (int64, error)
+
 	// Writes continuous log data snapshots from the remote service into the passed io.Writer,
-	// on an interval set by the LogsRefreshRate, defaulting to 1 second.
+	// on an interval set by the LogsRefreshRate, defaulting to 1 second, starting from the set TailStart.
 	// The configured node id and log file name are used.
+	// When the log is rotated or truncated, a notice is written and the new log is followed from its beginning.
+	// Failed requests are retried according to the RetryPolicy, resuming from the last successfully read log data.
+	// When a Checkpoint is set, the page marker following every written log data snapshot is saved into it.
+	// When the io.Writer is a PollWriter, and no Until is set, it is told the page marker following the log data of every poll.
 	// Any errors during read or write is returned.
 	// NOTE: this call blocks until cancellation of the passed context.Context.
 	TailLog(ctx context.Context, output io.Writer) error
+
+	// Sends the log data TailLog would write as a Chunk of complete lines per log data snapshot, in a goroutine,
+	// holding back a trailing partial line until a later snapshot completes it.
+	// The chunks channel is closed once tailing stops, after which the error it stopped with, if any, is sent on the errors channel,
+	// which is then closed as well. Cancellation of the passed context.Context is not an error.
+	Stream(ctx context.Context) (chunks <-chan Chunk, errs <-chan error)
 }
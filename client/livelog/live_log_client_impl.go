@@ -9,6 +9,9 @@ import (
 	"github.com/hanoch-jfrog/forest/client/livelog/model"
 	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
 	"io"
+	"io/ioutil"
+	"math"
+	"net/url"
 	"time"
 )
 
@@ -16,6 +19,13 @@ const (
 	defaultRequestTimeout    = 15 * time.Second
 	defaultLogRequestTimeout = time.Minute
 	defaultLogsRefreshRate   = time.Second
+	// A page marker beyond the end of any log, used to query the current page marker without its log data.
+	probePageMarker = math.MaxInt64
+	// The line length assumed when reading back the last lines of a log; doubled until enough lines are read.
+	estimatedLineSize = 256
+	// The number of bytes before the page marker that are read again by every poll, to tell whether the log was replaced
+	// by a new log that already grew past the page marker.
+	defaultRotationCheckSize = 64
 )
 
 type client struct {
@@ -23,12 +33,21 @@ type client struct {
 	nodeId          string
 	logFileName     string
 	logsRefreshRate time.Duration
+	tailStart       TailStart
+	retryPolicy     RetryPolicy
+	timeRange       TimeRange
+	checkpoint      Checkpoint
+	noticeOutput    io.Writer
+	// the number of bytes before the page marker that are read again by every poll, or 0 to read none
+	rotationCheckSize int
 }
 
 func NewClient(strategy strategy.Http) *client {
 	return &client{
-		httpStrategy:    strategy,
-		logsRefreshRate: defaultLogsRefreshRate,
+		httpStrategy:      strategy,
+		logsRefreshRate:   defaultLogsRefreshRate,
+		noticeOutput:      ioutil.Discard,
+		rotationCheckSize: defaultRotationCheckSize,
 	}
 }
 
@@ -90,18 +109,146 @@ func (s *client) SetLogsRefreshRate(logsRefreshRate time.Duration) {
 	s.logsRefreshRate = logsRefreshRate
 }
 
-func (s *client) CatLog(ctx context.Context, output io.Writer) error {
-	logReader, _, err := s.doCatLog(ctx, 0)
-	if err != nil {
+func (s *client) SetTailStart(tailStart TailStart) {
+	s.tailStart = tailStart
+}
+
+func (s *client) SetRetryPolicy(retryPolicy RetryPolicy) {
+	s.retryPolicy = retryPolicy
+}
+
+func (s *client) SetTimeRange(timeRange TimeRange) {
+	s.timeRange = timeRange
+}
+
+func (s *client) SetCheckpoint(checkpoint Checkpoint) {
+	s.checkpoint = checkpoint
+}
+
+func (s *client) SetNoticeOutput(noticeOutput io.Writer) {
+	s.noticeOutput = noticeOutput
+}
+
+func (s *client) CatLog(ctx context.Context, output io.Writer) (err error) {
+	var logReader io.Reader
+	if s.timeRange.hasSince() {
+		if err = s.validateLogSource(); err != nil {
+			return err
+		}
+		var content []byte
+		if content, _, err = s.readSince(ctx); err != nil {
+			return err
+		}
+		logReader = bytes.NewReader(content)
+	} else if logReader, _, err = s.doCatLog(ctx, 0); err != nil {
 		return err
 	}
+	if s.timeRange.hasUntil() {
+		untilOutput := &untilWriter{output: output, until: s.timeRange.Until}
+		defer func() {
+			if closeErr := untilOutput.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		output = untilOutput
+	}
 	_, err = io.Copy(output, logReader)
 	return err
 }
 
+func (s *client) ReadLog(ctx context.Context, pageMarker int64, output io.Writer) (int64, error) {
+	logReader, newPageMarker, err := s.doCatLog(ctx, pageMarker)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = io.Copy(output, logReader); err != nil {
+		return 0, err
+	}
+	return newPageMarker, nil
+}
+
 func (s *client) TailLog(ctx context.Context, output io.Writer) error {
-	pageMarker := int64(0)
+	return s.tailLog(ctx, output, func(int64) {})
+}
+
+func (s *client) Stream(ctx context.Context) (<-chan Chunk, <-chan error) {
+	chunks := make(chan Chunk)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(chunks)
+		output := &chunkWriter{ctx: ctx, nodeId: s.nodeId, logFileName: s.logFileName, chunks: chunks}
+		err := s.tailLog(ctx, output, output.startAt)
+		if err == nil {
+			// the time range ended, so the partial line will not be completed
+			err = output.flush()
+		}
+		if err = ignoreIfCancelled(ctx, err); err != nil {
+			errs <- err
+		}
+	}()
+	return chunks, errs
+}
+
+// Performs TailLog, calling startAt with the page marker of the log data written following it,
+// before writing log data that does not follow the log data written before it.
+func (s *client) tailLog(ctx context.Context, output io.Writer, startAt func(pageMarker int64)) (err error) {
+	if err = s.validateLogSource(); err != nil {
+		return err
+	}
+	pollDone := func(int64) error { return nil }
+	if pollOutput, ok := output.(PollWriter); ok && !s.timeRange.hasUntil() {
+		pollDone = pollOutput.PollDone
+	}
+	var untilOutput *untilWriter
+	if s.timeRange.hasUntil() {
+		untilOutput = &untilWriter{output: output, until: s.timeRange.Until}
+		defer func() {
+			if closeErr := untilOutput.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		output = untilOutput
+	}
+	pageMarker, resumed, err := s.loadCheckpoint()
+	if err != nil {
+		return err
+	}
+	// the last log data before the page marker, which must be read again, unchanged, ahead of new log data
+	var tail []byte
+	if resumed && pageMarker > 0 && s.rotationCheckSize > 0 {
+		// a saved page marker follows a whole line, unless the log was rotated since it was saved
+		tail = []byte("\n")
+	}
 	curLogRefreshRate := time.Duration(0)
+	if !resumed && (s.timeRange.hasSince() || !s.tailStart.isFromBeginning()) {
+		var startContent []byte
+		if s.timeRange.hasSince() {
+			startContent, pageMarker, err = s.readSince(ctx)
+		} else {
+			startContent, pageMarker, err = s.readTailStart(ctx)
+		}
+		if err != nil {
+			return ignoreIfCancelled(ctx, err)
+		}
+		startAt(pageMarker - int64(len(startContent)))
+		if _, err = output.Write(startContent); err != nil {
+			return err
+		}
+		tail = s.lastBytes(nil, startContent)
+		if err = pollDone(pageMarker); err != nil {
+			return err
+		}
+		if err = s.saveCheckpoint(pageMarker); err != nil {
+			return err
+		}
+		if s.isPastUntil(untilOutput) {
+			return nil
+		}
+		curLogRefreshRate = s.logsRefreshRate
+	} else {
+		startAt(pageMarker)
+	}
 
 	for {
 		select {
@@ -111,32 +258,189 @@ func (s *client) TailLog(ctx context.Context, output io.Writer) error {
 			if curLogRefreshRate == 0 {
 				curLogRefreshRate = s.logsRefreshRate
 			}
-			var logReader io.Reader
-			var err error
-
-			logReader, pageMarker, err = s.doCatLog(ctx, pageMarker)
+			content, newPageMarker, err := s.readFrom(ctx, pageMarker-int64(len(tail)))
 			if err != nil {
+				return ignoreIfCancelled(ctx, err)
+			}
+			// a page marker behind the requested one means the log was rotated or truncated,
+			// and a changed tail means it was rotated, and the new log grew past the page marker
+			if newPageMarker < pageMarker || !bytes.HasPrefix(content, tail) {
+				s.writeNotice("- Log %s of node %s was rotated, following the new log from its beginning\n", s.logFileName, s.nodeId)
+				content, newPageMarker, err = s.readFrom(ctx, 0)
+				if err != nil {
+					return ignoreIfCancelled(ctx, err)
+				}
+				startAt(0)
+				tail = nil
+			} else {
+				content = content[len(tail):]
+			}
+			if _, err = output.Write(content); err != nil {
 				return err
 			}
-			_, err = io.Copy(output, logReader)
-			if err != nil {
+			tail = s.lastBytes(tail, content)
+			if err = pollDone(newPageMarker); err != nil {
 				return err
 			}
+			if newPageMarker != pageMarker {
+				if err = s.saveCheckpoint(newPageMarker); err != nil {
+					return err
+				}
+			}
+			pageMarker = newPageMarker
+			if s.isPastUntil(untilOutput) {
+				return nil
+			}
 		}
 	}
 }
 
-func (s *client) doCatLog(ctx context.Context, lastPageMarker int64) (logReader io.Reader, newPageMarker int64, err error) {
-	if s.nodeId == "" {
-		return nil, 0, fmt.Errorf("node id must be set")
+// Returns whether tailing is done, as the passed untilWriter has seen an entry after the Until of the set TimeRange,
+// or the Until has passed and every entry written before it was already read.
+func (s *client) isPastUntil(untilOutput *untilWriter) bool {
+	return untilOutput != nil && (untilOutput.passed || time.Now().After(s.timeRange.Until))
+}
+
+// Returns the page marker saved in the set Checkpoint, and whether there was one.
+func (s *client) loadCheckpoint() (int64, bool, error) {
+	if s.checkpoint == nil {
+		return 0, false, nil
 	}
-	if s.logFileName == "" {
-		return nil, 0, fmt.Errorf("log file name must be set")
+	pageMarker, ok, err := s.checkpoint.Load()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed loading the checkpoint of log %s of node %s: %w", s.logFileName, s.nodeId, err)
+	}
+	if ok {
+		s.writeNotice("- Resuming log %s of node %s from page marker %d\n", s.logFileName, s.nodeId, pageMarker)
+	}
+	return pageMarker, ok, nil
+}
+
+func (s *client) saveCheckpoint(pageMarker int64) error {
+	if s.checkpoint == nil {
+		return nil
+	}
+	if err := s.checkpoint.Save(pageMarker); err != nil {
+		return fmt.Errorf("failed saving the checkpoint of log %s of node %s: %w", s.logFileName, s.nodeId, err)
+	}
+	return nil
+}
+
+func (s *client) writeNotice(format string, args ...interface{}) {
+	if s.noticeOutput != nil {
+		fmt.Fprintf(s.noticeOutput, format, args...)
+	}
+}
+
+// Reads the log data the set TailStart starts from, returning it along with the page marker following it.
+func (s *client) readTailStart(ctx context.Context) (content []byte, pageMarker int64, err error) {
+	_, logSize, err := s.doCatLogWithRetries(ctx, probePageMarker)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.tailStart.Lines <= 0 {
+		return s.readFrom(ctx, logSize-s.tailStart.Bytes)
+	}
+
+	for windowSize := s.tailStart.Lines * estimatedLineSize; ; windowSize *= 2 {
+		offset := logSize - windowSize
+		content, pageMarker, err = s.readFrom(ctx, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		lastLines, found := lastLines(content, s.tailStart.Lines)
+		if found || offset <= 0 {
+			return lastLines, pageMarker, nil
+		}
+	}
+}
+
+func (s *client) readFrom(ctx context.Context, offset int64) ([]byte, int64, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	logReader, pageMarker, err := s.doCatLogWithRetries(ctx, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	content, err := ioutil.ReadAll(logReader)
+	return content, pageMarker, err
+}
+
+// Returns the last bytes of the passed tail followed by the passed content, up to the rotation check size, in a new slice.
+func (s *client) lastBytes(tail, content []byte) []byte {
+	if len(content) >= s.rotationCheckSize {
+		return append([]byte(nil), content[len(content)-s.rotationCheckSize:]...)
+	}
+	if keep := s.rotationCheckSize - len(content); len(tail) > keep {
+		tail = tail[len(tail)-keep:]
+	}
+	return append(append([]byte(nil), tail...), content...)
+}
+
+// Returns the last lines of the passed content, counting a trailing partial line as a line,
+// and whether the content holds that many complete lines, preceded by a new line.
+func lastLines(content []byte, lines int64) ([]byte, bool) {
+	end := len(content)
+	if end > 0 && content[end-1] == '\n' {
+		end--
+	}
+	for idx := end - 1; idx >= 0; idx-- {
+		if content[idx] != '\n' {
+			continue
+		}
+		if lines--; lines == 0 {
+			return content[idx+1:], true
+		}
+	}
+	return content, false
+}
+
+// Performs doCatLog, retrying failed requests according to the set RetryPolicy.
+// Since the page marker is only advanced by successful requests, a retry resumes from the last good page marker.
+func (s *client) doCatLogWithRetries(ctx context.Context, lastPageMarker int64) (io.Reader, int64, error) {
+	var firstFailure time.Time
+	for retries := 0; ; retries++ {
+		logReader, newPageMarker, err := s.doCatLog(ctx, lastPageMarker)
+		if err == nil {
+			if retries > 0 {
+				s.writeNotice("- Reconnected to node %s\n", s.nodeId)
+			}
+			return logReader, newPageMarker, nil
+		}
+		if retries == 0 {
+			firstFailure = time.Now()
+		}
+		if ctx.Err() != nil || !s.retryPolicy.allowsRetry(retries, time.Since(firstFailure)) {
+			return nil, 0, err
+		}
+		backoff := s.retryPolicy.backoff(retries + 1)
+		s.writeNotice("- Request for log %s of node %s failed: %v; reconnecting in %v...\n", s.logFileName, s.nodeId, err, backoff.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return nil, 0, err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// Returns nil if the passed error occurred due to the cancellation of the passed context, and the error otherwise.
+func ignoreIfCancelled(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+func (s *client) doCatLog(ctx context.Context, lastPageMarker int64) (logReader io.Reader, newPageMarker int64, err error) {
+	if err = s.validateLogSource(); err != nil {
+		return nil, 0, err
 	}
 
 	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, defaultLogRequestTimeout)
 	defer cancelTimeout()
-	endpoint := fmt.Sprintf("%s?$file_size=%d&id=%s", constants.DataEndpoint, lastPageMarker, s.logFileName)
+	// the log name is escaped, so that it cannot add parameters of its own
+	endpoint := fmt.Sprintf("%s?$file_size=%d&%s", constants.DataEndpoint, lastPageMarker, url.Values{"id": {s.logFileName}}.Encode())
 	resBody, err := s.httpStrategy.SendGet(timeoutCtx, endpoint, s.nodeId)
 	if err != nil {
 		return nil, 0, err
@@ -150,3 +454,13 @@ func (s *client) doCatLog(ctx context.Context, lastPageMarker int64) (logReader
 	logDataBuf := bytes.NewBufferString(logData.Content)
 	return logDataBuf, logData.PageMarker, nil
 }
+
+func (s *client) validateLogSource() error {
+	if s.nodeId == "" {
+		return fmt.Errorf("node id must be set")
+	}
+	if s.logFileName == "" {
+		return fmt.Errorf("log file name must be set")
+	}
+	return nil
+}
@@ -3,11 +3,14 @@ package livelog
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/hanoch-jfrog/forest/client/livelog/constants"
 	"github.com/hanoch-jfrog/forest/client/livelog/model"
 	"github.com/stretchr/testify/require"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -199,6 +202,24 @@ func Test_client_CatLog(t *testing.T) {
 	}
 }
 
+func Test_client_ReadLog(t *testing.T) {
+	s := NewClient(&mockFileHttpStrategy{t: t, content: "first\nsecond\n"})
+	s.SetNodeId("node-1")
+	s.SetLogFileName("one.log")
+
+	out := &bytes.Buffer{}
+	pageMarker, err := s.ReadLog(context.Background(), 6, out)
+	require.NoError(t, err)
+	require.Equal(t, "second\n", out.String())
+	require.Equal(t, int64(13), pageMarker)
+
+	out.Reset()
+	pageMarker, err = s.ReadLog(context.Background(), pageMarker, out)
+	require.NoError(t, err)
+	require.Empty(t, out.String())
+	require.Equal(t, int64(13), pageMarker)
+}
+
 func Test_client_TailLog_errors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -282,6 +303,427 @@ func Test_client_TailLog(t *testing.T) {
 	}
 }
 
+func Test_client_TailLog_tailStart(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		tailStart TailStart
+		want      string
+	}{
+		{
+			name:      "last lines",
+			content:   "one\ntwo\nthree\nfour\n",
+			tailStart: TailStart{Lines: 2},
+			want:      "three\nfour\n",
+		},
+		{
+			name:      "last lines with partial line",
+			content:   "one\ntwo\nthree\nfo",
+			tailStart: TailStart{Lines: 2},
+			want:      "three\nfo",
+		},
+		{
+			name:      "more lines than log",
+			content:   "one\ntwo\n",
+			tailStart: TailStart{Lines: 5},
+			want:      "one\ntwo\n",
+		},
+		{
+			name:      "last lines beyond window estimate",
+			content:   strings.Repeat("x", 3*estimatedLineSize) + "\n" + strings.Repeat("y", 3*estimatedLineSize) + "\n",
+			tailStart: TailStart{Lines: 1},
+			want:      strings.Repeat("y", 3*estimatedLineSize) + "\n",
+		},
+		{
+			name:      "last bytes",
+			content:   "one\ntwo\nthree\n",
+			tailStart: TailStart{Bytes: 4},
+			want:      "ree\n",
+		},
+		{
+			name:      "more bytes than log",
+			content:   "one\n",
+			tailStart: TailStart{Bytes: 100},
+			want:      "one\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &client{
+				httpStrategy:    &mockFileHttpStrategy{t: t, content: tt.content},
+				nodeId:          "node-1",
+				logFileName:     "one.log",
+				logsRefreshRate: time.Hour,
+				tailStart:       tt.tailStart,
+			}
+			timeoutCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			out := &bytes.Buffer{}
+			require.NoError(t, s.TailLog(timeoutCtx, out))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func Test_client_TailLog_rotation(t *testing.T) {
+	// the log grows, is rotated into a log shorter than the last page marker, and then grows again
+	logStates := []string{"one\ntwo\n", "one\ntwo\nthree\n", "new\n", "new\n", "new\nnext\n"}
+	var requestedPageMarkers []int64
+	s := &client{
+		httpStrategy: &mockFuncHttpStrategy{
+			sendGet: func(endpoint, _ string) ([]byte, error) {
+				var pageMarker int64
+				_, err := fmt.Sscanf(endpoint, constants.DataEndpoint+"?$file_size=%d&", &pageMarker)
+				require.NoError(t, err)
+				content := logStates[len(logStates)-1]
+				if len(requestedPageMarkers) < len(logStates) {
+					content = logStates[len(requestedPageMarkers)]
+				}
+				requestedPageMarkers = append(requestedPageMarkers, pageMarker)
+				data := model.Data{PageMarker: int64(len(content))}
+				if pageMarker < int64(len(content)) {
+					data.Content = content[pageMarker:]
+				}
+				return json.Marshal(data)
+			},
+		},
+		nodeId:          "node-1",
+		logFileName:     "one.log",
+		logsRefreshRate: 10 * time.Millisecond,
+	}
+	notices := &bytes.Buffer{}
+	s.SetNoticeOutput(notices)
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	out := &bytes.Buffer{}
+	require.NoError(t, s.TailLog(timeoutCtx, out))
+	require.Equal(t, []int64{0, 8, 14, 0, 4}, requestedPageMarkers[:5])
+	require.Equal(t, "one\ntwo\nthree\nnew\nnext\n", out.String())
+	require.Equal(t, "- Log one.log of node node-1 was rotated, following the new log from its beginning\n", notices.String())
+}
+
+func Test_client_TailLog_rotationPastPageMarker(t *testing.T) {
+	// the log grows, and is rotated into a log longer than the last page marker between polls, which then grows again
+	logStates := []string{"one\ntwo\n", "one\ntwo\nthree\n", "new-one\nnew-two\n", "new-one\nnew-two\n", "new-one\nnew-two\nnext\n"}
+	var requestedPageMarkers []int64
+	s := NewClient(&mockFuncHttpStrategy{
+		sendGet: func(endpoint, _ string) ([]byte, error) {
+			var pageMarker int64
+			_, err := fmt.Sscanf(endpoint, constants.DataEndpoint+"?$file_size=%d&", &pageMarker)
+			require.NoError(t, err)
+			content := logStates[len(logStates)-1]
+			if len(requestedPageMarkers) < len(logStates) {
+				content = logStates[len(requestedPageMarkers)]
+			}
+			requestedPageMarkers = append(requestedPageMarkers, pageMarker)
+			data := model.Data{PageMarker: int64(len(content))}
+			if pageMarker < int64(len(content)) {
+				data.Content = content[pageMarker:]
+			}
+			return json.Marshal(data)
+		},
+	})
+	s.SetNodeId("node-1")
+	s.SetLogFileName("one.log")
+	s.SetLogsRefreshRate(10 * time.Millisecond)
+	s.rotationCheckSize = 4
+	notices := &bytes.Buffer{}
+	s.SetNoticeOutput(notices)
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	out := &bytes.Buffer{}
+	require.NoError(t, s.TailLog(timeoutCtx, out))
+	// every poll reads the last 4 bytes again, and the ones of the log before the rotation are found changed
+	require.Equal(t, []int64{0, 4, 10, 0, 12}, requestedPageMarkers[:5])
+	require.Equal(t, "one\ntwo\nthree\nnew-one\nnew-two\nnext\n", out.String())
+	require.Equal(t, "- Log one.log of node node-1 was rotated, following the new log from its beginning\n", notices.String())
+}
+
+func Test_client_Stream(t *testing.T) {
+	// the log grows by partial lines, and is then rotated
+	logStates := []string{"one\ntw", "one\ntwo\nthr", "one\ntwo\nthree\n", "new\n"}
+	requests := 0
+	s := &client{
+		httpStrategy: &mockFuncHttpStrategy{
+			sendGet: func(endpoint, _ string) ([]byte, error) {
+				var pageMarker int64
+				_, err := fmt.Sscanf(endpoint, constants.DataEndpoint+"?$file_size=%d&", &pageMarker)
+				require.NoError(t, err)
+				content := logStates[len(logStates)-1]
+				if requests < len(logStates) {
+					content = logStates[requests]
+				}
+				requests++
+				data := model.Data{PageMarker: int64(len(content))}
+				if pageMarker < int64(len(content)) {
+					data.Content = content[pageMarker:]
+				}
+				return json.Marshal(data)
+			},
+		},
+		nodeId:          "node-1",
+		logFileName:     "one.log",
+		logsRefreshRate: 10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chunks, errs := s.Stream(ctx)
+	var received []Chunk
+	for chunk := range chunks {
+		require.Equal(t, "node-1", chunk.NodeId)
+		require.Equal(t, "one.log", chunk.LogName)
+		require.False(t, chunk.FetchTime.IsZero())
+		chunk.NodeId, chunk.LogName, chunk.FetchTime = "", "", time.Time{}
+		received = append(received, chunk)
+		if len(received) == 4 {
+			cancel()
+		}
+	}
+	require.NoError(t, <-errs)
+	require.Equal(t, []Chunk{
+		{StartOffset: 0, EndOffset: 4, Lines: []string{"one"}},
+		{StartOffset: 4, EndOffset: 8, Lines: []string{"two"}},
+		{StartOffset: 8, EndOffset: 14, Lines: []string{"three"}},
+		{StartOffset: 0, EndOffset: 4, Lines: []string{"new"}},
+	}, received)
+}
+
+func Test_client_Stream_tailStart(t *testing.T) {
+	s := &client{
+		httpStrategy:    &mockFileHttpStrategy{t: t, content: "one\ntwo\nthree\nfour"},
+		nodeId:          "node-1",
+		logFileName:     "one.log",
+		logsRefreshRate: time.Hour,
+		tailStart:       TailStart{Lines: 2},
+		timeRange:       TimeRange{Until: time.Now().Add(-time.Minute)},
+	}
+	// the time range ends right away, so the trailing partial line is sent as well
+	chunks, errs := s.Stream(context.Background())
+	var received []Chunk
+	for chunk := range chunks {
+		received = append(received, Chunk{StartOffset: chunk.StartOffset, EndOffset: chunk.EndOffset, Lines: chunk.Lines})
+	}
+	require.NoError(t, <-errs)
+	require.Equal(t, []Chunk{
+		{StartOffset: 8, EndOffset: 14, Lines: []string{"three"}},
+		{StartOffset: 14, EndOffset: 18, Lines: []string{"four"}},
+	}, received)
+}
+
+func Test_client_Stream_error(t *testing.T) {
+	s := &client{
+		httpStrategy: &mockFuncHttpStrategy{
+			sendGet: func(string, string) ([]byte, error) {
+				return nil, errors.New("connection refused")
+			},
+		},
+		nodeId:      "node-1",
+		logFileName: "one.log",
+	}
+	chunks, errs := s.Stream(context.Background())
+	_, ok := <-chunks
+	require.False(t, ok)
+	require.EqualError(t, <-errs, "connection refused")
+	_, ok = <-errs
+	require.False(t, ok)
+}
+
+func Test_client_TailLog_checkpoint(t *testing.T) {
+	tests := []struct {
+		name            string
+		checkpoint      *memoryCheckpoint
+		tailStart       TailStart
+		want            string
+		wantPageMarkers []int64
+		wantNotice      string
+	}{
+		{
+			name:            "no saved checkpoint",
+			checkpoint:      &memoryCheckpoint{},
+			want:            "first\nsecond\n",
+			wantPageMarkers: []int64{13},
+		},
+		{
+			name:            "no saved checkpoint, with tail start",
+			checkpoint:      &memoryCheckpoint{},
+			tailStart:       TailStart{Lines: 1},
+			want:            "second\n",
+			wantPageMarkers: []int64{13},
+		},
+		{
+			name:            "resume from saved checkpoint, ignoring tail start",
+			checkpoint:      &memoryCheckpoint{pageMarkers: []int64{6}},
+			tailStart:       TailStart{Lines: 2},
+			want:            "second\n",
+			wantPageMarkers: []int64{6, 13},
+			wantNotice:      "- Resuming log one.log of node node-1 from page marker 6\n",
+		},
+		{
+			name:            "resume from a saved checkpoint in the middle of a line of a rotated log",
+			checkpoint:      &memoryCheckpoint{pageMarkers: []int64{3}},
+			want:            "first\nsecond\n",
+			wantPageMarkers: []int64{3, 13},
+			wantNotice: "- Resuming log one.log of node node-1 from page marker 3\n" +
+				"- Log one.log of node node-1 was rotated, following the new log from its beginning\n",
+		},
+		{
+			name:            "resume from a saved checkpoint beyond a rotated log",
+			checkpoint:      &memoryCheckpoint{pageMarkers: []int64{100}},
+			want:            "first\nsecond\n",
+			wantPageMarkers: []int64{100, 13},
+			wantNotice: "- Resuming log one.log of node node-1 from page marker 100\n" +
+				"- Log one.log of node node-1 was rotated, following the new log from its beginning\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewClient(&mockFileHttpStrategy{t: t, content: "first\nsecond\n"})
+			s.SetNodeId("node-1")
+			s.SetLogFileName("one.log")
+			s.SetLogsRefreshRate(10 * time.Millisecond)
+			s.SetTailStart(tt.tailStart)
+			s.SetCheckpoint(tt.checkpoint)
+			notices := &bytes.Buffer{}
+			s.SetNoticeOutput(notices)
+
+			timeoutCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			out := &bytes.Buffer{}
+			require.NoError(t, s.TailLog(timeoutCtx, out))
+			require.Equal(t, tt.want, out.String())
+			require.Equal(t, tt.wantPageMarkers, tt.checkpoint.pageMarkers)
+			require.Equal(t, tt.wantNotice, notices.String())
+		})
+	}
+}
+
+// Records the log data written into it, and the page marker of every done poll.
+type pollRecorder struct {
+	bytes.Buffer
+	pageMarkers []int64
+}
+
+func (r *pollRecorder) PollDone(pageMarker int64) error {
+	r.pageMarkers = append(r.pageMarkers, pageMarker)
+	return nil
+}
+
+func Test_client_TailLog_pollWriter(t *testing.T) {
+	s := NewClient(&mockFileHttpStrategy{t: t, content: "first\nsecond\n"})
+	s.SetNodeId("node-1")
+	s.SetLogFileName("one.log")
+	s.SetLogsRefreshRate(10 * time.Millisecond)
+	s.SetTailStart(TailStart{Lines: 1})
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	out := &pollRecorder{}
+	require.NoError(t, s.TailLog(timeoutCtx, out))
+	require.Equal(t, "second\n", out.String())
+	// the poll reading the tail start, and every following poll without new log data
+	require.True(t, len(out.pageMarkers) >= 3, "polls done %v", out.pageMarkers)
+	for _, pageMarker := range out.pageMarkers {
+		require.Equal(t, int64(13), pageMarker)
+	}
+
+	// log data following the until is held back, so the page markers of the polls do not follow the written log data
+	s.SetTimeRange(TimeRange{Until: time.Now().Add(time.Hour)})
+	timeoutCtx, cancel = context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	out = &pollRecorder{}
+	require.NoError(t, s.TailLog(timeoutCtx, out))
+	require.Empty(t, out.pageMarkers)
+}
+
+func Test_client_TailLog_checkpointErrors(t *testing.T) {
+	s := NewClient(&mockFileHttpStrategy{t: t, content: "first\n"})
+	s.SetNodeId("node-1")
+	s.SetLogFileName("one.log")
+	s.SetCheckpoint(&memoryCheckpoint{loadErr: fmt.Errorf("unreadable")})
+	err := s.TailLog(context.Background(), &bytes.Buffer{})
+	require.EqualError(t, err, "failed loading the checkpoint of log one.log of node node-1: unreadable")
+
+	s.SetCheckpoint(&memoryCheckpoint{saveErr: fmt.Errorf("read-only")})
+	err = s.TailLog(context.Background(), &bytes.Buffer{})
+	require.EqualError(t, err, "failed saving the checkpoint of log one.log of node node-1: read-only")
+}
+
+func Test_client_TailLog_retries(t *testing.T) {
+	tests := []struct {
+		name        string
+		retryPolicy RetryPolicy
+		failures    int
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "recovers within max retries",
+			retryPolicy: RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond},
+			failures:    2,
+			want:        "one\ntwo\n",
+		},
+		{
+			name:        "gives up after max retries",
+			retryPolicy: RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond},
+			failures:    3,
+			want:        "one\n",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the first request succeeds, and the following ones fail until the failures run out
+			var requestedPageMarkers []int64
+			failures := tt.failures
+			s := &client{
+				httpStrategy: &mockFuncHttpStrategy{
+					sendGet: func(endpoint, _ string) ([]byte, error) {
+						var pageMarker int64
+						_, err := fmt.Sscanf(endpoint, constants.DataEndpoint+"?$file_size=%d&", &pageMarker)
+						require.NoError(t, err)
+						requestedPageMarkers = append(requestedPageMarkers, pageMarker)
+						if len(requestedPageMarkers) == 1 {
+							return json.Marshal(model.Data{Content: "one\n", PageMarker: 4})
+						}
+						if failures > 0 {
+							failures--
+							return nil, fmt.Errorf("unexpected response; status code: 502")
+						}
+						content := "one\ntwo\n"
+						return json.Marshal(model.Data{Content: content[pageMarker:], PageMarker: int64(len(content))})
+					},
+				},
+				nodeId:          "node-1",
+				logFileName:     "one.log",
+				logsRefreshRate: 10 * time.Millisecond,
+				retryPolicy:     tt.retryPolicy,
+			}
+			notices := &bytes.Buffer{}
+			s.SetNoticeOutput(notices)
+
+			timeoutCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			out := &bytes.Buffer{}
+			err := s.TailLog(timeoutCtx, out)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Contains(t, notices.String(), "Reconnected to node node-1")
+			}
+			require.Equal(t, tt.want, out.String())
+			require.Contains(t, notices.String(), "reconnecting in")
+			for _, pageMarker := range requestedPageMarkers[1:] {
+				require.True(t, pageMarker == 4 || pageMarker == 8)
+			}
+		})
+	}
+}
+
 type mockHttpStrategy struct {
 	t              *testing.T
 	expectEndpoint string
@@ -320,3 +762,59 @@ func (s *mockTailLogHttpStrategy) SendGet(_ context.Context, endpoint, nodeId st
 	s.getCount++
 	return res, nil
 }
+
+// Serves the log data of a single, static log file, from any page marker.
+type mockFileHttpStrategy struct {
+	t       *testing.T
+	content string
+}
+
+func (s *mockFileHttpStrategy) NodesEndpoint() string {
+	return mockHttpStrategyNodesEndpoint
+}
+
+func (s *mockFileHttpStrategy) SendGet(_ context.Context, endpoint, _ string) ([]byte, error) {
+	var pageMarker int64
+	var logFileName string
+	_, err := fmt.Sscanf(endpoint, constants.DataEndpoint+"?$file_size=%d&id=%s", &pageMarker, &logFileName)
+	require.NoError(s.t, err)
+	content := ""
+	if pageMarker < int64(len(s.content)) {
+		content = s.content[pageMarker:]
+	}
+	return json.Marshal(model.Data{Content: content, PageMarker: int64(len(s.content))})
+}
+
+type mockFuncHttpStrategy struct {
+	sendGet func(endpoint, nodeId string) ([]byte, error)
+}
+
+func (s *mockFuncHttpStrategy) NodesEndpoint() string {
+	return mockHttpStrategyNodesEndpoint
+}
+
+func (s *mockFuncHttpStrategy) SendGet(_ context.Context, endpoint, nodeId string) ([]byte, error) {
+	return s.sendGet(endpoint, nodeId)
+}
+
+// Keeps every saved page marker, the last of which is loaded.
+type memoryCheckpoint struct {
+	pageMarkers []int64
+	loadErr     error
+	saveErr     error
+}
+
+func (c *memoryCheckpoint) Load() (int64, bool, error) {
+	if c.loadErr != nil || len(c.pageMarkers) == 0 {
+		return 0, false, c.loadErr
+	}
+	return c.pageMarkers[len(c.pageMarkers)-1], true, nil
+}
+
+func (c *memoryCheckpoint) Save(pageMarker int64) error {
+	if c.saveErr != nil {
+		return c.saveErr
+	}
+	c.pageMarkers = append(c.pageMarkers, pageMarker)
+	return nil
+}
@@ -0,0 +1,202 @@
+package livelog
+
+import (
+	"bytes"
+	"container/heap"
+	"github.com/hanoch-jfrog/forest/parser"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	minMergerFlushInterval = 10 * time.Millisecond
+)
+
+// Merges the log lines of many sources into a single io.Writer, in global timestamp order.
+// Each line is expected to start with an ISO-8601 timestamp, as in the JFrog unified log format.
+// Lines without a leading timestamp, such as stack trace continuations, stay attached to the line preceding them.
+// Lines are held for the reorder window after they are received, so that lines of slower sources
+// can still be written before them.
+type Merger struct {
+	output        io.Writer
+	entryFormat   EntryFormat
+	reorderWindow time.Duration
+
+	mu       sync.Mutex
+	pending  mergeEntries
+	seq      uint64
+	writeErr error
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// Returns the content to write for a single entry of the passed source, including its trailing new line.
+// It may be called concurrently for different sources.
+type EntryFormat func(source Source, logLine *parser.LogLine) []byte
+
+// Returns an EntryFormat that writes the original lines of each entry, each line prefixed using the passed LinePrefix.
+func PrefixedEntryFormat(linePrefix LinePrefix) EntryFormat {
+	return func(source Source, logLine *parser.LogLine) []byte {
+		prefix := linePrefix(source)
+		var content bytes.Buffer
+		for _, line := range strings.Split(logLine.Raw, "\n") {
+			content.WriteString(prefix)
+			content.WriteString(line)
+			content.WriteByte('\n')
+		}
+		return content.Bytes()
+	}
+}
+
+type mergeEntry struct {
+	timestamp  time.Time
+	receivedAt time.Time
+	seq        uint64
+	content    []byte
+}
+
+// Creates a Merger that writes into the passed io.Writer, prefixing every line using the passed LinePrefix.
+// The Merger must be closed once all of its sources are done, to write the lines it still holds.
+func NewMerger(output io.Writer, linePrefix LinePrefix, reorderWindow time.Duration) *Merger {
+	m := &Merger{
+		output:        output,
+		entryFormat:   PrefixedEntryFormat(linePrefix),
+		reorderWindow: reorderWindow,
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	go m.flushLoop()
+	return m
+}
+
+// Sets how entries are written, replacing the LinePrefix the Merger was created with.
+// NOTE: must be called before SourceOutput.
+func (m *Merger) SetEntryFormat(entryFormat EntryFormat) {
+	m.entryFormat = entryFormat
+}
+
+// Returns a SourceOutput whose io.Writers feed the Merger.
+func (m *Merger) SourceOutput() SourceOutput {
+	entryFormat := m.entryFormat
+	return func(source Source) io.Writer {
+		mergeSource := &mergeSource{
+			merger:      m,
+			source:      source,
+			entryFormat: entryFormat,
+		}
+		return parser.NewEntryWriter(mergeSource.push)
+	}
+}
+
+// Writes every held line, regardless of the reorder window, and stops the Merger.
+// Returns the first error that occurred while writing into the output.
+func (m *Merger) Close() error {
+	close(m.done)
+	<-m.stopped
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flush(time.Time{})
+	return m.writeErr
+}
+
+func (m *Merger) flushLoop() {
+	defer close(m.stopped)
+	flushInterval := m.reorderWindow / 2
+	if flushInterval < minMergerFlushInterval {
+		flushInterval = minMergerFlushInterval
+	}
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			m.flush(now)
+			m.mu.Unlock()
+		}
+	}
+}
+
+func (m *Merger) push(timestamp time.Time, content []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.writeErr != nil {
+		return m.writeErr
+	}
+	m.seq++
+	now := time.Now()
+	heap.Push(&m.pending, &mergeEntry{
+		timestamp:  timestamp,
+		receivedAt: now,
+		seq:        m.seq,
+		content:    content,
+	})
+	if m.reorderWindow <= 0 {
+		m.flush(now)
+	}
+	return m.writeErr
+}
+
+// Writes the held entries in timestamp order, for as long as the earliest one was held for the reorder window.
+// A zero time writes every held entry.
+// NOTE: the caller must hold the Merger's lock.
+func (m *Merger) flush(now time.Time) {
+	for m.pending.Len() > 0 && m.writeErr == nil {
+		earliest := m.pending[0]
+		if !now.IsZero() && now.Sub(earliest.receivedAt) < m.reorderWindow {
+			return
+		}
+		heap.Pop(&m.pending)
+		_, m.writeErr = m.output.Write(earliest.content)
+	}
+}
+
+type mergeSource struct {
+	merger        *Merger
+	source        Source
+	entryFormat   EntryFormat
+	lastTimestamp time.Time
+}
+
+// Passes a parsed entry to the Merger, formatted by the EntryFormat.
+// Continuation entries are attached using the timestamp of the entry preceding them.
+func (s *mergeSource) push(logLine *parser.LogLine) error {
+	if !logLine.IsContinuation() {
+		s.lastTimestamp = logLine.Timestamp
+	}
+	return s.merger.push(s.lastTimestamp, s.entryFormat(s.source, logLine))
+}
+
+// A min-heap of entries, ordered by timestamp and then by the order they were received in.
+type mergeEntries []*mergeEntry
+
+func (e mergeEntries) Len() int {
+	return len(e)
+}
+
+func (e mergeEntries) Less(i, j int) bool {
+	if !e[i].timestamp.Equal(e[j].timestamp) {
+		return e[i].timestamp.Before(e[j].timestamp)
+	}
+	return e[i].seq < e[j].seq
+}
+
+func (e mergeEntries) Swap(i, j int) {
+	e[i], e[j] = e[j], e[i]
+}
+
+func (e *mergeEntries) Push(x interface{}) {
+	*e = append(*e, x.(*mergeEntry))
+}
+
+func (e *mergeEntries) Pop() interface{} {
+	old := *e
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*e = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,83 @@
+package livelog
+
+import (
+	"bytes"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestMerger(t *testing.T) {
+	out := &syncBuffer{}
+	merger := NewMerger(out, NodeIdPrefix, 100*time.Millisecond)
+	output := merger.SourceOutput()
+	first := output(Source{NodeId: "node-1"})
+	second := output(Source{NodeId: "node-2"})
+
+	_, err := first.Write([]byte("2020-12-06T19:21:52.300Z [jfrt ] [ERROR] - third\n\tat stack.trace\n2020-12-06T19:21:52.100Z [jfrt ] [INFO ] - first\n"))
+	require.NoError(t, err)
+	_, err = second.Write([]byte("2020-12-06T19:21:52.200Z [jfac ] [INFO ] - second\n2020-12-06T19:21:52.400Z [jfac ] [INFO ] - fourth"))
+	require.NoError(t, err)
+	require.Empty(t, out.String())
+
+	time.Sleep(250 * time.Millisecond)
+	want := "[node-1] 2020-12-06T19:21:52.100Z [jfrt ] [INFO ] - first\n" +
+		"[node-2] 2020-12-06T19:21:52.200Z [jfac ] [INFO ] - second\n" +
+		"[node-1] 2020-12-06T19:21:52.300Z [jfrt ] [ERROR] - third\n" +
+		"[node-1] \tat stack.trace\n"
+	require.Equal(t, want, out.String())
+
+	require.NoError(t, second.(io.Closer).Close())
+	require.NoError(t, merger.Close())
+	require.Equal(t, want+"[node-2] 2020-12-06T19:21:52.400Z [jfac ] [INFO ] - fourth\n", out.String())
+}
+
+func TestMerger_continuationInLaterWrite(t *testing.T) {
+	out := &bytes.Buffer{}
+	merger := NewMerger(out, NodeIdPrefix, time.Hour)
+	output := merger.SourceOutput()
+	first := output(Source{NodeId: "node-1"})
+	second := output(Source{NodeId: "node-2"})
+
+	_, err := first.Write([]byte("2020-12-06T19:21:52.100Z - first\n"))
+	require.NoError(t, err)
+	_, err = second.Write([]byte("2020-12-06T19:21:52.200Z - second\n"))
+	require.NoError(t, err)
+	_, err = first.Write([]byte("\tat stack.trace\n"))
+	require.NoError(t, err)
+	require.NoError(t, merger.Close())
+
+	want := "[node-1] 2020-12-06T19:21:52.100Z - first\n" +
+		"[node-1] \tat stack.trace\n" +
+		"[node-2] 2020-12-06T19:21:52.200Z - second\n"
+	require.Equal(t, want, out.String())
+}
+
+func TestMerger_noReorderWindow(t *testing.T) {
+	out := &bytes.Buffer{}
+	merger := NewMerger(out, NodeIdPrefix, 0)
+	w := merger.SourceOutput()(Source{NodeId: "node-1"})
+
+	_, err := w.Write([]byte("2020-12-06T19:21:52.200Z - second\n2020-12-06T19:21:52.100Z - first\n"))
+	require.NoError(t, err)
+	// without a reorder window, lines are written as they arrive
+	require.Equal(t, "[node-1] 2020-12-06T19:21:52.200Z - second\n[node-1] 2020-12-06T19:21:52.100Z - first\n", out.String())
+	require.NoError(t, merger.Close())
+}
+
+func TestMerger_SetEntryFormat(t *testing.T) {
+	out := &bytes.Buffer{}
+	merger := NewMerger(out, NodeIdPrefix, time.Hour)
+	merger.SetEntryFormat(func(source Source, logLine *parser.LogLine) []byte {
+		return []byte(source.NodeId + "," + logLine.Level.String() + "," + logLine.Message + "\n")
+	})
+	output := merger.SourceOutput()
+	_, err := output(Source{NodeId: "node-2"}).Write([]byte("2020-12-06T19:21:52.200Z [jfac ] [WARN ] - second\n"))
+	require.NoError(t, err)
+	_, err = output(Source{NodeId: "node-1"}).Write([]byte("2020-12-06T19:21:52.100Z [jfrt ] [INFO ] - first\n"))
+	require.NoError(t, err)
+	require.NoError(t, merger.Close())
+	require.Equal(t, "node-1,INFO,first\nnode-2,WARN,second\n", out.String())
+}
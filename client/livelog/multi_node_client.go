@@ -0,0 +1,103 @@
+package livelog
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Identifies a single log of a single node of the remote service.
+type Source struct {
+	NodeId  string
+	LogName string
+}
+
+// Returns the io.Writer that log data of the passed source is written into.
+// If the returned io.Writer is also an io.Closer, it is closed once the source stops producing log data.
+type SourceOutput func(source Source) io.Writer
+
+// Returns a SourceOutput that writes into the io.WriteCloser returned by wrap for each source,
+// which in turn writes into the io.Writer of this SourceOutput for that source.
+// Closing the returned io.Writer closes the wrapping io.WriteCloser, and then the wrapped io.Writer if it is an io.Closer.
+func (o SourceOutput) Wrap(wrap func(source Source, output io.Writer) io.WriteCloser) SourceOutput {
+	return func(source Source) io.Writer {
+		wrapped := o(source)
+		return &wrappedWriter{
+			WriteCloser: wrap(source, wrapped),
+			wrapped:     wrapped,
+		}
+	}
+}
+
+// Returns the Checkpoint of the passed source.
+type SourceCheckpoint func(source Source) Checkpoint
+
+type wrappedWriter struct {
+	io.WriteCloser
+	wrapped io.Writer
+}
+
+func (w *wrappedWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if closer, ok := w.wrapped.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+type MultiNodeClient interface {
+	// Sets the node ids to use when querying the remote service for log data.
+	// An empty slice selects every node of the remote service.
+	SetNodeIds(nodeIds []string)
+
+	// Sets the log file names to use when querying the remote service for log data.
+	// Each log file name of each node is a separate source.
+	// An empty slice selects every log file name in the config of each node.
+	SetLogFileNames(logFileNames []string)
+
+	// Sets the refresh rate interval between each log request.
+	SetLogsRefreshRate(logsRefreshRate time.Duration)
+
+	// Sets where TailLog starts following each source from, defaulting to the beginning of the log.
+	SetTailStart(tailStart TailStart)
+
+	// Sets how failed log requests of each source are retried by TailLog, defaulting to no retries.
+	SetRetryPolicy(retryPolicy RetryPolicy)
+
+	// Sets the TimeRange of the log data written by CatLog and TailLog for each source, defaulting to the whole log.
+	SetTimeRange(timeRange TimeRange)
+
+	// Sets the Checkpoint that TailLog resumes each source from, and saves the page marker it has read up to into.
+	// No checkpoints are used by default.
+	SetCheckpoints(checkpoints SourceCheckpoint)
+
+	// Sets the refresh rate interval between each query of the available nodes while tailing,
+	// defaulting to 10 seconds.
+	SetNodesRefreshRate(nodesRefreshRate time.Duration)
+
+	// Sets the io.Writer that notices, such as nodes joining or leaving, or logs being rotated, are written into.
+	// Notices are discarded by default.
+	SetNoticeOutput(noticeOutput io.Writer)
+
+	// Sets the maximal number of sources that CatLog reads at a time, defaulting to every source at once.
+	SetMaxConcurrency(maxConcurrency int)
+
+	// Sets whether CatLog writes a notice for every source that fails, including every node whose log names cannot be queried,
+	// and goes on with the other sources, rather than returning the first error. Defaults to false.
+	SetSkipFailedSources(skipFailedSources bool)
+
+	// Writes a single log data snapshot of every source, concurrently,
+	// into the io.Writer returned by the passed SourceOutput for that source.
+	// Any error during read or write is returned, unless failed sources are skipped, and some source did not fail.
+	CatLog(ctx context.Context, output SourceOutput) error
+
+	// Writes continuous log data snapshots of every source, concurrently,
+	// into the io.Writer returned by the passed SourceOutput for that source.
+	// Nodes that join the remote service are picked up, and nodes that leave it are dropped,
+	// on an interval set by the NodesRefreshRate.
+	// Any error of a node that is still available is returned.
+	// NOTE: this call blocks until cancellation of the passed context.Context.
+	TailLog(ctx context.Context, output SourceOutput) error
+}
@@ -0,0 +1,345 @@
+package livelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/util"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNodesRefreshRate = 10 * time.Second
+)
+
+type multiNodeClient struct {
+	httpStrategy      strategy.Http
+	serviceClient     *client
+	nodeIds           []string
+	logFileNames      []string
+	logsRefreshRate   time.Duration
+	nodesRefreshRate  time.Duration
+	tailStart         TailStart
+	retryPolicy       RetryPolicy
+	timeRange         TimeRange
+	checkpoints       SourceCheckpoint
+	noticeOutput      io.Writer
+	maxConcurrency    int
+	skipFailedSources bool
+}
+
+type sourceError struct {
+	source Source
+	err    error
+}
+
+func (e sourceError) Error() string {
+	return fmt.Sprintf("node %s, log %s: %v", e.source.NodeId, e.source.LogName, e.err)
+}
+
+func (e sourceError) Unwrap() error {
+	return e.err
+}
+
+func NewMultiNodeClient(strategy strategy.Http) *multiNodeClient {
+	return &multiNodeClient{
+		httpStrategy:     strategy,
+		serviceClient:    NewClient(strategy),
+		logsRefreshRate:  defaultLogsRefreshRate,
+		nodesRefreshRate: defaultNodesRefreshRate,
+		noticeOutput:     ioutil.Discard,
+	}
+}
+
+func (s *multiNodeClient) SetNodeIds(nodeIds []string) {
+	s.nodeIds = nodeIds
+}
+
+func (s *multiNodeClient) SetLogFileNames(logFileNames []string) {
+	s.logFileNames = logFileNames
+}
+
+func (s *multiNodeClient) SetLogsRefreshRate(logsRefreshRate time.Duration) {
+	s.logsRefreshRate = logsRefreshRate
+}
+
+func (s *multiNodeClient) SetTailStart(tailStart TailStart) {
+	s.tailStart = tailStart
+}
+
+func (s *multiNodeClient) SetRetryPolicy(retryPolicy RetryPolicy) {
+	s.retryPolicy = retryPolicy
+}
+
+func (s *multiNodeClient) SetTimeRange(timeRange TimeRange) {
+	s.timeRange = timeRange
+}
+
+func (s *multiNodeClient) SetCheckpoints(checkpoints SourceCheckpoint) {
+	s.checkpoints = checkpoints
+}
+
+func (s *multiNodeClient) SetNodesRefreshRate(nodesRefreshRate time.Duration) {
+	s.nodesRefreshRate = nodesRefreshRate
+}
+
+func (s *multiNodeClient) SetNoticeOutput(noticeOutput io.Writer) {
+	s.noticeOutput = noticeOutput
+}
+
+func (s *multiNodeClient) SetMaxConcurrency(maxConcurrency int) {
+	s.maxConcurrency = maxConcurrency
+}
+
+func (s *multiNodeClient) SetSkipFailedSources(skipFailedSources bool) {
+	s.skipFailedSources = skipFailedSources
+}
+
+func (s *multiNodeClient) CatLog(ctx context.Context, output SourceOutput) error {
+	nodeIds, err := s.selectedNodeIds(ctx)
+	if err != nil {
+		return err
+	}
+	if len(nodeIds) == 0 {
+		return fmt.Errorf("none of the selected node ids were found [%v]", util.SliceToCsv(s.nodeIds))
+	}
+
+	var sources []Source
+	var firstErr error
+	for _, nodeId := range nodeIds {
+		nodeSources, err := s.sources(ctx, []string{nodeId})
+		if err != nil {
+			if !s.skipFailedSources {
+				return err
+			}
+			fmt.Fprintf(s.noticeOutput, "- Failed to query the logs of node %s, skipping it: %v\n", nodeId, errors.Unwrap(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		sources = append(sources, nodeSources...)
+	}
+	maxConcurrency := s.maxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = len(sources)
+	}
+	semaphore := make(chan struct{}, maxConcurrency)
+	sourceErrs := make(chan error, len(sources))
+	for _, source := range sources {
+		go func(source Source) {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			sourceErrs <- s.runSource(ctx, source, output, false)
+		}(source)
+	}
+
+	succeeded := false
+	for range sources {
+		err := <-sourceErrs
+		if err == nil {
+			succeeded = true
+			continue
+		}
+		if s.skipFailedSources && ctx.Err() == nil {
+			var srcErr sourceError
+			errors.As(err, &srcErr)
+			fmt.Fprintf(s.noticeOutput, "- Failed to read log %s of node %s, skipping it: %v\n", srcErr.source.LogName, srcErr.source.NodeId, srcErr.err)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if s.skipFailedSources && succeeded {
+		return nil
+	}
+	return firstErr
+}
+
+func (s *multiNodeClient) TailLog(ctx context.Context, output SourceOutput) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	tailCtx, cancelTails := context.WithCancel(ctx)
+	defer cancelTails()
+
+	tails := make(map[string]context.CancelFunc)
+	sourceErrs := make(chan sourceError)
+	// sources only finish without an error once the Until of the TimeRange has passed
+	sourcesDone := make(chan Source)
+	runningSources := make(map[string]int)
+	startTail := func(nodeId string) error {
+		sources, err := s.sources(ctx, []string{nodeId})
+		if err != nil {
+			return err
+		}
+		nodeCtx, cancelNode := context.WithCancel(tailCtx)
+		tails[nodeId] = cancelNode
+		for _, source := range sources {
+			wg.Add(1)
+			runningSources[nodeId]++
+			go func(source Source) {
+				defer wg.Done()
+				err := s.runSource(nodeCtx, source, output, true)
+				if nodeCtx.Err() != nil {
+					return
+				}
+				if err == nil {
+					select {
+					case sourcesDone <- source:
+					case <-nodeCtx.Done():
+					}
+					return
+				}
+				select {
+				case sourceErrs <- err.(sourceError):
+				case <-nodeCtx.Done():
+				}
+			}(source)
+		}
+		return nil
+	}
+	syncTails := func() error {
+		nodeIds, err := s.selectedNodeIds(ctx)
+		if err != nil {
+			return err
+		}
+		for nodeId, cancelNode := range tails {
+			if !util.InSlice(nodeIds, nodeId) {
+				cancelNode()
+				delete(tails, nodeId)
+				delete(runningSources, nodeId)
+				fmt.Fprintf(s.noticeOutput, "- Node %s left, dropping it\n", nodeId)
+			}
+		}
+		for _, nodeId := range nodeIds {
+			if _, ok := tails[nodeId]; !ok {
+				if err = startTail(nodeId); err != nil {
+					return err
+				}
+				fmt.Fprintf(s.noticeOutput, "- Node %s joined, following it\n", nodeId)
+			}
+		}
+		return nil
+	}
+
+	nodeIds, err := s.selectedNodeIds(ctx)
+	if err != nil {
+		return err
+	}
+	if len(nodeIds) == 0 {
+		return fmt.Errorf("none of the selected node ids were found [%v]", util.SliceToCsv(s.nodeIds))
+	}
+	for _, nodeId := range nodeIds {
+		if err = startTail(nodeId); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.nodesRefreshRate):
+			if err = syncTails(); err != nil {
+				return err
+			}
+		case source := <-sourcesDone:
+			if runningSources[source.NodeId]--; runningSources[source.NodeId] == 0 {
+				delete(runningSources, source.NodeId)
+			}
+			if len(runningSources) == 0 {
+				return nil
+			}
+		case sourceErr := <-sourceErrs:
+			nodeId := sourceErr.source.NodeId
+			if cancelNode, ok := tails[nodeId]; ok {
+				cancelNode()
+				delete(tails, nodeId)
+				delete(runningSources, nodeId)
+			}
+			nodeIds, err = s.selectedNodeIds(ctx)
+			if err != nil {
+				return err
+			}
+			if util.InSlice(nodeIds, nodeId) {
+				return sourceErr
+			}
+			fmt.Fprintf(s.noticeOutput, "- Node %s left, dropping it\n", nodeId)
+		}
+	}
+}
+
+// Returns the selected node ids that are currently available on the remote service.
+func (s *multiNodeClient) selectedNodeIds(ctx context.Context) ([]string, error) {
+	availableNodeIds, err := s.serviceClient.GetServiceNodeIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.nodeIds) == 0 {
+		return availableNodeIds, nil
+	}
+	var nodeIds []string
+	for _, nodeId := range s.nodeIds {
+		if util.InSlice(availableNodeIds, nodeId) {
+			nodeIds = append(nodeIds, nodeId)
+		}
+	}
+	return nodeIds, nil
+}
+
+// Returns a source for each log file name of each of the passed node ids.
+// Without log file names, every log file name in the config of each node is used.
+func (s *multiNodeClient) sources(ctx context.Context, nodeIds []string) ([]Source, error) {
+	var sources []Source
+	for _, nodeId := range nodeIds {
+		logFileNames := s.logFileNames
+		if len(logFileNames) == 0 {
+			nodeClient := NewClient(s.httpStrategy)
+			nodeClient.SetNodeId(nodeId)
+			config, err := nodeClient.GetConfig(ctx)
+			if err != nil {
+				return nil, sourceError{source: Source{NodeId: nodeId}, err: err}
+			}
+			logFileNames = config.LogFileNames
+		}
+		for _, logFileName := range logFileNames {
+			sources = append(sources, Source{NodeId: nodeId, LogName: logFileName})
+		}
+	}
+	return sources, nil
+}
+
+func (s *multiNodeClient) runSource(ctx context.Context, source Source, output SourceOutput, isStreaming bool) error {
+	sourceClient := NewClient(s.httpStrategy)
+	sourceClient.SetNodeId(source.NodeId)
+	sourceClient.SetLogFileName(source.LogName)
+	sourceClient.SetLogsRefreshRate(s.logsRefreshRate)
+	sourceClient.SetTailStart(s.tailStart)
+	sourceClient.SetRetryPolicy(s.retryPolicy)
+	sourceClient.SetTimeRange(s.timeRange)
+	sourceClient.SetNoticeOutput(s.noticeOutput)
+	if s.checkpoints != nil {
+		sourceClient.SetCheckpoint(s.checkpoints(source))
+	}
+
+	sourceOutput := output(source)
+	var err error
+	if isStreaming {
+		err = sourceClient.TailLog(ctx, sourceOutput)
+	} else {
+		err = sourceClient.CatLog(ctx, sourceOutput)
+	}
+	if closer, ok := sourceOutput.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return sourceError{source: source, err: err}
+	}
+	return nil
+}
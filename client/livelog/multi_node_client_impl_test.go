@@ -0,0 +1,321 @@
+package livelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/constants"
+	"github.com/hanoch-jfrog/forest/client/livelog/fakeserver"
+	"github.com/hanoch-jfrog/forest/client/livelog/model"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_multiNodeClient_CatLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodeIds []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "all nodes",
+			want: []string{"[node-1] one", "[node-2] two"},
+		},
+		{
+			name:    "selected nodes",
+			nodeIds: []string{"node-2"},
+			want:    []string{"[node-2] two"},
+		},
+		{
+			name:    "unavailable selected nodes",
+			nodeIds: []string{"node-3"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpStrategy := &mockMultiNodeHttpStrategy{
+				t:           t,
+				nodeIds:     []string{"node-1", "node-2"},
+				logContents: map[string]string{"node-1": "one\n", "node-2": "two"},
+			}
+			s := NewMultiNodeClient(httpStrategy)
+			s.SetNodeIds(tt.nodeIds)
+			s.SetLogFileNames([]string{"one.log"})
+
+			out := &bytes.Buffer{}
+			err := s.CatLog(context.Background(), NewPrefixedOutput(out, NodeIdPrefix))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, sortedLines(out.String()))
+		})
+	}
+}
+
+func Test_multiNodeClient_CatLog_multipleLogs(t *testing.T) {
+	httpStrategy := &mockMultiNodeHttpStrategy{
+		t:           t,
+		nodeIds:     []string{"node-1", "node-2"},
+		logContents: map[string]string{"node-1": "one\n", "node-2": "two\n"},
+	}
+	s := NewMultiNodeClient(httpStrategy)
+	s.SetNodeIds([]string{"node-1"})
+	s.SetLogFileNames([]string{"one.log", "two.log"})
+
+	out := &bytes.Buffer{}
+	err := s.CatLog(context.Background(), NewPrefixedOutput(out, NodeIdAndLogNamePrefix))
+	require.NoError(t, err)
+	require.Equal(t, []string{"[node-1 one.log] one", "[node-1 two.log] one"}, sortedLines(out.String()))
+}
+
+func Test_multiNodeClient_CatLog_everyLog(t *testing.T) {
+	logsDir, err := ioutil.TempDir("", "livelog")
+	require.NoError(t, err)
+	defer os.RemoveAll(logsDir)
+	server := fakeserver.New(fakeserver.Config{LogsDir: logsDir})
+	require.NoError(t, server.AppendLog("node-1", "console.log", "console of node 1\n"))
+	require.NoError(t, server.AppendLog("node-1", "access-service.log", "access of node 1\n"))
+	require.NoError(t, server.AppendLog("node-2", "router-service.log", "router of node 2\n"))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	httpStrategy, err := strategy.NewStandaloneHttpStrategy(strategy.StandaloneHttpConfig{BaseUrl: httpServer.URL})
+	require.NoError(t, err)
+
+	s := NewMultiNodeClient(httpStrategy)
+	out := &bytes.Buffer{}
+	require.NoError(t, s.CatLog(context.Background(), NewPrefixedOutput(out, NodeIdAndLogNamePrefix)))
+	require.Equal(t, []string{
+		"[node-1 access-service.log] access of node 1",
+		"[node-1 console.log] console of node 1",
+		"[node-2 router-service.log] router of node 2",
+	}, sortedLines(out.String()))
+}
+
+func Test_multiNodeClient_TailLog_nodesChange(t *testing.T) {
+	httpStrategy := &mockMultiNodeHttpStrategy{
+		t:           t,
+		nodeIds:     []string{"node-1"},
+		logContents: map[string]string{"node-1": "one\n", "node-2": "two\n"},
+	}
+	s := NewMultiNodeClient(httpStrategy)
+	s.SetLogFileNames([]string{"one.log"})
+	s.SetLogsRefreshRate(10 * time.Millisecond)
+	s.SetNodesRefreshRate(50 * time.Millisecond)
+	notices := &syncBuffer{}
+	s.SetNoticeOutput(notices)
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		httpStrategy.setNodeIds([]string{"node-1", "node-2"})
+		time.Sleep(150 * time.Millisecond)
+		httpStrategy.setNodeIds([]string{"node-2"})
+	}()
+
+	out := &syncBuffer{}
+	err := s.TailLog(timeoutCtx, NewPrefixedOutput(out, NodeIdPrefix))
+	require.NoError(t, err)
+	require.Equal(t, []string{"[node-1] one", "[node-2] two"}, sortedLines(out.String()))
+	require.Contains(t, notices.String(), "Node node-2 joined")
+	require.Contains(t, notices.String(), "Node node-1 left")
+}
+
+func Test_multiNodeClient_TailLog_nodeError(t *testing.T) {
+	httpStrategy := &mockMultiNodeHttpStrategy{
+		t:           t,
+		nodeIds:     []string{"node-1", "node-2"},
+		logContents: map[string]string{"node-1": "one\n"},
+		logErrs:     map[string]error{"node-2": fmt.Errorf("some-error")},
+	}
+	s := NewMultiNodeClient(httpStrategy)
+	s.SetLogFileNames([]string{"one.log"})
+
+	err := s.TailLog(context.Background(), NewPrefixedOutput(&syncBuffer{}, NodeIdPrefix))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "node-2")
+}
+
+func Test_prefixedWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	output := NewPrefixedOutput(out, NodeIdPrefix)
+	w := output(Source{NodeId: "node-1", LogName: "one.log"})
+
+	_, err := w.Write([]byte("first line\nsecond "))
+	require.NoError(t, err)
+	require.Equal(t, "[node-1] first line\n", out.String())
+	_, err = w.Write([]byte("line\nthird"))
+	require.NoError(t, err)
+	require.Equal(t, "[node-1] first line\n[node-1] second line\n", out.String())
+	require.NoError(t, w.(*prefixedWriter).Close())
+	require.Equal(t, "[node-1] first line\n[node-1] second line\n[node-1] third\n", out.String())
+}
+
+func TestSourceOutput_Wrap(t *testing.T) {
+	out := &bytes.Buffer{}
+	var wrappedSources []Source
+	output := NewPrefixedOutput(out, NodeIdPrefix).Wrap(func(source Source, output io.Writer) io.WriteCloser {
+		wrappedSources = append(wrappedSources, source)
+		return &upperCaseWriter{output: output}
+	})
+	w := output(Source{NodeId: "node-1", LogName: "one.log"})
+
+	_, err := w.Write([]byte("first line\nsecond"))
+	require.NoError(t, err)
+	require.Equal(t, "[node-1] FIRST LINE\n", out.String())
+	require.NoError(t, w.(io.Closer).Close())
+	require.Equal(t, "[node-1] FIRST LINE\n[node-1] SECOND\n", out.String())
+	require.Equal(t, []Source{{NodeId: "node-1", LogName: "one.log"}}, wrappedSources)
+}
+
+type upperCaseWriter struct {
+	output io.Writer
+}
+
+func (w *upperCaseWriter) Write(p []byte) (int, error) {
+	return w.output.Write(bytes.ToUpper(p))
+}
+
+func (w *upperCaseWriter) Close() error {
+	return nil
+}
+
+func sortedLines(content string) []string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Serves the full log content of each node on the first log request of that node,
+// and no new content afterwards.
+type mockMultiNodeHttpStrategy struct {
+	t           *testing.T
+	mu          sync.Mutex
+	nodeIds     []string
+	logContents map[string]string
+	logErrs     map[string]error
+}
+
+func (s *mockMultiNodeHttpStrategy) setNodeIds(nodeIds []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodeIds = nodeIds
+}
+
+func (s *mockMultiNodeHttpStrategy) NodesEndpoint() string {
+	return mockHttpStrategyNodesEndpoint
+}
+
+func (s *mockMultiNodeHttpStrategy) SendGet(_ context.Context, endpoint, nodeId string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if endpoint == mockHttpStrategyNodesEndpoint {
+		serviceNodes := model.ServiceNodes{}
+		for _, id := range s.nodeIds {
+			serviceNodes.Nodes = append(serviceNodes.Nodes, model.ServiceNode{NodeId: id})
+		}
+		return json.Marshal(serviceNodes)
+	}
+	require.True(s.t, strings.HasPrefix(endpoint, constants.DataEndpoint))
+	if err := s.logErrs[nodeId]; err != nil {
+		return nil, err
+	}
+	content := s.logContents[nodeId]
+	if !strings.Contains(endpoint, "$file_size=0&") {
+		content = ""
+	}
+	return json.Marshal(model.Data{Content: content, PageMarker: int64(len(s.logContents[nodeId]))})
+}
+
+// Serves logs a.log and b.log of every node, failing the config of node-3 and log b.log of node-1,
+// and tracking the largest number of concurrent log data requests.
+type concurrencyHttpStrategy struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *concurrencyHttpStrategy) NodesEndpoint() string {
+	return mockHttpStrategyNodesEndpoint
+}
+
+func (s *concurrencyHttpStrategy) SendGet(_ context.Context, endpoint, nodeId string) ([]byte, error) {
+	switch {
+	case endpoint == mockHttpStrategyNodesEndpoint:
+		return json.Marshal(model.ServiceNodes{Nodes: []model.ServiceNode{{NodeId: "node-1"}, {NodeId: "node-2"}, {NodeId: "node-3"}}})
+	case endpoint == constants.ConfigEndpoint:
+		if nodeId == "node-3" {
+			return nil, fmt.Errorf("unexpected response; status code: 502")
+		}
+		return json.Marshal(model.Config{LogFileNames: []string{"a.log", "b.log"}})
+	}
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	if nodeId == "node-1" && strings.HasSuffix(endpoint, "id=b.log") {
+		return nil, fmt.Errorf("unexpected response; status code: 500")
+	}
+	content := "entry of " + nodeId + "\n"
+	return json.Marshal(model.Data{Content: content, PageMarker: int64(len(content))})
+}
+
+func Test_multiNodeClient_CatLog_skipFailedSources(t *testing.T) {
+	httpStrategy := &concurrencyHttpStrategy{}
+	s := NewMultiNodeClient(httpStrategy)
+	s.SetMaxConcurrency(2)
+	s.SetSkipFailedSources(true)
+	notices := &bytes.Buffer{}
+	s.SetNoticeOutput(notices)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, s.CatLog(context.Background(), NewPrefixedOutput(out, NodeIdAndLogNamePrefix)))
+	require.Equal(t, []string{"[node-1 a.log] entry of node-1", "[node-2 a.log] entry of node-2", "[node-2 b.log] entry of node-2"}, sortedLines(out.String()))
+	require.Equal(t, []string{
+		"- Failed to query the logs of node node-3, skipping it: unexpected response; status code: 502",
+		"- Failed to read log b.log of node node-1, skipping it: unexpected response; status code: 500",
+	}, strings.Split(strings.TrimSuffix(notices.String(), "\n"), "\n"))
+	require.Equal(t, 2, httpStrategy.maxInFlight)
+
+	// without skipping, the first failure is returned
+	s.SetSkipFailedSources(false)
+	require.EqualError(t, s.CatLog(context.Background(), NewPrefixedOutput(&bytes.Buffer{}, NodeIdAndLogNamePrefix)),
+		"node node-3, log : unexpected response; status code: 502")
+}
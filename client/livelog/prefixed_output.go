@@ -0,0 +1,78 @@
+package livelog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Returns the prefix to write before every log line of the passed source.
+type LinePrefix func(source Source) string
+
+// Prefixes log lines by their node id.
+func NodeIdPrefix(source Source) string {
+	return fmt.Sprintf("[%s] ", source.NodeId)
+}
+
+// Prefixes log lines by their node id and log name.
+func NodeIdAndLogNamePrefix(source Source) string {
+	return fmt.Sprintf("[%s %s] ", source.NodeId, source.LogName)
+}
+
+// Returns a SourceOutput that writes the log data of every source into the passed io.Writer,
+// one complete line at a time, each line prefixed using the passed LinePrefix.
+func NewPrefixedOutput(output io.Writer, linePrefix LinePrefix) SourceOutput {
+	outputMutex := &sync.Mutex{}
+	return func(source Source) io.Writer {
+		return &prefixedWriter{
+			output:      output,
+			outputMutex: outputMutex,
+			prefix:      []byte(linePrefix(source)),
+		}
+	}
+}
+
+type prefixedWriter struct {
+	output      io.Writer
+	outputMutex *sync.Mutex
+	prefix      []byte
+	partialLine []byte
+}
+
+func (w *prefixedWriter) Write(p []byte) (int, error) {
+	w.partialLine = append(w.partialLine, p...)
+	lastNewLine := bytes.LastIndexByte(w.partialLine, '\n')
+	if lastNewLine < 0 {
+		return len(p), nil
+	}
+	if err := w.writeLines(w.partialLine[:lastNewLine+1]); err != nil {
+		return 0, err
+	}
+	w.partialLine = append(w.partialLine[:0], w.partialLine[lastNewLine+1:]...)
+	return len(p), nil
+}
+
+// Writes the remaining partial line, if any, terminated by a new line.
+func (w *prefixedWriter) Close() error {
+	if len(w.partialLine) == 0 {
+		return nil
+	}
+	err := w.writeLines(append(w.partialLine, '\n'))
+	w.partialLine = nil
+	return err
+}
+
+func (w *prefixedWriter) writeLines(lines []byte) error {
+	var buf bytes.Buffer
+	for len(lines) > 0 {
+		lineEnd := bytes.IndexByte(lines, '\n') + 1
+		buf.Write(w.prefix)
+		buf.Write(lines[:lineEnd])
+		lines = lines[lineEnd:]
+	}
+	w.outputMutex.Lock()
+	defer w.outputMutex.Unlock()
+	_, err := w.output.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,68 @@
+package livelog
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Controls how failed log requests are retried while tailing.
+// Retries back off exponentially, with random jitter, until either cap is reached.
+// The zero value does not retry at all.
+type RetryPolicy struct {
+	// The maximal number of consecutive retries of a failed request; zero or less means no cap.
+	MaxRetries int
+	// The maximal time to keep retrying since the first consecutive failure; zero or less means no cap.
+	MaxElapsedTime time.Duration
+	// The backoff before the first retry.
+	InitialBackoff time.Duration
+	// The maximal backoff between retries.
+	MaxBackoff time.Duration
+	// The factor each backoff is multiplied by, compared to the backoff before it.
+	Multiplier float64
+	// The fraction of each backoff that is randomly added or subtracted, between 0 and 1.
+	Jitter float64
+}
+
+// Retries for up to 5 minutes, backing off from 1 second up to 30 seconds.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxElapsedTime: 5 * time.Minute,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+func (p RetryPolicy) isDisabled() bool {
+	return p.MaxRetries <= 0 && p.MaxElapsedTime <= 0
+}
+
+// Returns whether another retry is allowed, given the number of retries done so far,
+// and the time passed since the first consecutive failure.
+func (p RetryPolicy) allowsRetry(retries int, elapsed time.Duration) bool {
+	if p.isDisabled() {
+		return false
+	}
+	if p.MaxRetries > 0 && retries >= p.MaxRetries {
+		return false
+	}
+	return p.MaxElapsedTime <= 0 || elapsed < p.MaxElapsedTime
+}
+
+// Returns the backoff before the passed retry, counting from 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(backoff)
+}
@@ -0,0 +1,73 @@
+package livelog
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_allowsRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		retryPolicy RetryPolicy
+		retries     int
+		elapsed     time.Duration
+		want        bool
+	}{
+		{
+			name:        "zero value",
+			retryPolicy: RetryPolicy{},
+		},
+		{
+			name:        "below max retries",
+			retryPolicy: RetryPolicy{MaxRetries: 3},
+			retries:     2,
+			want:        true,
+		},
+		{
+			name:        "max retries reached",
+			retryPolicy: RetryPolicy{MaxRetries: 3},
+			retries:     3,
+		},
+		{
+			name:        "below max elapsed time",
+			retryPolicy: RetryPolicy{MaxElapsedTime: time.Minute},
+			retries:     100,
+			elapsed:     time.Second,
+			want:        true,
+		},
+		{
+			name:        "max elapsed time reached",
+			retryPolicy: RetryPolicy{MaxElapsedTime: time.Minute},
+			elapsed:     time.Minute,
+		},
+		{
+			name:        "max retries reached before max elapsed time",
+			retryPolicy: RetryPolicy{MaxRetries: 1, MaxElapsedTime: time.Minute},
+			retries:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.retryPolicy.allowsRetry(tt.retries, tt.elapsed))
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	retryPolicy := RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+	require.Equal(t, time.Second, retryPolicy.backoff(1))
+	require.Equal(t, 2*time.Second, retryPolicy.backoff(2))
+	require.Equal(t, 4*time.Second, retryPolicy.backoff(3))
+	require.Equal(t, 5*time.Second, retryPolicy.backoff(4))
+
+	retryPolicy.Jitter = 0.5
+	for retry := 1; retry < 100; retry++ {
+		backoff := retryPolicy.backoff(2)
+		require.True(t, backoff >= time.Second && backoff <= 3*time.Second, "backoff %v out of jitter range", backoff)
+	}
+}
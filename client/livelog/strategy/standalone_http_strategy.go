@@ -0,0 +1,147 @@
+package strategy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/constants"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyHeader = "X-JFrog-Art-Api"
+)
+
+// Configures a standalone http strategy, which requires no JFrog CLI configuration.
+type StandaloneHttpConfig struct {
+	// The base url of the remote service, such as https://acme.jfrog.io/artifactory/
+	BaseUrl string
+
+	// Credentials, the first of which that is set is used: an access token,
+	// a user and password for basic authentication, or an API key.
+	AccessToken string
+	User        string
+	Password    string
+	ApiKey      string
+
+	// A PEM encoded bundle of certificate authorities to trust, in addition to the system ones.
+	CaBundlePath string
+	// A PEM encoded client certificate and its private key, for mutual TLS.
+	ClientCertPath string
+	ClientKeyPath  string
+	// Skips verification of the remote service's certificate. Meant for testing only.
+	InsecureSkipVerify bool
+
+	// The proxy url to send requests through. When empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables are used.
+	ProxyUrl string
+	// The timeout of each request, on top of the deadline of its context. Zero means no timeout.
+	RequestTimeout time.Duration
+}
+
+func NewStandaloneHttpStrategy(config StandaloneHttpConfig) (*standaloneHttpStrategy, error) {
+	if config.BaseUrl == "" {
+		return nil, fmt.Errorf("base url must be set")
+	}
+	if !strings.HasSuffix(config.BaseUrl, "/") {
+		config.BaseUrl += "/"
+	}
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	return &standaloneHttpStrategy{
+		config: config,
+		client: &http.Client{Transport: transport},
+	}, nil
+}
+
+func newTransport(config StandaloneHttpConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	if config.CaBundlePath != "" {
+		caBundle, err := ioutil.ReadFile(config.CaBundlePath)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no certificates found in CA bundle [%v]", config.CaBundlePath)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if config.ClientCertPath != "" || config.ClientKeyPath != "" {
+		clientCert, err := tls.LoadX509KeyPair(config.ClientCertPath, config.ClientKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	if config.ProxyUrl != "" {
+		proxyUrl, err := url.Parse(config.ProxyUrl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url [%v]: %w", config.ProxyUrl, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	return transport, nil
+}
+
+type standaloneHttpStrategy struct {
+	config StandaloneHttpConfig
+	client *http.Client
+}
+
+func (s *standaloneHttpStrategy) NodesEndpoint() string {
+	return artifactoryNodesEndpoint
+}
+
+func (s *standaloneHttpStrategy) SendGet(ctx context.Context, endpoint, nodeId string) ([]byte, error) {
+	if s.config.RequestTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, s.config.RequestTimeout)
+		defer cancelTimeout()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.BaseUrl+endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.setAuthentication(req)
+	if nodeId != "" {
+		req.Header.Set(constants.NodeIdHeader, nodeId)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected response; status code: %d, message: %s", res.StatusCode, resBody)
+	}
+	return resBody, nil
+}
+
+func (s *standaloneHttpStrategy) setAuthentication(req *http.Request) {
+	switch {
+	case s.config.AccessToken != "":
+		req.Header.Set("Authorization", "Bearer "+s.config.AccessToken)
+	case s.config.User != "":
+		req.SetBasicAuth(s.config.User, s.config.Password)
+	case s.config.ApiKey != "":
+		req.Header.Set(apiKeyHeader, s.config.ApiKey)
+	}
+}
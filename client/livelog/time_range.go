@@ -0,0 +1,149 @@
+package livelog
+
+import (
+	"bytes"
+	"context"
+	"github.com/hanoch-jfrog/forest/parser"
+	"io"
+	"time"
+)
+
+// The size of the first window read back from the end of the log when searching for TimeRange.Since;
+// doubled until the window starts before Since.
+const initialSinceWindowSize = 64 * 1024
+
+// Restricts the log data to the entries within a time range, by the timestamps their first lines start with.
+// Lines without a timestamp, such as stack traces, belong to the entry preceding them.
+// The zero value does not restrict the log data.
+type TimeRange struct {
+	// Starts from the first entry at or after Since, unless zero.
+	Since time.Time
+	// Stops after the last entry at or before Until, unless zero.
+	Until time.Time
+}
+
+func (r TimeRange) hasSince() bool {
+	return !r.Since.IsZero()
+}
+
+func (r TimeRange) hasUntil() bool {
+	return !r.Until.IsZero()
+}
+
+// Reads the log data starting at the first entry at or after the Since of the set TimeRange,
+// returning it along with the page marker following it.
+// Since the data endpoint returns the log from the requested page marker to its end, windows that double in size
+// are read back from the end of the log, until one starts before Since; so the transferred data is bounded
+// by a small multiple of the data following Since, rather than by the size of the log.
+// A window without any timestamp, such as of a log of another format, stops the search with a notice, and no log data.
+func (s *client) readSince(ctx context.Context) (content []byte, pageMarker int64, err error) {
+	_, logSize, err := s.doCatLogWithRetries(ctx, probePageMarker)
+	if err != nil {
+		return nil, 0, err
+	}
+	for windowSize := int64(initialSinceWindowSize); ; windowSize *= 2 {
+		offset := logSize - windowSize
+		content, pageMarker, err = s.readFrom(ctx, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		windowStart := int64(0)
+		if offset > 0 {
+			// the window most likely starts in the middle of a line
+			windowStart = offset
+			if lineEnd := bytes.IndexByte(content, '\n'); lineEnd >= 0 {
+				content = content[lineEnd+1:]
+			} else {
+				content = nil
+			}
+		}
+		start, startsBefore, hasTimestamps := entriesSince(content, s.timeRange.Since)
+		if !hasTimestamps && pageMarker > windowStart {
+			// most likely a log of another format, whose search would read the whole log, to find no entries
+			s.writeNotice("- Log %s of node %s has no timestamps in its last %d bytes, so no entries at or after %s were found\n",
+				s.logFileName, s.nodeId, pageMarker-windowStart, s.timeRange.Since.UTC().Format(time.RFC3339))
+			return nil, pageMarker, nil
+		}
+		if startsBefore || offset <= 0 {
+			return content[start:], pageMarker, nil
+		}
+	}
+}
+
+// Returns the offset of the first entry at or after since in the passed content, or the content's length if there is none,
+// whether the content has an entry before since, meaning no earlier content can hold entries at or after since,
+// and whether any line of the content has a timestamp.
+func entriesSince(content []byte, since time.Time) (start int, startsBefore bool, hasTimestamps bool) {
+	for lineStart := 0; lineStart < len(content); {
+		lineEnd := bytes.IndexByte(content[lineStart:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += lineStart
+		}
+		if timestamp, ok := parser.ParseTimestamp(string(content[lineStart:lineEnd])); ok {
+			if !timestamp.Before(since) {
+				return lineStart, startsBefore, true
+			}
+			startsBefore = true
+		}
+		lineStart = lineEnd + 1
+	}
+	return len(content), startsBefore, startsBefore
+}
+
+// An io.WriteCloser that writes complete lines into its output, until the first entry after until.
+// A trailing partial line is held until it is complete, or until the untilWriter is closed.
+type untilWriter struct {
+	output      io.Writer
+	until       time.Time
+	partialLine []byte
+	passed      bool
+}
+
+func (w *untilWriter) Write(p []byte) (int, error) {
+	if w.passed {
+		return len(p), nil
+	}
+	w.partialLine = append(w.partialLine, p...)
+	lines := w.partialLine
+	end := 0
+	for !w.passed {
+		lineEnd := bytes.IndexByte(lines[end:], '\n')
+		if lineEnd < 0 {
+			break
+		}
+		if w.isAfterUntil(lines[end : end+lineEnd]) {
+			w.passed = true
+			break
+		}
+		end += lineEnd + 1
+	}
+	if _, err := w.output.Write(lines[:end]); err != nil {
+		return 0, err
+	}
+	w.partialLine = append(w.partialLine[:0], lines[end:]...)
+	if w.passed {
+		w.partialLine = nil
+	}
+	return len(p), nil
+}
+
+// Writes the remaining partial line, unless it is after until. Does not close the output.
+func (w *untilWriter) Close() error {
+	if w.passed || len(w.partialLine) == 0 {
+		return nil
+	}
+	if w.isAfterUntil(w.partialLine) {
+		w.passed = true
+		return nil
+	}
+	_, err := w.output.Write(w.partialLine)
+	w.partialLine = nil
+	return err
+}
+
+func (w *untilWriter) isAfterUntil(line []byte) bool {
+	timestamp, ok := parser.ParseTimestamp(string(line))
+	return ok && timestamp.After(w.until)
+}
@@ -0,0 +1,211 @@
+package livelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+var timeRangeStart = time.Date(2020, 12, 6, 19, 0, 0, 0, time.UTC)
+
+// Returns a log with an entry per second, starting at timeRangeStart, where every 10th entry has a stack trace.
+func timeRangeLog(entries int) string {
+	var log strings.Builder
+	for idx := 0; idx < entries; idx++ {
+		log.WriteString(timeRangeLine(idx))
+	}
+	return log.String()
+}
+
+func timeRangeLine(idx int) string {
+	line := fmt.Sprintf("%s [jfrt ] [INFO ] - entry %d\n", timeRangeStart.Add(time.Duration(idx)*time.Second).Format("2006-01-02T15:04:05.000Z"), idx)
+	if idx%10 == 0 {
+		line += "\tat stack.trace\n"
+	}
+	return line
+}
+
+func newTimeRangeClient(t *testing.T, log string, transferred *int) *client {
+	fileStrategy := &mockFileHttpStrategy{t: t, content: log}
+	s := NewClient(&mockFuncHttpStrategy{
+		sendGet: func(endpoint, nodeId string) ([]byte, error) {
+			res, err := fileStrategy.SendGet(context.Background(), endpoint, nodeId)
+			*transferred += len(res)
+			return res, err
+		},
+	})
+	s.SetNodeId("node-1")
+	s.SetLogFileName("one.log")
+	return s
+}
+
+func Test_client_CatLog_timeRange(t *testing.T) {
+	log := timeRangeLog(10000)
+	tests := []struct {
+		name            string
+		timeRange       TimeRange
+		wantFirst       int
+		wantLast        int
+		wantTransferred int
+	}{
+		{
+			name:            "recent since",
+			timeRange:       TimeRange{Since: timeRangeStart.Add(9900 * time.Second)},
+			wantFirst:       9900,
+			wantLast:        9999,
+			wantTransferred: 2 * initialSinceWindowSize,
+		},
+		{
+			name:            "since between entries",
+			timeRange:       TimeRange{Since: timeRangeStart.Add(9900*time.Second - time.Millisecond)},
+			wantFirst:       9900,
+			wantLast:        9999,
+			wantTransferred: 2 * initialSinceWindowSize,
+		},
+		{
+			name:            "early since",
+			timeRange:       TimeRange{Since: timeRangeStart.Add(100 * time.Second)},
+			wantFirst:       100,
+			wantLast:        9999,
+			wantTransferred: 4 * len(log),
+		},
+		{
+			name:            "since before the log",
+			timeRange:       TimeRange{Since: timeRangeStart.Add(-time.Hour)},
+			wantFirst:       0,
+			wantLast:        9999,
+			wantTransferred: 4 * len(log),
+		},
+		{
+			name:            "since and until",
+			timeRange:       TimeRange{Since: timeRangeStart.Add(9000 * time.Second), Until: timeRangeStart.Add(9010 * time.Second)},
+			wantFirst:       9000,
+			wantLast:        9010,
+			wantTransferred: 4 * (len(log) / 10),
+		},
+		{
+			name:            "until only",
+			timeRange:       TimeRange{Until: timeRangeStart.Add(5 * time.Second)},
+			wantFirst:       0,
+			wantLast:        5,
+			wantTransferred: 2 * len(log),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transferred := 0
+			s := newTimeRangeClient(t, log, &transferred)
+			s.SetTimeRange(tt.timeRange)
+			out := &bytes.Buffer{}
+			require.NoError(t, s.CatLog(context.Background(), out))
+
+			var want strings.Builder
+			for idx := tt.wantFirst; idx <= tt.wantLast; idx++ {
+				want.WriteString(timeRangeLine(idx))
+			}
+			require.Equal(t, want.String(), out.String())
+			require.True(t, transferred <= tt.wantTransferred, "transferred %d bytes", transferred)
+		})
+	}
+}
+
+func Test_client_CatLog_sinceAfterTheLog(t *testing.T) {
+	transferred := 0
+	s := newTimeRangeClient(t, timeRangeLog(10), &transferred)
+	s.SetTimeRange(TimeRange{Since: timeRangeStart.Add(time.Hour)})
+	out := &bytes.Buffer{}
+	require.NoError(t, s.CatLog(context.Background(), out))
+	require.Empty(t, out.String())
+}
+
+func Test_client_CatLog_sinceWithoutTimestamps(t *testing.T) {
+	// lines of the legacy format, whose timestamps are not parsed
+	legacyLog := strings.Repeat("2020-12-06 19:00:00,000 [http-nio-8081-exec-1] [INFO ] (o.a.Some:58) - legacy entry\n", 2000)
+	tests := []struct {
+		name            string
+		log             string
+		wantNotice      string
+		wantTransferred int
+	}{
+		{
+			name:            "a log larger than the first window",
+			log:             legacyLog,
+			wantNotice:      "- Log one.log of node node-1 has no timestamps in its last 65536 bytes, so no entries at or after 2020-12-06T19:00:00Z were found\n",
+			wantTransferred: 2 * initialSinceWindowSize,
+		},
+		{
+			name:            "a log smaller than the first window",
+			log:             legacyLog[:1000],
+			wantNotice:      "- Log one.log of node node-1 has no timestamps in its last 1000 bytes, so no entries at or after 2020-12-06T19:00:00Z were found\n",
+			wantTransferred: 2 * initialSinceWindowSize,
+		},
+		{
+			name:            "a single line larger than the first window",
+			log:             strings.Repeat("x", 3*initialSinceWindowSize),
+			wantNotice:      "- Log one.log of node node-1 has no timestamps in its last 65536 bytes, so no entries at or after 2020-12-06T19:00:00Z were found\n",
+			wantTransferred: 2 * initialSinceWindowSize,
+		},
+		{
+			name:            "an empty log",
+			wantTransferred: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transferred := 0
+			s := newTimeRangeClient(t, tt.log, &transferred)
+			s.SetTimeRange(TimeRange{Since: timeRangeStart})
+			notices := &bytes.Buffer{}
+			s.SetNoticeOutput(notices)
+			out := &bytes.Buffer{}
+			require.NoError(t, s.CatLog(context.Background(), out))
+			require.Empty(t, out.String())
+			require.Equal(t, tt.wantNotice, notices.String())
+			// the search stops after the first window
+			require.True(t, transferred <= tt.wantTransferred, "transferred %d bytes", transferred)
+		})
+	}
+}
+
+func Test_client_TailLog_timeRange(t *testing.T) {
+	transferred := 0
+	s := newTimeRangeClient(t, timeRangeLog(100), &transferred)
+	s.SetLogsRefreshRate(10 * time.Millisecond)
+	s.SetTimeRange(TimeRange{Since: timeRangeStart.Add(50 * time.Second), Until: timeRangeStart.Add(60 * time.Second)})
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	out := &bytes.Buffer{}
+	require.NoError(t, s.TailLog(timeoutCtx, out))
+	require.NoError(t, timeoutCtx.Err(), "tailing stops once the until has passed")
+	require.True(t, strings.HasPrefix(out.String(), timeRangeLine(50)))
+	require.True(t, strings.HasSuffix(out.String(), timeRangeLine(60)))
+}
+
+func TestUntilWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := &untilWriter{output: out, until: timeRangeStart.Add(2 * time.Second)}
+	for _, chunk := range []string{timeRangeLine(0) + timeRangeLine(1)[:10], timeRangeLine(1)[10:] + "\tat more.trace\n", timeRangeLine(2) + timeRangeLine(3) + timeRangeLine(4)} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		require.Equal(t, len(chunk), n)
+	}
+	require.NoError(t, w.Close())
+	require.True(t, w.passed)
+	require.Equal(t, timeRangeLine(0)+timeRangeLine(1)+"\tat more.trace\n"+timeRangeLine(2), out.String())
+}
+
+func TestUntilWriter_partialLine(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := &untilWriter{output: out, until: timeRangeStart.Add(time.Hour)}
+	_, err := w.Write([]byte(timeRangeLine(1) + "partial"))
+	require.NoError(t, err)
+	require.Equal(t, timeRangeLine(1), out.String())
+	require.NoError(t, w.Close())
+	require.Equal(t, timeRangeLine(1)+"partial", out.String())
+	require.False(t, w.passed)
+}
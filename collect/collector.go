@@ -0,0 +1,268 @@
+package collect
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMaxConcurrency = 4
+
+// Collects every log of every node of a remote service into a support bundle,
+// a gzipped tar archive laid out as <node_id>/<log_name>, with a manifest at its root.
+type Collector struct {
+	httpStrategy   strategy.Http
+	serverUrl      string
+	maxConcurrency int
+	noticeOutput   io.Writer
+}
+
+func NewCollector(httpStrategy strategy.Http) *Collector {
+	return &Collector{
+		httpStrategy:   httpStrategy,
+		maxConcurrency: defaultMaxConcurrency,
+		noticeOutput:   ioutil.Discard,
+	}
+}
+
+// Sets the url of the remote service, recorded in the manifest.
+func (c *Collector) SetServerUrl(serverUrl string) {
+	c.serverUrl = serverUrl
+}
+
+// Sets the maximal number of concurrent requests, defaulting to 4.
+func (c *Collector) SetMaxConcurrency(maxConcurrency int) {
+	c.maxConcurrency = maxConcurrency
+}
+
+// Sets the io.Writer that notices, such as failures of single logs, are written into.
+// Notices are discarded by default.
+func (c *Collector) SetNoticeOutput(noticeOutput io.Writer) {
+	c.noticeOutput = noticeOutput
+}
+
+// Writes the support bundle into the passed io.Writer, and returns its manifest.
+// Failures of single nodes or logs are recorded in the manifest rather than returned,
+// so an error is returned only when the nodes cannot be queried, or the bundle cannot be written.
+func (c *Collector) Collect(ctx context.Context, output io.Writer) (*Manifest, error) {
+	manifest := &Manifest{
+		Version:   ManifestVersion,
+		ServerUrl: c.serverUrl,
+		StartedAt: time.Now().UTC(),
+	}
+	nodeIds, err := livelog.NewClient(c.httpStrategy).GetServiceNodeIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifest.NodeIds = nodeIds
+
+	gzipWriter := gzip.NewWriter(output)
+	bundle := &bundleWriter{tarWriter: tar.NewWriter(gzipWriter)}
+	logs := c.collectNodes(ctx, nodeIds, manifest, bundle)
+	if bundle.err != nil {
+		return nil, bundle.err
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].NodeId != logs[j].NodeId {
+			return logs[i].NodeId < logs[j].NodeId
+		}
+		return logs[i].LogName < logs[j].LogName
+	})
+	manifest.Logs = logs
+	manifest.FinishedAt = time.Now().UTC()
+
+	manifestContent, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = bundle.writeFile(ManifestFileName, bytes.NewReader(manifestContent), int64(len(manifestContent)), manifest.FinishedAt); err != nil {
+		return nil, err
+	}
+	if err = bundle.tarWriter.Close(); err != nil {
+		return nil, err
+	}
+	if err = gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Queries the log names of every node and downloads every log into the bundle,
+// with up to maxConcurrency requests at a time.
+func (c *Collector) collectNodes(ctx context.Context, nodeIds []string, manifest *Manifest, bundle *bundleWriter) []ManifestLog {
+	maxConcurrency := c.maxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+	semaphore := make(chan struct{}, maxConcurrency)
+	var mu sync.Mutex
+	var logs []ManifestLog
+	var wg sync.WaitGroup
+	for _, nodeId := range nodeIds {
+		wg.Add(1)
+		go func(nodeId string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			logNames, err := c.logNames(ctx, nodeId)
+			<-semaphore
+			if err != nil {
+				c.writeNotice("- Failed to query the logs of node %s: %v\n", nodeId, err)
+				mu.Lock()
+				if manifest.NodeErrors == nil {
+					manifest.NodeErrors = make(map[string]string)
+				}
+				manifest.NodeErrors[nodeId] = err.Error()
+				mu.Unlock()
+				return
+			}
+			for _, logName := range logNames {
+				wg.Add(1)
+				go func(logName string) {
+					defer wg.Done()
+					semaphore <- struct{}{}
+					log := c.collectLog(ctx, nodeId, logName, bundle)
+					<-semaphore
+					mu.Lock()
+					logs = append(logs, log)
+					mu.Unlock()
+				}(logName)
+			}
+		}(nodeId)
+	}
+	wg.Wait()
+	return logs
+}
+
+func (c *Collector) logNames(ctx context.Context, nodeId string) ([]string, error) {
+	client := livelog.NewClient(c.httpStrategy)
+	client.SetNodeId(nodeId)
+	config, err := client.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return config.LogFileNames, nil
+}
+
+func (c *Collector) collectLog(ctx context.Context, nodeId, logName string, bundle *bundleWriter) ManifestLog {
+	log := ManifestLog{NodeId: nodeId, LogName: logName}
+	err := c.downloadLog(ctx, &log, bundle)
+	if err != nil {
+		c.writeNotice("- Failed to collect log %s of node %s: %v\n", logName, nodeId, err)
+		log.Path = ""
+		log.Error = err.Error()
+	}
+	return log
+}
+
+// Downloads the log into a temporary file, so that only the bundle holds its content, and writes it into the bundle once its size is known.
+func (c *Collector) downloadLog(ctx context.Context, log *ManifestLog, bundle *bundleWriter) (err error) {
+	bundlePath, err := bundlePath(log.NodeId, log.LogName)
+	if err != nil {
+		return err
+	}
+	tempFile, err := ioutil.TempFile("", "forest-collect-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tempFile.Close()
+		if removeErr := os.Remove(tempFile.Name()); err == nil {
+			err = removeErr
+		}
+	}()
+	client := livelog.NewClient(c.httpStrategy)
+	client.SetNodeId(log.NodeId)
+	client.SetLogFileName(log.LogName)
+	checksum := sha256.New()
+	pageMarker, err := client.ReadLog(ctx, 0, io.MultiWriter(tempFile, checksum))
+	log.CollectedAt = time.Now().UTC()
+	if err != nil {
+		return err
+	}
+	size, err := tempFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err = tempFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err = bundle.writeFile(bundlePath, tempFile, size, log.CollectedAt); err != nil {
+		return err
+	}
+	log.Path = bundlePath
+	log.Size = size
+	log.PageMarker = pageMarker
+	log.Sha256 = hex.EncodeToString(checksum.Sum(nil))
+	return nil
+}
+
+// Returns the cleaned path of the log within the bundle, <node_id>/<log_name>.
+// Names that could be extracted outside of the directory of the node, with a ".." component, as an absolute path or under a drive,
+// and node ids of more than a single component, are rejected. Backslashes are separators to extractors on Windows.
+func bundlePath(nodeId, logName string) (string, error) {
+	if nodeId == "" || nodeId == "." || nodeId == ".." || strings.ContainsAny(nodeId, `/\:`) {
+		return "", fmt.Errorf("invalid node id [%v]", nodeId)
+	}
+	logPath := strings.Replace(logName, `\`, "/", -1)
+	for _, component := range strings.Split(logPath, "/") {
+		if component == ".." {
+			return "", fmt.Errorf("invalid log name [%v]", logName)
+		}
+	}
+	logPath = path.Clean(logPath)
+	if logPath == "." || path.IsAbs(logPath) || strings.Contains(strings.SplitN(logPath, "/", 2)[0], ":") {
+		return "", fmt.Errorf("invalid log name [%v]", logName)
+	}
+	return nodeId + "/" + logPath, nil
+}
+
+func (c *Collector) writeNotice(format string, args ...interface{}) {
+	if c.noticeOutput != nil {
+		_, _ = fmt.Fprintf(c.noticeOutput, format, args...)
+	}
+}
+
+// Serializes the writes of concurrently collected logs into a tar.Writer.
+// Once a write fails, the error is kept and every following write fails with it,
+// since the archive is corrupted.
+type bundleWriter struct {
+	mu        sync.Mutex
+	tarWriter *tar.Writer
+	err       error
+}
+
+// Writes a file of the passed size, whose content is read from the passed io.Reader.
+func (b *bundleWriter) writeFile(name string, content io.Reader, size int64, modTime time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  modTime,
+	}
+	if b.err = b.tarWriter.WriteHeader(header); b.err != nil {
+		return b.err
+	}
+	_, b.err = io.Copy(b.tarWriter, content)
+	return b.err
+}
@@ -0,0 +1,179 @@
+package collect
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/constants"
+	"github.com/hanoch-jfrog/forest/client/livelog/fakeserver"
+	"github.com/hanoch-jfrog/forest/client/livelog/model"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	logsDir, err := ioutil.TempDir("", "collect")
+	require.NoError(t, err)
+	defer os.RemoveAll(logsDir)
+	server := fakeserver.New(fakeserver.Config{LogsDir: logsDir})
+	require.NoError(t, server.AppendLog("node-1", "console.log", "console of node 1\n"))
+	require.NoError(t, server.AppendLog("node-1", "access-service.log", "access of node 1\n"))
+	require.NoError(t, server.AppendLog("node-2", "console.log", "console of node 2\n"))
+	require.NoError(t, server.AppendLog("node-2", "broken.log", "never collected\n"))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	httpStrategy, err := strategy.NewStandaloneHttpStrategy(strategy.StandaloneHttpConfig{BaseUrl: httpServer.URL + "/artifactory/"})
+	require.NoError(t, err)
+
+	collector := NewCollector(&failingHttpStrategy{Http: httpStrategy, failingLogName: "broken.log"})
+	collector.SetServerUrl("http://acme/artifactory/")
+	collector.SetMaxConcurrency(2)
+	notices := &bytes.Buffer{}
+	collector.SetNoticeOutput(notices)
+	bundle := &bytes.Buffer{}
+	manifest, err := collector.Collect(context.Background(), bundle)
+	require.NoError(t, err)
+
+	require.Equal(t, ManifestVersion, manifest.Version)
+	require.Equal(t, "http://acme/artifactory/", manifest.ServerUrl)
+	require.Equal(t, []string{"node-1", "node-2"}, manifest.NodeIds)
+	require.Len(t, manifest.Logs, 4)
+	require.Equal(t, "node-1/access-service.log", manifest.Logs[0].Path)
+	require.Equal(t, "node-1/console.log", manifest.Logs[1].Path)
+	require.Equal(t, "", manifest.Logs[2].Path)
+	require.Contains(t, manifest.Logs[2].Error, "broken")
+	require.Equal(t, "node-2/console.log", manifest.Logs[3].Path)
+	checksum := sha256.Sum256([]byte("console of node 2\n"))
+	require.Equal(t, hex.EncodeToString(checksum[:]), manifest.Logs[3].Sha256)
+	require.Equal(t, int64(18), manifest.Logs[3].Size)
+	require.Equal(t, int64(18), manifest.Logs[3].PageMarker)
+	require.Contains(t, notices.String(), "- Failed to collect log broken.log of node node-2")
+
+	files := readBundle(t, bundle)
+	require.Len(t, files, 4)
+	require.Equal(t, "console of node 1\n", files["node-1/console.log"])
+	require.Equal(t, "access of node 1\n", files["node-1/access-service.log"])
+	require.Equal(t, "console of node 2\n", files["node-2/console.log"])
+	bundledManifest := &Manifest{}
+	require.NoError(t, json.Unmarshal([]byte(files[ManifestFileName]), bundledManifest))
+	require.Equal(t, manifest.Logs, bundledManifest.Logs)
+}
+
+func readBundle(t *testing.T, bundle io.Reader) map[string]string {
+	gzipReader, err := gzip.NewReader(bundle)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+	files := make(map[string]string)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(tarReader)
+		require.NoError(t, err)
+		files[header.Name] = string(content)
+	}
+}
+
+type failingHttpStrategy struct {
+	strategy.Http
+	failingLogName string
+}
+
+func (s *failingHttpStrategy) SendGet(ctx context.Context, endpoint, nodeId string) ([]byte, error) {
+	if strings.HasSuffix(endpoint, "id="+s.failingLogName) {
+		return nil, fmt.Errorf("log %s is broken", s.failingLogName)
+	}
+	return s.Http.SendGet(ctx, endpoint, nodeId)
+}
+
+// Lists the passed log name in the config of every node, in addition to its own log names.
+type extraLogNameHttpStrategy struct {
+	strategy.Http
+	logName string
+}
+
+func (s *extraLogNameHttpStrategy) SendGet(ctx context.Context, endpoint, nodeId string) ([]byte, error) {
+	resBody, err := s.Http.SendGet(ctx, endpoint, nodeId)
+	if err != nil || endpoint != constants.ConfigEndpoint {
+		return resBody, err
+	}
+	srvConfig := &model.Config{}
+	if err = json.Unmarshal(resBody, srvConfig); err != nil {
+		return nil, err
+	}
+	srvConfig.LogFileNames = append(srvConfig.LogFileNames, s.logName)
+	return json.Marshal(srvConfig)
+}
+
+func TestCollector_Collect_invalidLogName(t *testing.T) {
+	logsDir, err := ioutil.TempDir("", "collect")
+	require.NoError(t, err)
+	defer os.RemoveAll(logsDir)
+	server := fakeserver.New(fakeserver.Config{LogsDir: logsDir})
+	require.NoError(t, server.AppendLog("node-1", "console.log", "console of node 1\n"))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	httpStrategy, err := strategy.NewStandaloneHttpStrategy(strategy.StandaloneHttpConfig{BaseUrl: httpServer.URL + "/artifactory/"})
+	require.NoError(t, err)
+
+	collector := NewCollector(&extraLogNameHttpStrategy{Http: httpStrategy, logName: "../../escape.log"})
+	bundle := &bytes.Buffer{}
+	manifest, err := collector.Collect(context.Background(), bundle)
+	require.NoError(t, err)
+
+	require.Len(t, manifest.Logs, 2)
+	require.Equal(t, "../../escape.log", manifest.Logs[0].LogName)
+	require.Equal(t, "", manifest.Logs[0].Path)
+	require.Equal(t, "invalid log name [../../escape.log]", manifest.Logs[0].Error)
+	files := readBundle(t, bundle)
+	require.Len(t, files, 2)
+	require.Equal(t, "console of node 1\n", files["node-1/console.log"])
+}
+
+func Test_bundlePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodeId  string
+		logName string
+		want    string
+		wantErr string
+	}{
+		{"log", "node-1", "console.log", "node-1/console.log", ""},
+		{"log of a directory", "node-1", "tomcat/localhost.log", "node-1/tomcat/localhost.log", ""},
+		{"uncleaned log name", "node-1", "./tomcat//localhost.log", "node-1/tomcat/localhost.log", ""},
+		{"parent component", "node-1", "../node-2/console.log", "", "invalid log name [../node-2/console.log]"},
+		{"inner parent component", "node-1", "tomcat/../../console.log", "", "invalid log name [tomcat/../../console.log]"},
+		{"backslash parent component", "node-1", `..\console.log`, "", `invalid log name [..\console.log]`},
+		{"absolute log name", "node-1", "/etc/passwd", "", "invalid log name [/etc/passwd]"},
+		{"drive", "node-1", `C:\console.log`, "", `invalid log name [C:\console.log]`},
+		{"current directory", "node-1", ".", "", "invalid log name [.]"},
+		{"empty log name", "node-1", "", "", "invalid log name []"},
+		{"parent node id", "..", "console.log", "", "invalid node id [..]"},
+		{"node id of components", "node-1/..", "console.log", "", "invalid node id [node-1/..]"},
+		{"empty node id", "", "console.log", "", "invalid node id []"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bundlePath(tt.nodeId, tt.logName)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
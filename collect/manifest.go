@@ -0,0 +1,37 @@
+package collect
+
+import "time"
+
+const (
+	// The name of the manifest file, at the root of a support bundle.
+	ManifestFileName = "manifest.json"
+	// The version of the manifest format, incremented on incompatible changes.
+	ManifestVersion = 1
+)
+
+// Describes the content of a support bundle.
+type Manifest struct {
+	Version    int       `json:"version"`
+	ServerUrl  string    `json:"server_url,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	NodeIds    []string  `json:"node_ids"`
+	// The errors of nodes whose log names could not be queried, by node id.
+	NodeErrors map[string]string `json:"node_errors,omitempty"`
+	// The collected logs, sorted by node id and log name, including the ones that failed to download.
+	Logs []ManifestLog `json:"logs"`
+}
+
+type ManifestLog struct {
+	NodeId  string `json:"node_id"`
+	LogName string `json:"log_name"`
+	// The path of the log in the bundle, empty when it failed to download.
+	Path string `json:"path,omitempty"`
+	// The number of bytes collected.
+	Size int64 `json:"size"`
+	// The page marker following the collected data, from which the log may be followed.
+	PageMarker  int64     `json:"page_marker"`
+	Sha256      string    `json:"sha256,omitempty"`
+	CollectedAt time.Time `json:"collected_at"`
+	Error       string    `json:"error,omitempty"`
+}
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/audit"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultAccessLogName = "artifactory-access.log"
+
+type auditConfiguration struct {
+	logName        string
+	timeRange      livelog.TimeRange
+	burstThreshold int
+	burstWindow    time.Duration
+	outputFormat   string
+}
+
+func GetAuditCommand() components.Command {
+	return components.Command{
+		Name:        "audit",
+		Description: "Report the failed logins, brute-force bursts, denied deploys and admin actions of the access log of every node",
+		Aliases:     []string{"a"},
+		Arguments:   getAuditArguments(),
+		Flags:       getAuditFlags(),
+		Action:      auditCmd,
+	}
+}
+
+func getAuditArguments() []components.Argument {
+	return []components.Argument{
+		{Name: "server_id", Description: "JFrog CLI Artifactory server id"},
+	}
+}
+
+func getAuditFlags() []components.Flag {
+	return []components.Flag{
+		components.StringFlag{
+			Name:        "since",
+			Description: "Start from the first event at or after the given time, either a duration ago, e.g. '168h', or a timestamp, e.g. '2020-12-06T00:00:00Z'",
+		},
+		components.StringFlag{
+			Name:        "until",
+			Description: "Stop after the last event at or before the given time, either a duration ago, e.g. '1h', or a timestamp",
+		},
+		components.StringFlag{
+			Name:         "log",
+			Description:  "The name of the access log",
+			DefaultValue: defaultAccessLogName,
+		},
+		components.StringFlag{
+			Name:         "burst-threshold",
+			Description:  "The number of failed logins of a single user or address, within the burst window, that make a brute-force burst",
+			DefaultValue: strconv.Itoa(audit.DefaultBurstThreshold),
+		},
+		components.StringFlag{
+			Name:         "burst-window",
+			Description:  "The time the failed logins of a brute-force burst must fall within, e.g. '30s'",
+			DefaultValue: audit.DefaultBurstWindow.String(),
+		},
+		components.StringFlag{
+			Name:         "output",
+			Description:  "The output format, one of text, json or csv",
+			DefaultValue: "text",
+		},
+	}
+}
+
+func auditCmd(c *components.Context) error {
+	if len(c.Arguments) != 1 {
+		return fmt.Errorf("wrong number of arguments. Expected: 1, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	}
+	conf, err := parseAuditConfiguration(c)
+	if err != nil {
+		return err
+	}
+
+	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
+	defer mainCtxCancel()
+	listenForTermination(mainCtxCancel)
+	return printAudit(mainCtx, c.Arguments[0], conf)
+}
+
+func parseAuditConfiguration(c *components.Context) (*auditConfiguration, error) {
+	conf := &auditConfiguration{
+		logName:        c.GetStringFlagValue("log"),
+		burstThreshold: audit.DefaultBurstThreshold,
+		burstWindow:    audit.DefaultBurstWindow,
+		outputFormat:   c.GetStringFlagValue("output"),
+	}
+	if conf.logName == "" {
+		conf.logName = defaultAccessLogName
+	}
+	if burstThreshold := c.GetStringFlagValue("burst-threshold"); burstThreshold != "" {
+		parsed, err := parsePositiveInt("burst-threshold", burstThreshold)
+		if err != nil {
+			return nil, err
+		}
+		conf.burstThreshold = int(parsed)
+	}
+	var err error
+	if burstWindow := c.GetStringFlagValue("burst-window"); burstWindow != "" {
+		if conf.burstWindow, err = time.ParseDuration(burstWindow); err != nil || conf.burstWindow <= 0 {
+			return nil, fmt.Errorf("invalid burst window [%v], expected a positive duration, e.g. 30s", burstWindow)
+		}
+	}
+	if conf.outputFormat, err = parseOutputFormat(conf.outputFormat, "text", "json", "csv"); err != nil {
+		return nil, err
+	}
+	if conf.timeRange, err = parseTimeRange(c, time.Now()); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func printAudit(ctx context.Context, cliServerId string, conf *auditConfiguration) error {
+	err := validateArgument("server id", cliServerId,
+		func() ([]string, error) {
+			return fetchAllServerIds()
+		})
+	if err != nil {
+		return err
+	}
+	serviceManager, err := newArtifactoryServiceManager(cliServerId)
+	if err != nil {
+		return err
+	}
+	httpStrategy := strategy.NewArtifactoryHttpStrategy(serviceManager)
+	if _, err = validateNodesAndLogs(ctx, httpStrategy, allNodesValue, []string{conf.logName}); err != nil {
+		return err
+	}
+
+	client := livelog.NewMultiNodeClient(httpStrategy)
+	client.SetLogFileNames([]string{conf.logName})
+	client.SetTimeRange(conf.timeRange)
+	client.SetRetryPolicy(livelog.DefaultRetryPolicy())
+	client.SetNoticeOutput(os.Stderr)
+	report := audit.NewReport()
+	report.SetBurstThreshold(conf.burstThreshold)
+	report.SetBurstWindow(conf.burstWindow)
+	if err = client.CatLog(ctx, report.SourceOutput()); err != nil {
+		return err
+	}
+	return writeAuditSummary(os.Stdout, report.Summary(), conf.outputFormat)
+}
+
+func writeAuditSummary(output io.Writer, summary *audit.Summary, outputFormat string) error {
+	switch outputFormat {
+	case "text":
+		return audit.WriteText(output, summary)
+	case "csv":
+		return audit.WriteCsv(output, summary)
+	}
+	return writeJsonLine(output, summary)
+}
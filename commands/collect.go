@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/collect"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultCollectParallel = 4
+
+func GetCollectCommand() components.Command {
+	return components.Command{
+		Name:        "collect",
+		Description: "Collect every log of every node into a support bundle archive",
+		Aliases:     []string{"c"},
+		Arguments:   getCollectArguments(),
+		Flags:       getCollectFlags(),
+		Action:      collectCmd,
+	}
+}
+
+func getCollectArguments() []components.Argument {
+	return []components.Argument{
+		{Name: "server_id", Description: "JFrog CLI Artifactory server id"},
+	}
+}
+
+func getCollectFlags() []components.Flag {
+	return []components.Flag{
+		components.StringFlag{
+			Name:        "output",
+			Description: "The path of the .tar.gz archive to write, defaulting to forest-<server_id>-<timestamp>.tar.gz",
+		},
+		components.StringFlag{
+			Name:         "parallel",
+			Description:  "The maximal number of concurrent requests",
+			DefaultValue: strconv.Itoa(defaultCollectParallel),
+		},
+	}
+}
+
+func collectCmd(c *components.Context) error {
+	if len(c.Arguments) != 1 {
+		return fmt.Errorf("wrong number of arguments. Expected: 1, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	}
+	cliServerId := c.Arguments[0]
+	parallel := int64(defaultCollectParallel)
+	if parallelArg := c.GetStringFlagValue("parallel"); parallelArg != "" {
+		var err error
+		if parallel, err = parsePositiveInt("parallel", parallelArg); err != nil {
+			return err
+		}
+	}
+	outputPath := c.GetStringFlagValue("output")
+	if outputPath == "" {
+		outputPath = defaultBundlePath(cliServerId, time.Now())
+	}
+
+	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
+	defer mainCtxCancel()
+	listenForTermination(mainCtxCancel)
+	return collectBundle(mainCtx, cliServerId, outputPath, int(parallel))
+}
+
+func defaultBundlePath(cliServerId string, now time.Time) string {
+	return fmt.Sprintf("forest-%s-%s.tar.gz", cliServerId, now.UTC().Format("20060102T150405Z"))
+}
+
+func collectBundle(ctx context.Context, cliServerId, outputPath string, parallel int) (err error) {
+	err = validateArgument("server id", cliServerId,
+		func() ([]string, error) {
+			return fetchAllServerIds()
+		})
+	if err != nil {
+		return err
+	}
+	rtDetails, err := getRtDetails(cliServerId)
+	if err != nil {
+		return err
+	}
+	serviceManager, err := newArtifactoryServiceManager(cliServerId)
+	if err != nil {
+		return err
+	}
+
+	collector := collect.NewCollector(strategy.NewArtifactoryHttpStrategy(serviceManager))
+	collector.SetServerUrl(rtDetails.Url)
+	collector.SetMaxConcurrency(parallel)
+	collector.SetNoticeOutput(os.Stderr)
+
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := outputFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(outputPath)
+		}
+	}()
+	manifest, err := collector.Collect(ctx, outputFile)
+	if err != nil {
+		return err
+	}
+
+	var failures int
+	for _, log := range manifest.Logs {
+		if log.Error != "" {
+			failures++
+		}
+	}
+	fmt.Printf("- Collected %d logs of %d nodes into %s\n", len(manifest.Logs)-failures, len(manifest.NodeIds), outputPath)
+	if failures > 0 || len(manifest.NodeErrors) > 0 {
+		fmt.Printf("- %d logs and %d nodes failed, see %s for details\n", failures, len(manifest.NodeErrors), collect.ManifestFileName)
+	}
+	return nil
+}
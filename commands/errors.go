@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/fingerprint"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultErrorsTop = 10
+	// With -f, the fingerprints of the time before the session started are known, so only later ones are new.
+	defaultErrorsBaseline = time.Hour
+)
+
+type errorsConfiguration struct {
+	isStreaming  bool
+	logNames     []string
+	top          int
+	timeRange    livelog.TimeRange
+	outputFormat string
+}
+
+func GetErrorsCommand() components.Command {
+	return components.Command{
+		Name:        "errors",
+		Description: "Group the WARN and ERROR entries of the logs of every selected node by fingerprint",
+		Aliases:     []string{"e"},
+		Arguments:   getErrorsArguments(),
+		Flags:       getErrorsFlags(),
+		Action:      errorsCmd,
+	}
+}
+
+func getErrorsArguments() []components.Argument {
+	return []components.Argument{
+		{Name: "server_id", Description: "JFrog CLI Artifactory server id"},
+		{Name: "node_id", Description: "Selected node id, a comma-separated list of node ids, or 'all' for every node"},
+	}
+}
+
+func getErrorsFlags() []components.Flag {
+	return []components.Flag{
+		components.BoolFlag{
+			Name:         "f",
+			Description:  "Follow the logs, refreshing the report every polling interval, and mark the fingerprints first seen after the session started as new. The fingerprints of the hour before, or since the since time, are known",
+			DefaultValue: false,
+		},
+		components.StringFlag{
+			Name:        "since",
+			Description: "Start from the first entry at or after the given time, either a duration ago, e.g. '30m', or a timestamp, e.g. '2020-12-06T19:00:00Z'",
+		},
+		components.StringFlag{
+			Name:        "until",
+			Description: "Stop after the last entry at or before the given time, either a duration ago, e.g. '10m', or a timestamp. With -f, following stops once this time has passed",
+		},
+		components.StringFlag{
+			Name:        "log",
+			Description: "Selected log name, or a comma-separated list of log names, defaulting to every log",
+		},
+		components.StringFlag{
+			Name:         "top",
+			Description:  "The number of fingerprints to report, starting with the most frequent",
+			DefaultValue: strconv.Itoa(defaultErrorsTop),
+		},
+		components.StringFlag{
+			Name:         "output",
+			Description:  "The output format, either text or json. With -f, a json report is written as a single line every polling interval",
+			DefaultValue: "text",
+		},
+	}
+}
+
+func errorsCmd(c *components.Context) error {
+	if len(c.Arguments) != 2 {
+		return fmt.Errorf("wrong number of arguments. Expected: 2, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	}
+	conf, err := parseErrorsConfiguration(c)
+	if err != nil {
+		return err
+	}
+
+	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
+	defer mainCtxCancel()
+	listenForTermination(mainCtxCancel)
+	return printErrors(mainCtx, c.Arguments[0], c.Arguments[1], conf)
+}
+
+func parseErrorsConfiguration(c *components.Context) (*errorsConfiguration, error) {
+	conf := &errorsConfiguration{
+		isStreaming:  c.GetBoolFlagValue("f"),
+		logNames:     parseList(c.GetStringFlagValue("log")),
+		top:          defaultErrorsTop,
+		outputFormat: c.GetStringFlagValue("output"),
+	}
+	if top := c.GetStringFlagValue("top"); top != "" {
+		parsed, err := parsePositiveInt("top", top)
+		if err != nil {
+			return nil, err
+		}
+		conf.top = int(parsed)
+	}
+	var err error
+	if conf.outputFormat, err = parseOutputFormat(conf.outputFormat, "text", "json"); err != nil {
+		return nil, err
+	}
+	if conf.timeRange, err = parseTimeRange(c, time.Now()); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func printErrors(ctx context.Context, cliServerId, nodeIdsArg string, conf *errorsConfiguration) error {
+	err := validateArgument("server id", cliServerId,
+		func() ([]string, error) {
+			return fetchAllServerIds()
+		})
+	if err != nil {
+		return err
+	}
+	serviceManager, err := newArtifactoryServiceManager(cliServerId)
+	if err != nil {
+		return err
+	}
+	httpStrategy := strategy.NewArtifactoryHttpStrategy(serviceManager)
+	selection, err := validateNodesAndLogs(ctx, httpStrategy, nodeIdsArg, conf.logNames)
+	if err != nil {
+		return err
+	}
+
+	client := livelog.NewMultiNodeClient(httpStrategy)
+	client.SetNodeIds(selection.nodeIds)
+	client.SetLogFileNames(conf.logNames)
+	client.SetLogsRefreshRate(selection.logsRefreshRate)
+	client.SetRetryPolicy(livelog.DefaultRetryPolicy())
+	client.SetNoticeOutput(os.Stderr)
+	report := fingerprint.NewReport()
+	highlight := conf.outputFormat == "text" && terminal.IsTerminal(int(os.Stdout.Fd()))
+	if !conf.isStreaming {
+		client.SetTimeRange(conf.timeRange)
+		if err = client.CatLog(ctx, report.SourceOutput()); err != nil {
+			return err
+		}
+		return writeErrorsSummary(os.Stdout, report.Summary(conf.top), conf.outputFormat, highlight)
+	}
+
+	sessionStart := time.Now()
+	report.SetNewSince(sessionStart)
+	timeRange := conf.timeRange
+	if timeRange.Since.IsZero() {
+		timeRange.Since = sessionStart.Add(-defaultErrorsBaseline)
+	}
+	client.SetTimeRange(timeRange)
+	return tailAndReport(ctx, client, report.SourceOutput(), selection.logsRefreshRate, highlight, func() error {
+		return writeErrorsSummary(os.Stdout, report.Summary(conf.top), conf.outputFormat, highlight)
+	})
+}
+
+func writeErrorsSummary(output io.Writer, summary *fingerprint.Summary, outputFormat string, highlight bool) error {
+	if outputFormat == "text" {
+		return fingerprint.WriteText(output, summary, highlight)
+	}
+	return writeJsonLine(output, summary)
+}
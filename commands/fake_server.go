@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/fakeserver"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// Registers the development commands, such as fake-server, when set to any value.
+	DevCommandsEnvVar = "FOREST_DEV"
+
+	defaultFakeServerListenAddress = "localhost:8082"
+)
+
+func GetFakeServerCommand() components.Command {
+	return components.Command{
+		Name:        "fake-server",
+		Description: "Serve the live log endpoints of a fake Artifactory out of a local directory, for local testing",
+		Arguments:   getFakeServerArguments(),
+		Flags:       getFakeServerFlags(),
+		Action:      fakeServerCmd,
+	}
+}
+
+func getFakeServerArguments() []components.Argument {
+	return []components.Argument{
+		{Name: "logs_dir", Description: "A directory holding a directory per node, each holding that node's log files"},
+	}
+}
+
+func getFakeServerFlags() []components.Flag {
+	return []components.Flag{
+		components.StringFlag{
+			Name:         "listen",
+			Description:  "The address to listen on",
+			DefaultValue: defaultFakeServerListenAddress,
+		},
+		components.StringFlag{
+			Name:        "growth-interval",
+			Description: "Append a generated line to every log on the given interval, e.g. '500ms'",
+		},
+		components.StringFlag{
+			Name:        "rotate-after-bytes",
+			Description: "With growth-interval, rotate any log that grows beyond the given number of bytes",
+		},
+		components.StringFlag{
+			Name:        "latency",
+			Description: "Delay every response by the given duration, e.g. '200ms'",
+		},
+		components.StringFlag{
+			Name:        "error-rate",
+			Description: "The fraction of log data requests, between 0 and 1, that fail",
+		},
+		components.StringFlag{
+			Name:        "error-status",
+			Description: "The status code of failed requests, defaulting to 502",
+		},
+	}
+}
+
+func fakeServerCmd(c *components.Context) error {
+	if len(c.Arguments) != 1 {
+		return fmt.Errorf("wrong number of arguments. Expected: 1, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	}
+	config, err := parseFakeServerConfig(c)
+	if err != nil {
+		return err
+	}
+	config.LogsDir = c.Arguments[0]
+	listenAddress := c.GetStringFlagValue("listen")
+	if listenAddress == "" {
+		listenAddress = defaultFakeServerListenAddress
+	}
+
+	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
+	defer mainCtxCancel()
+	listenForTermination(mainCtxCancel)
+
+	server := fakeserver.New(config)
+	errs := make(chan error, 2)
+	go func() {
+		errs <- server.Run(mainCtx)
+	}()
+	go func() {
+		errs <- http.ListenAndServe(listenAddress, server)
+	}()
+	fmt.Printf("- Serving the logs of %s on http://%s/artifactory/\n", config.LogsDir, listenAddress)
+	return <-errs
+}
+
+func parseFakeServerConfig(c *components.Context) (config fakeserver.Config, err error) {
+	if growthInterval := c.GetStringFlagValue("growth-interval"); growthInterval != "" {
+		if config.GrowthInterval, err = time.ParseDuration(growthInterval); err != nil {
+			return config, fmt.Errorf("invalid growth interval [%v]: %w", growthInterval, err)
+		}
+	}
+	if rotateAfterBytes := c.GetStringFlagValue("rotate-after-bytes"); rotateAfterBytes != "" {
+		if config.RotateAfterBytes, err = parsePositiveInt("rotate-after-bytes", rotateAfterBytes); err != nil {
+			return
+		}
+	}
+	if latency := c.GetStringFlagValue("latency"); latency != "" {
+		if config.Latency, err = time.ParseDuration(latency); err != nil {
+			return config, fmt.Errorf("invalid latency [%v]: %w", latency, err)
+		}
+	}
+	if errorRate := c.GetStringFlagValue("error-rate"); errorRate != "" {
+		config.ErrorRate, err = strconv.ParseFloat(errorRate, 64)
+		if err != nil || config.ErrorRate < 0 || config.ErrorRate > 1 {
+			return config, fmt.Errorf("invalid error rate [%v], expected a number between 0 and 1", errorRate)
+		}
+	}
+	if errorStatus := c.GetStringFlagValue("error-status"); errorStatus != "" {
+		config.ErrorStatusCode, err = strconv.Atoi(errorStatus)
+		if err != nil || config.ErrorStatusCode < 400 || config.ErrorStatusCode > 599 {
+			return config, fmt.Errorf("invalid error status [%v], expected a status code between 400 and 599", errorStatus)
+		}
+	}
+	return config, nil
+}
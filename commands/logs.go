@@ -3,17 +3,45 @@ package commands
 import (
 	"context"
 	"fmt"
+	"github.com/hanoch-jfrog/forest/checkpoint"
 	"github.com/hanoch-jfrog/forest/client/livelog"
 	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/filter"
+	"github.com/hanoch-jfrog/forest/format"
+	"github.com/hanoch-jfrog/forest/parser"
 	"github.com/hanoch-jfrog/forest/util"
 	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"github.com/jfrog/jfrog-cli-core/utils/coreutils"
+	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
 
+const (
+	allNodesValue        = "all"
+	defaultReorderWindow = 2 * time.Second
+)
+
+type logsConfiguration struct {
+	isStreaming   bool
+	resume        bool
+	tui           bool
+	tuiSync       bool
+	tuiBufferSize int64
+	reorderWindow time.Duration
+	tailStart     livelog.TailStart
+	timeRange     livelog.TimeRange
+	retryPolicy   livelog.RetryPolicy
+	// nil when no filter flag is set
+	filter       *filter.Filter
+	outputFormat format.Format
+}
+
 func GetLogsCommand() components.Command {
 	return components.Command{
 		Name:        "logs",
@@ -28,8 +56,8 @@ func GetLogsCommand() components.Command {
 func getLogsArguments() []components.Argument {
 	return []components.Argument{
 		{Name: "server_id", Description: "JFrog CLI Artifactory server id"},
-		{Name: "node_id", Description: "Selected node id"},
-		{Name: "log_name", Description: "Selected log name"},
+		{Name: "node_id", Description: "Selected node id, a comma-separated list of node ids, or 'all' for every node"},
+		{Name: "log_name", Description: "Selected log name, or a comma-separated list of log names"},
 	}
 }
 
@@ -45,11 +73,86 @@ func getLogsFlags() []components.Flag {
 			Description:  "Do 'tail -f' on the log",
 			DefaultValue: false,
 		},
+		components.StringFlag{
+			Name:        "lines",
+			Description: "With -f, start following from the last given number of lines, instead of the beginning of the log",
+		},
+		components.StringFlag{
+			Name:        "bytes",
+			Description: "With -f, start following from the last given number of bytes, instead of the beginning of the log",
+		},
+		components.StringFlag{
+			Name:        "since",
+			Description: "Start from the first entry at or after the given time, either a duration ago, e.g. '30m', or a timestamp, e.g. '2020-12-06T19:00:00Z'",
+		},
+		components.StringFlag{
+			Name:        "until",
+			Description: "Stop after the last entry at or before the given time, either a duration ago, e.g. '10m', or a timestamp. With -f, following stops once this time has passed",
+		},
+		components.BoolFlag{
+			Name:         "tui",
+			Description:  "Follow the logs in a full-screen viewer, with search, filters and pausing, where every selected node and log gets its own pane. Implies -f",
+			DefaultValue: false,
+		},
+		components.BoolFlag{
+			Name:         "tui-sync",
+			Description:  "With tui, start with synced scrolling, where scrolling one pane scrolls every pane to the same time",
+			DefaultValue: false,
+		},
+		components.StringFlag{
+			Name:         "tui-buffer",
+			Description:  "With tui, the maximal memory the viewer keeps lines in, optionally with a K, M or G suffix, after which the oldest lines are dropped",
+			DefaultValue: defaultTuiBufferSize,
+		},
+		components.BoolFlag{
+			Name:         "resume",
+			Description:  "With -f, resume following from where the last run with this flag stopped, and save the progress for the next run",
+			DefaultValue: false,
+		},
+		components.StringFlag{
+			Name:        "retries",
+			Description: "With -f, the maximal number of consecutive retries of a failed request, where 0 means no cap. Set both retries and retry-timeout to 0 to disable retries",
+		},
+		components.StringFlag{
+			Name:         "retry-timeout",
+			Description:  "With -f, how long to keep retrying failed requests before giving up, where 0 means no cap",
+			DefaultValue: livelog.DefaultRetryPolicy().MaxElapsedTime.String(),
+		},
+		components.StringFlag{
+			Name:         "reorder-window",
+			Description:  "How long to hold lines of multiple nodes or logs, in order to write them in timestamp order",
+			DefaultValue: defaultReorderWindow.String(),
+		},
+		components.StringFlag{
+			Name:         "output",
+			Description:  "The output format, one of text, json, ndjson, logfmt or csv. Every json, logfmt or csv record carries the parsed fields, the node id, the log name and the server id",
+			DefaultValue: string(format.Text),
+		},
+		components.StringFlag{
+			Name:        "level",
+			Description: "Show only entries of the given comma-separated levels, where a '+' suffix includes more severe levels, e.g. 'warn+'",
+		},
+		components.StringFlag{
+			Name:        "service",
+			Description: "Show only entries of the given comma-separated service types, e.g. 'jfrt,jfac'",
+		},
+		components.StringFlag{
+			Name:        "thread",
+			Description: "Show only entries whose thread name contains one of the given comma-separated values",
+		},
+		components.StringFlag{
+			Name:        "logger",
+			Description: "Show only entries whose logging location contains one of the given comma-separated values",
+		},
+		components.StringFlag{
+			Name:        "trace-id",
+			Description: "Show only entries of the given comma-separated trace ids",
+		},
 	}
 }
 
 func listenForTermination(cancelCtx context.CancelFunc) {
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGKILL, syscall.SIGABRT)
 	go func() {
 		<-c
@@ -60,12 +163,19 @@ func listenForTermination(cancelCtx context.CancelFunc) {
 }
 
 func logsCmd(c *components.Context) error {
-	isStreaming := c.GetBoolFlagValue("f")
 	isInteractive := c.GetBoolFlagValue("i")
+	conf, err := parseLogsConfiguration(c)
+	if err != nil {
+		return err
+	}
 
 	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
 	defer mainCtxCancel()
-	listenForTermination(mainCtxCancel)
+	if conf.tui {
+		cancelOnTermination(mainCtxCancel)
+	} else {
+		listenForTermination(mainCtxCancel)
+	}
 
 	if !isInteractive {
 		if len(c.Arguments) != 3 {
@@ -74,12 +184,157 @@ func logsCmd(c *components.Context) error {
 		serverId := c.Arguments[0]
 		nodeId := c.Arguments[1]
 		logFileName := c.Arguments[2]
-		return buildServiceFromArguments(mainCtx, serverId, nodeId, logFileName, isStreaming)
+		return buildServiceFromArguments(mainCtx, serverId, nodeId, logFileName, conf)
+	}
+	return interactiveMenu(mainCtx, conf)
+}
+
+func parseLogsConfiguration(c *components.Context) (*logsConfiguration, error) {
+	conf := &logsConfiguration{
+		tui:           c.GetBoolFlagValue("tui"),
+		tuiSync:       c.GetBoolFlagValue("tui-sync"),
+		resume:        c.GetBoolFlagValue("resume"),
+		reorderWindow: defaultReorderWindow,
+	}
+	conf.isStreaming = c.GetBoolFlagValue("f") || conf.tui
+	if conf.resume && !conf.isStreaming {
+		return nil, fmt.Errorf("the resume flag requires the f flag")
+	}
+	if conf.tuiSync && !conf.tui {
+		return nil, fmt.Errorf("the tui-sync flag requires the tui flag")
+	}
+	var err error
+	tuiBufferSize := c.GetStringFlagValue("tui-buffer")
+	if tuiBufferSize == "" {
+		tuiBufferSize = defaultTuiBufferSize
+	}
+	if conf.tuiBufferSize, err = parseByteSize("tui-buffer", tuiBufferSize); err != nil {
+		return nil, err
+	}
+	if reorderWindow := c.GetStringFlagValue("reorder-window"); reorderWindow != "" {
+		if conf.reorderWindow, err = time.ParseDuration(reorderWindow); err != nil {
+			return nil, fmt.Errorf("invalid reorder window [%v]: %w", reorderWindow, err)
+		}
+	}
+	if conf.tailStart, err = parseTailStart(c, conf.isStreaming); err != nil {
+		return nil, err
+	}
+	if conf.timeRange, err = parseTimeRange(c, time.Now()); err != nil {
+		return nil, err
+	}
+	if !conf.timeRange.Since.IsZero() && conf.tailStart != (livelog.TailStart{}) {
+		return nil, fmt.Errorf("the since flag cannot be used along with the lines and bytes flags")
+	}
+	if conf.retryPolicy, err = parseRetryPolicy(c); err != nil {
+		return nil, err
+	}
+	logsFilter, err := parseFilter(c)
+	if err != nil {
+		return nil, err
+	}
+	if !logsFilter.IsEmpty() {
+		conf.filter = logsFilter
+	}
+	if conf.outputFormat, err = format.ParseFormat(c.GetStringFlagValue("output")); err != nil {
+		return nil, err
+	}
+	if conf.tui && conf.outputFormat != format.Text {
+		return nil, fmt.Errorf("the tui flag cannot be used along with the %v output format", conf.outputFormat)
+	}
+	return conf, nil
+}
+
+func parseTailStart(c *components.Context, isStreaming bool) (tailStart livelog.TailStart, err error) {
+	lines, bytes := c.GetStringFlagValue("lines"), c.GetStringFlagValue("bytes")
+	if lines == "" && bytes == "" {
+		return
+	}
+	if !isStreaming {
+		return tailStart, fmt.Errorf("the lines and bytes flags require the f flag")
+	}
+	if lines != "" && bytes != "" {
+		return tailStart, fmt.Errorf("only one of the lines and bytes flags may be set")
+	}
+	if lines != "" {
+		tailStart.Lines, err = parsePositiveInt("lines", lines)
+		return
+	}
+	tailStart.Bytes, err = parsePositiveInt("bytes", bytes)
+	return
+}
+
+func parseTimeRange(c *components.Context, now time.Time) (timeRange livelog.TimeRange, err error) {
+	if since := c.GetStringFlagValue("since"); since != "" {
+		if timeRange.Since, err = parseTime("since", since, now); err != nil {
+			return
+		}
+	}
+	if until := c.GetStringFlagValue("until"); until != "" {
+		if timeRange.Until, err = parseTime("until", until, now); err != nil {
+			return
+		}
+	}
+	if !timeRange.Since.IsZero() && !timeRange.Until.IsZero() && timeRange.Until.Before(timeRange.Since) {
+		return timeRange, fmt.Errorf("the until time [%v] is before the since time [%v]", timeRange.Until, timeRange.Since)
+	}
+	return
+}
+
+// Parses either a non-negative duration before now, or an ISO-8601 timestamp.
+func parseTime(flagName, value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+	if timestamp, ok := parser.ParseTimestamp(value); ok && !strings.ContainsAny(value, " \t|[") {
+		return timestamp, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid %v value [%v], expected a duration such as 30m, or a timestamp such as 2020-12-06T19:00:00Z", flagName, value)
+}
+
+func parseRetryPolicy(c *components.Context) (livelog.RetryPolicy, error) {
+	retryPolicy := livelog.DefaultRetryPolicy()
+	if retries := c.GetStringFlagValue("retries"); retries != "" {
+		maxRetries, err := strconv.Atoi(retries)
+		if err != nil || maxRetries < 0 {
+			return retryPolicy, fmt.Errorf("invalid retries value [%v], expected a non-negative number", retries)
+		}
+		retryPolicy.MaxRetries = maxRetries
+	}
+	if retryTimeout := c.GetStringFlagValue("retry-timeout"); retryTimeout != "" {
+		maxElapsedTime, err := time.ParseDuration(retryTimeout)
+		if err != nil || maxElapsedTime < 0 {
+			return retryPolicy, fmt.Errorf("invalid retry timeout [%v], expected a non-negative duration", retryTimeout)
+		}
+		retryPolicy.MaxElapsedTime = maxElapsedTime
+	}
+	return retryPolicy, nil
+}
+
+func parsePositiveInt(flagName, value string) (int64, error) {
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("invalid %v value [%v], expected a positive number", flagName, value)
+	}
+	return parsed, nil
+}
+
+func parseFilter(c *components.Context) (*filter.Filter, error) {
+	logsFilter := &filter.Filter{
+		Services: parseList(c.GetStringFlagValue("service")),
+		Threads:  parseList(c.GetStringFlagValue("thread")),
+		Loggers:  parseList(c.GetStringFlagValue("logger")),
+		TraceIds: parseList(c.GetStringFlagValue("trace-id")),
+	}
+	if levels := c.GetStringFlagValue("level"); levels != "" {
+		var err error
+		if logsFilter.Levels, err = filter.ParseLevels(levels); err != nil {
+			return nil, err
+		}
 	}
-	return interactiveMenu(mainCtx, isStreaming)
+	return logsFilter, nil
 }
 
-func buildServiceFromArguments(ctx context.Context, cliServerId, nodeId, logName string, isStreaming bool) error {
+func buildServiceFromArguments(ctx context.Context, cliServerId, nodeIdsArg, logNamesArg string, conf *logsConfiguration) error {
 	err := validateArgument("server id", cliServerId,
 		func() ([]string, error) {
 			return fetchAllServerIds()
@@ -93,33 +348,131 @@ func buildServiceFromArguments(ctx context.Context, cliServerId, nodeId, logName
 		return err
 	}
 	artifactoryHttpStrategy := strategy.NewArtifactoryHttpStrategy(serviceManager)
-	client := livelog.NewClient(artifactoryHttpStrategy)
-
-	err = validateArgument("node id", nodeId,
-		func() ([]string, error) {
-			return client.GetServiceNodeIds(ctx)
-		})
+	logNames := parseList(logNamesArg)
+	if len(logNames) == 0 {
+		logNames = []string{logNamesArg}
+	}
+	selection, err := validateNodesAndLogs(ctx, artifactoryHttpStrategy, nodeIdsArg, logNames)
 	if err != nil {
 		return err
 	}
-	client.SetNodeId(nodeId)
+	nodeIds := selection.nodeIds
+	if conf.tui {
+		if len(nodeIds) == 0 {
+			nodeIds = selection.availableNodeIds
+		}
+		return runTui(ctx, artifactoryHttpStrategy, cliServerId, tuiPaneSpecs(nodeIds, logNames), conf)
+	}
+	if len(nodeIds) != 1 || len(logNames) != 1 {
+		return printMultiNodeLogs(ctx, artifactoryHttpStrategy, cliServerId, nodeIds, logNames, selection.logsRefreshRate, conf)
+	}
+	client := livelog.NewClient(artifactoryHttpStrategy)
+	client.SetNodeId(nodeIds[0])
+	client.SetLogFileName(logNames[0])
+	client.SetLogsRefreshRate(selection.logsRefreshRate)
+	return printLogs(ctx, client, format.Origin{ServerId: cliServerId, NodeId: nodeIds[0], LogName: logNames[0]}, conf)
+}
 
-	var logsRefreshRate time.Duration
-	err = validateArgument("log name", logName,
-		func() ([]string, error) {
-			srvConfig, fetchErr := client.GetConfig(ctx)
-			if fetchErr != nil {
-				return nil, fetchErr
-			}
-			logsRefreshRate = util.MillisToDuration(srvConfig.RefreshRateMillis)
-			return srvConfig.LogFileNames, nil
-		})
+// The nodes and logs selected by the arguments of a command, validated by validateNodesAndLogs.
+type nodesAndLogs struct {
+	// The selected node ids, nil meaning every node.
+	nodeIds []string
+	// The ids of every node of the remote service.
+	availableNodeIds []string
+	// The refresh rate of the first selected node.
+	logsRefreshRate time.Duration
+}
+
+// Validates the passed node id argument, and the passed log names on every selected node.
+func validateNodesAndLogs(ctx context.Context, httpStrategy strategy.Http, nodeIdsArg string, logNames []string) (*nodesAndLogs, error) {
+	client := livelog.NewClient(httpStrategy)
+	availableNodeIds, err := client.GetServiceNodeIds(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	client.SetLogFileName(logName)
-	client.SetLogsRefreshRate(logsRefreshRate)
-	return printLogs(ctx, client, isStreaming)
+	nodeIds := parseNodeIds(nodeIdsArg)
+	for _, nodeId := range nodeIds {
+		err = validateArgument("node id", nodeId,
+			func() ([]string, error) {
+				return availableNodeIds, nil
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+	selectedNodeIds := nodeIds
+	if len(selectedNodeIds) == 0 {
+		selectedNodeIds = availableNodeIds
+	}
+	// the log names of every selected node, since nodes may have different logs
+	nodeLogNames := make([][]string, len(selectedNodeIds))
+	var refreshRateMillis int64
+	for idx, nodeId := range selectedNodeIds {
+		client.SetNodeId(nodeId)
+		srvConfig, err := client.GetConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		nodeLogNames[idx] = srvConfig.LogFileNames
+		if idx == 0 {
+			refreshRateMillis = srvConfig.RefreshRateMillis
+		}
+	}
+	for _, logName := range logNames {
+		var missingNodeIds []string
+		for idx, nodeId := range selectedNodeIds {
+			if !util.InSlice(nodeLogNames[idx], logName) {
+				missingNodeIds = append(missingNodeIds, nodeId)
+			}
+		}
+		if len(missingNodeIds) == len(selectedNodeIds) {
+			err = validateArgument("log name", logName,
+				func() ([]string, error) {
+					return nodeLogNames[0], nil
+				})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(missingNodeIds) > 0 {
+			return nil, fmt.Errorf("log name not found [%v] on the node ids [%v], consider selecting only the nodes that have it", logName, util.SliceToCsv(missingNodeIds))
+		}
+	}
+	return &nodesAndLogs{
+		nodeIds:          nodeIds,
+		availableNodeIds: availableNodeIds,
+		logsRefreshRate:  util.MillisToDuration(refreshRateMillis),
+	}, nil
+}
+
+// Parses the output flag of a report into one of the passed output formats, defaulting to text.
+func parseOutputFormat(outputFormat string, outputFormats ...string) (string, error) {
+	if outputFormat == "" {
+		return "text", nil
+	}
+	if !util.InSlice(outputFormats, outputFormat) {
+		return "", fmt.Errorf("unknown output format [%v], expected one of [%v]", outputFormat, util.SliceToCsv(outputFormats))
+	}
+	return outputFormat, nil
+}
+
+// Parses the node id argument into the selected node ids, returning nil when every node is selected.
+func parseNodeIds(nodeIdsArg string) []string {
+	if nodeIdsArg == allNodesValue {
+		return nil
+	}
+	return parseList(nodeIdsArg)
+}
+
+// Parses a comma-separated argument into its distinct, non-empty values.
+func parseList(arg string) []string {
+	var values []string
+	for _, value := range strings.Split(arg, ",") {
+		if value = strings.TrimSpace(value); value != "" && !util.InSlice(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
 }
 
 func validateArgument(argumentName string, wantedVal string, allValues func() ([]string, error)) error {
@@ -136,7 +489,7 @@ func validateArgument(argumentName string, wantedVal string, allValues func() ([
 	return nil
 }
 
-func interactiveMenu(ctx context.Context, isStreaming bool) error {
+func interactiveMenu(ctx context.Context, conf *logsConfiguration) error {
 	selectedCliServerId, err := selectCliServerId()
 	if err != nil {
 		return err
@@ -147,23 +500,136 @@ func interactiveMenu(ctx context.Context, isStreaming bool) error {
 	}
 	artifactoryStrategy := strategy.NewArtifactoryHttpStrategy(serviceManager)
 	client := livelog.NewClient(artifactoryStrategy)
-	nodeId, err := selectNodeId(ctx, client)
+	nodeId, availableNodeIds, err := selectNodeId(ctx, client)
 	if err != nil {
 		return err
 	}
-	client.SetNodeId(nodeId)
+	if nodeId == allNodesValue {
+		client.SetNodeId(availableNodeIds[0])
+	} else {
+		client.SetNodeId(nodeId)
+	}
 	logName, logsRefreshRate, err := selectLogNameAndFetchRefreshRate(ctx, client)
 	if err != nil {
 		return err
 	}
+	if conf.tui {
+		nodeIds := availableNodeIds
+		if nodeId != allNodesValue {
+			nodeIds = []string{nodeId}
+		}
+		return runTui(ctx, artifactoryStrategy, selectedCliServerId, tuiPaneSpecs(nodeIds, []string{logName}), conf)
+	}
+	if nodeId == allNodesValue {
+		return printMultiNodeLogs(ctx, artifactoryStrategy, selectedCliServerId, nil, []string{logName}, logsRefreshRate, conf)
+	}
 	client.SetLogFileName(logName)
 	client.SetLogsRefreshRate(logsRefreshRate)
-	return printLogs(ctx, client, isStreaming)
+	return printLogs(ctx, client, format.Origin{ServerId: selectedCliServerId, NodeId: nodeId, LogName: logName}, conf)
+}
+
+func printLogs(ctx context.Context, client livelog.Client, origin format.Origin, conf *logsConfiguration) (err error) {
+	client.SetNoticeOutput(os.Stderr)
+	if err = configureClient(client, origin, conf); err != nil {
+		return err
+	}
+	var output io.Writer = os.Stdout
+	if conf.outputFormat != format.Text {
+		if _, err = output.Write(format.Header(conf.outputFormat)); err != nil {
+			return err
+		}
+		formatWriter := format.NewWriter(output, conf.outputFormat, origin)
+		defer func() {
+			if closeErr := formatWriter.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		output = formatWriter
+	}
+	if conf.filter != nil {
+		filterWriter := filter.NewWriter(output, conf.filter)
+		defer func() {
+			if closeErr := filterWriter.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		output = filterWriter
+	}
+	if conf.isStreaming {
+		return client.TailLog(ctx, output)
+	}
+	return client.CatLog(ctx, output)
 }
 
-func printLogs(ctx context.Context, client livelog.Client, isStreaming bool) error {
-	if isStreaming {
-		return client.TailLog(ctx, os.Stdout)
+// Applies the tail start, time range, retry policy and resume flag of the passed configuration to the passed client.
+func configureClient(client livelog.Client, origin format.Origin, conf *logsConfiguration) error {
+	client.SetTailStart(conf.tailStart)
+	client.SetTimeRange(conf.timeRange)
+	client.SetRetryPolicy(conf.retryPolicy)
+	if conf.resume {
+		store, err := newCheckpointStore()
+		if err != nil {
+			return err
+		}
+		client.SetCheckpoint(store.Checkpoint(checkpoint.Key{ServerId: origin.ServerId, NodeId: origin.NodeId, LogName: origin.LogName}))
+	}
+	return nil
+}
+
+func printMultiNodeLogs(ctx context.Context, httpStrategy strategy.Http, cliServerId string, nodeIds, logNames []string, logsRefreshRate time.Duration, conf *logsConfiguration) (err error) {
+	client := livelog.NewMultiNodeClient(httpStrategy)
+	client.SetNodeIds(nodeIds)
+	client.SetLogFileNames(logNames)
+	client.SetLogsRefreshRate(logsRefreshRate)
+	client.SetTailStart(conf.tailStart)
+	client.SetTimeRange(conf.timeRange)
+	client.SetRetryPolicy(conf.retryPolicy)
+	client.SetNoticeOutput(os.Stderr)
+	if conf.resume {
+		store, err := newCheckpointStore()
+		if err != nil {
+			return err
+		}
+		client.SetCheckpoints(func(source livelog.Source) livelog.Checkpoint {
+			return store.Checkpoint(checkpoint.Key{ServerId: cliServerId, NodeId: source.NodeId, LogName: source.LogName})
+		})
+	}
+
+	linePrefix := livelog.NodeIdPrefix
+	if len(logNames) > 1 {
+		linePrefix = livelog.NodeIdAndLogNamePrefix
+	}
+	if _, err = os.Stdout.Write(format.Header(conf.outputFormat)); err != nil {
+		return err
+	}
+	merger := livelog.NewMerger(os.Stdout, linePrefix, conf.reorderWindow)
+	if conf.outputFormat != format.Text {
+		merger.SetEntryFormat(func(source livelog.Source, logLine *parser.LogLine) []byte {
+			return format.Encode(conf.outputFormat, format.Origin{ServerId: cliServerId, NodeId: source.NodeId, LogName: source.LogName}, logLine)
+		})
+	}
+	defer func() {
+		if closeErr := merger.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	output := merger.SourceOutput()
+	if conf.filter != nil {
+		output = output.Wrap(func(_ livelog.Source, sourceOutput io.Writer) io.WriteCloser {
+			return filter.NewWriter(sourceOutput, conf.filter)
+		})
+	}
+	if conf.isStreaming {
+		return client.TailLog(ctx, output)
+	}
+	return client.CatLog(ctx, output)
+}
+
+// Returns the checkpoint store of the resume flag, kept under the JFrog CLI home directory.
+func newCheckpointStore() (*checkpoint.Store, error) {
+	homeDir, err := coreutils.GetJfrogHomeDir()
+	if err != nil {
+		return nil, err
 	}
-	return client.CatLog(ctx, os.Stdout)
+	return checkpoint.NewStore(filepath.Join(homeDir, "forest", "checkpoints")), nil
 }
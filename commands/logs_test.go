@@ -1,11 +1,19 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/fakeserver"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
 	"github.com/jfrog/jfrog-cli-core/plugins/components"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidateArgument(t *testing.T) {
@@ -74,6 +82,200 @@ func TestValidateArgument(t *testing.T) {
 	}
 }
 
+func TestParseNodeIds(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodeIdsArg  string
+		wantNodeIds []string
+	}{
+		{
+			name:        "single node id",
+			nodeIdsArg:  "a",
+			wantNodeIds: []string{"a"},
+		},
+		{
+			name:        "all node ids",
+			nodeIdsArg:  "all",
+			wantNodeIds: nil,
+		},
+		{
+			name:        "comma-separated node ids",
+			nodeIdsArg:  "a, b,,a",
+			wantNodeIds: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantNodeIds, parseNodeIds(tt.nodeIdsArg))
+		})
+	}
+}
+
+// Starts a fake remote service of two nodes, returning its http strategy and a function that stops it.
+func startFakeServer(t *testing.T, logs map[string]string) (*fakeserver.Server, strategy.Http, func()) {
+	logsDir, err := ioutil.TempDir("", "forest-commands-")
+	require.NoError(t, err)
+	server := fakeserver.New(fakeserver.Config{LogsDir: logsDir, RefreshRateMillis: 100})
+	for nodeIdAndLogName, content := range logs {
+		parts := strings.SplitN(nodeIdAndLogName, "/", 2)
+		require.NoError(t, server.AppendLog(parts[0], parts[1], content))
+	}
+	httpServer := httptest.NewServer(server)
+	httpStrategy, err := strategy.NewStandaloneHttpStrategy(strategy.StandaloneHttpConfig{BaseUrl: httpServer.URL + "/artifactory/"})
+	require.NoError(t, err)
+	return server, httpStrategy, func() {
+		httpServer.Close()
+		os.RemoveAll(logsDir)
+	}
+}
+
+func TestValidateNodesAndLogs(t *testing.T) {
+	_, httpStrategy, stopServer := startFakeServer(t, map[string]string{
+		"node-1/console.log":        "one\n",
+		"node-1/access-service.log": "access\n",
+		"node-2/console.log":        "two\n",
+		"node-2/router-service.log": "router\n",
+		"node-2/access-service.log": "access\n",
+	})
+	defer stopServer()
+	tests := []struct {
+		name             string
+		nodeIdsArg       string
+		logNames         []string
+		wantNodeIds      []string
+		wantErrMsgPrefix string
+	}{
+		{name: "every node", nodeIdsArg: "all", logNames: []string{"console.log"}},
+		{name: "selected nodes", nodeIdsArg: "node-2,node-1", logNames: []string{"console.log", "access-service.log"}, wantNodeIds: []string{"node-2", "node-1"}},
+		{name: "unknown node", nodeIdsArg: "node-1,node-3", logNames: []string{"console.log"}, wantErrMsgPrefix: "node id not found [node-3]"},
+		{name: "unknown log", nodeIdsArg: "node-1", logNames: []string{"console.log", "missing.log"}, wantErrMsgPrefix: "log name not found [missing.log]"},
+		{name: "log of another node", nodeIdsArg: "node-1", logNames: []string{"router-service.log"}, wantErrMsgPrefix: "log name not found [router-service.log], consider"},
+		{name: "log of some selected nodes", nodeIdsArg: "node-1,node-2", logNames: []string{"router-service.log"}, wantErrMsgPrefix: "log name not found [router-service.log] on the node ids [node-1]"},
+		{name: "log of some nodes of all", nodeIdsArg: "all", logNames: []string{"console.log", "router-service.log"}, wantErrMsgPrefix: "log name not found [router-service.log] on the node ids [node-1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := validateNodesAndLogs(context.Background(), httpStrategy, tt.nodeIdsArg, tt.logNames)
+			if tt.wantErrMsgPrefix != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasPrefix(err.Error(), tt.wantErrMsgPrefix), "error %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNodeIds, selection.nodeIds)
+			assert.Equal(t, []string{"node-1", "node-2"}, selection.availableNodeIds)
+			assert.Equal(t, 100*time.Millisecond, selection.logsRefreshRate)
+		})
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputFormat string
+		want         string
+		wantErr      bool
+	}{
+		{name: "default", outputFormat: "", want: "text"},
+		{name: "text", outputFormat: "text", want: "text"},
+		{name: "csv", outputFormat: "csv", want: "csv"},
+		{name: "unknown", outputFormat: "yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOutputFormat(tt.outputFormat, "text", "json", "csv")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePositiveInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "positive", value: "100", want: 100},
+		{name: "zero", value: "0", wantErr: true},
+		{name: "negative", value: "-1", wantErr: true},
+		{name: "not a number", value: "ten", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePositiveInt("lines", tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2020, 12, 6, 19, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "duration", value: "30m", want: now.Add(-30 * time.Minute)},
+		{name: "timestamp", value: "2020-12-06T10:00:00Z", want: time.Date(2020, 12, 6, 10, 0, 0, 0, time.UTC)},
+		{name: "timestamp with offset", value: "2020-12-06T12:00:00.500+02:00", want: time.Date(2020, 12, 6, 10, 0, 0, 500000000, time.UTC)},
+		{name: "negative duration", value: "-30m", wantErr: true},
+		{name: "trailing text", value: "2020-12-06T10:00:00Z later", wantErr: true},
+		{name: "not a time", value: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTime("since", tt.value, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %v", got)
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "bytes", value: "4096", want: 4096},
+		{name: "kilobytes", value: "16K", want: 16 << 10},
+		{name: "megabytes", value: "64M", want: 64 << 20},
+		{name: "lower case gigabytes", value: "2g", want: 2 << 30},
+		{name: "zero", value: "0M", wantErr: true},
+		{name: "unknown suffix", value: "64MB", wantErr: true},
+		{name: "suffix only", value: "M", wantErr: true},
+		{name: "overflow", value: "9999999999999G", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseByteSize("tui-buffer", tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestLogCmdArguments(t *testing.T) {
 	tests := []struct {
 		name             string
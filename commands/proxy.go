@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/proxy"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultProxyListenAddress = "localhost:8091"
+	// Every request of the proxy is a GET request without a body, so its headers and body are read within a short time.
+	proxyReadHeaderTimeout = 10 * time.Second
+	proxyReadTimeout       = 30 * time.Second
+	// Long enough for the response of a single read of a large log, which a client tailing the log polls for again and again,
+	// rather than for a response streamed for as long as it tails.
+	proxyWriteTimeout = 5 * time.Minute
+	proxyIdleTimeout  = 2 * time.Minute
+)
+
+func GetProxyCommand() components.Command {
+	return components.Command{
+		Name:        "proxy",
+		Description: "Serve the logs of every configured server, read with its admin credentials, to the users a policy file allows",
+		Flags:       getProxyFlags(),
+		Action:      proxyCmd,
+	}
+}
+
+func getProxyFlags() []components.Flag {
+	return []components.Flag{
+		components.StringFlag{
+			Name:        "policy",
+			Description: "The JSON policy file, of how users are authenticated, and which users or groups may read which logs on which servers",
+			Mandatory:   true,
+		},
+		components.StringFlag{
+			Name:         "listen",
+			Description:  "The address to listen on",
+			DefaultValue: defaultProxyListenAddress,
+		},
+		components.StringFlag{
+			Name:        "audit-log",
+			Description: "The file every access is appended to, as a line of JSON, defaulting to the standard output",
+		},
+		components.StringFlag{
+			Name:        "tls-cert",
+			Description: "A PEM encoded certificate to serve HTTPS with, along with tls-key",
+		},
+		components.StringFlag{
+			Name:        "tls-key",
+			Description: "The PEM encoded private key of tls-cert",
+		},
+	}
+}
+
+func proxyCmd(c *components.Context) (err error) {
+	if len(c.Arguments) != 0 {
+		return fmt.Errorf("wrong number of arguments. Expected: 0, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	}
+	policyPath := c.GetStringFlagValue("policy")
+	if policyPath == "" {
+		return fmt.Errorf("the policy flag is mandatory")
+	}
+	tlsCert, tlsKey := c.GetStringFlagValue("tls-cert"), c.GetStringFlagValue("tls-key")
+	if (tlsCert == "") != (tlsKey == "") {
+		return fmt.Errorf("the tls-cert and tls-key flags must be set together")
+	}
+	listenAddress := c.GetStringFlagValue("listen")
+	if listenAddress == "" {
+		listenAddress = defaultProxyListenAddress
+	}
+	policy, err := proxy.LoadPolicy(policyPath)
+	if err != nil {
+		return err
+	}
+	var auditOutput io.Writer = os.Stdout
+	if auditLogPath := c.GetStringFlagValue("audit-log"); auditLogPath != "" {
+		auditFile, err := os.OpenFile(auditLogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := auditFile.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		auditOutput = auditFile
+	}
+
+	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
+	defer mainCtxCancel()
+	listenForTermination(mainCtxCancel)
+
+	logsProxy := proxy.NewProxy(policy, cliServers{})
+	logsProxy.SetAuditOutput(auditOutput)
+	defer logsProxy.Close()
+	httpServer := &http.Server{
+		Addr:              listenAddress,
+		Handler:           logsProxy,
+		ReadHeaderTimeout: proxyReadHeaderTimeout,
+		ReadTimeout:       proxyReadTimeout,
+		WriteTimeout:      proxyWriteTimeout,
+		IdleTimeout:       proxyIdleTimeout,
+	}
+	go func() {
+		<-mainCtx.Done()
+		_ = httpServer.Close()
+	}()
+	scheme := "https"
+	if tlsCert == "" {
+		scheme = "http"
+		if !isLoopbackAddress(listenAddress) {
+			fmt.Fprintf(os.Stderr, "- Listening on %s without TLS: tokens and logs are sent in plain text\n", listenAddress)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "- Serving the logs of every server under %s://%s/<server_id>/\n", scheme, listenAddress)
+	if tlsCert != "" {
+		err = httpServer.ListenAndServeTLS(tlsCert, tlsKey)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/web"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+const defaultServeListenAddress = "localhost:8080"
+
+func GetServeCommand() components.Command {
+	return components.Command{
+		Name:        "serve",
+		Description: "Run a local web UI, which streams the logs of every configured server to the browser",
+		Flags:       getServeFlags(),
+		Action:      serveCmd,
+	}
+}
+
+func getServeFlags() []components.Flag {
+	return []components.Flag{
+		components.StringFlag{
+			Name:         "listen",
+			Description:  "The address to listen on. Anyone who can reach it can read the logs of every configured server",
+			DefaultValue: defaultServeListenAddress,
+		},
+	}
+}
+
+// The JFrog CLI servers, as configured by 'jfrog rt config'.
+type cliServers struct{}
+
+func (cliServers) ServerIds() ([]string, error) {
+	return fetchAllServerIds()
+}
+
+func (cliServers) HttpStrategy(serverId string) (strategy.Http, error) {
+	serviceManager, err := newArtifactoryServiceManager(serverId)
+	if err != nil {
+		return nil, err
+	}
+	return strategy.NewArtifactoryHttpStrategy(serviceManager), nil
+}
+
+func serveCmd(c *components.Context) error {
+	if len(c.Arguments) != 0 {
+		return fmt.Errorf("wrong number of arguments. Expected: 0, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	}
+	listenAddress := c.GetStringFlagValue("listen")
+	if listenAddress == "" {
+		listenAddress = defaultServeListenAddress
+	}
+
+	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
+	defer mainCtxCancel()
+	listenForTermination(mainCtxCancel)
+
+	server := web.NewServer(cliServers{})
+	server.SetNoticeOutput(os.Stderr)
+	server.SetListenAddress(listenAddress)
+	defer server.Close()
+	if !isLoopbackAddress(listenAddress) {
+		fmt.Fprintf(os.Stderr, "- Listening on %s, which is not a loopback address: anyone who can reach it can read every log\n", listenAddress)
+	}
+	httpServer := &http.Server{Addr: listenAddress, Handler: server}
+	go func() {
+		<-mainCtx.Done()
+		_ = httpServer.Close()
+	}()
+	fmt.Printf("- Serving the web UI on http://%s/\n", listenAddress)
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Returns whether the passed listen address only accepts connections from this machine.
+func isLoopbackAddress(listenAddress string) bool {
+	host, _, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
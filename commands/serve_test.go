@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIsLoopbackAddress(t *testing.T) {
+	assert.True(t, isLoopbackAddress("localhost:8080"))
+	assert.True(t, isLoopbackAddress("127.0.0.1:8080"))
+	assert.True(t, isLoopbackAddress("[::1]:8080"))
+	assert.False(t, isLoopbackAddress(":8080"))
+	assert.False(t, isLoopbackAddress("0.0.0.0:8080"))
+	assert.False(t, isLoopbackAddress("10.0.0.1:8080"))
+	assert.False(t, isLoopbackAddress("8080"))
+}
@@ -0,0 +1,328 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/drain"
+	"github.com/hanoch-jfrog/forest/stats"
+	"github.com/hanoch-jfrog/forest/util"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	requestsReport        = "requests"
+	templatesReport       = "templates"
+	defaultRequestLogName = "artifactory-request.log"
+	defaultStatsTop       = 10
+	// Clears the terminal before the table of the live mode is written again.
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+var defaultTemplatesLogNames = []string{"artifactory-service.log", "router-service.log"}
+
+type statsConfiguration struct {
+	report       string
+	isStreaming  bool
+	logNames     []string
+	top          int
+	timeRange    livelog.TimeRange
+	outputFormat string
+	// the options of the templates report
+	window        time.Duration
+	templatesPath string
+	savePath      string
+}
+
+func GetStatsCommand() components.Command {
+	return components.Command{
+		Name:        "stats",
+		Description: "Report statistics of the logs of every selected node",
+		Aliases:     []string{"s"},
+		Arguments:   getStatsArguments(),
+		Flags:       getStatsFlags(),
+		Action:      statsCmd,
+	}
+}
+
+func getStatsArguments() []components.Argument {
+	return []components.Argument{
+		{Name: "report", Description: "The report to make, either 'requests': the latency percentiles per endpoint and repository, the status codes, the top users and addresses, and the bytes served, of the request log, " +
+			"or 'templates': the most frequent message templates of the service logs, learned as the logs are read"},
+		{Name: "server_id", Description: "JFrog CLI Artifactory server id"},
+		{Name: "node_id", Description: "Selected node id, a comma-separated list of node ids, or 'all' for every node"},
+	}
+}
+
+func getStatsFlags() []components.Flag {
+	return []components.Flag{
+		components.BoolFlag{
+			Name:         "f",
+			Description:  "Follow the logs from now on, or from the since time, refreshing the report every polling interval",
+			DefaultValue: false,
+		},
+		components.StringFlag{
+			Name:        "since",
+			Description: "Start from the first entry at or after the given time, either a duration ago, e.g. '30m', or a timestamp, e.g. '2020-12-06T19:00:00Z'",
+		},
+		components.StringFlag{
+			Name:        "until",
+			Description: "Stop after the last entry at or before the given time, either a duration ago, e.g. '10m', or a timestamp. With -f, following stops once this time has passed",
+		},
+		components.StringFlag{
+			Name:        "log",
+			Description: "Selected log name, or a comma-separated list of log names, defaulting to artifactory-request.log for the requests report, and to artifactory-service.log and router-service.log for the templates report",
+		},
+		components.StringFlag{
+			Name:         "top",
+			Description:  "The number of endpoints, repositories, users and addresses, or of templates, to report, starting with the most frequent",
+			DefaultValue: strconv.Itoa(defaultStatsTop),
+		},
+		components.StringFlag{
+			Name:         "output",
+			Description:  "The output format, either text or json. With -f, a json report is written as a single line every polling interval",
+			DefaultValue: "text",
+		},
+		components.StringFlag{
+			Name:        "window",
+			Description: "With the templates report, also count the entries of every template within a window that ends at the latest entry, e.g. '5m', and order the templates by it",
+		},
+		components.StringFlag{
+			Name:        "templates",
+			Description: "With the templates report, a file of known templates saved by a former run, so that entries which match none of them are reported as anomalies",
+		},
+		components.StringFlag{
+			Name:        "save",
+			Description: "With the templates report, the file to save the known and learned templates into once the report ends, to be passed as templates to later runs",
+		},
+	}
+}
+
+func statsCmd(c *components.Context) error {
+	if len(c.Arguments) != 3 {
+		return fmt.Errorf("wrong number of arguments. Expected: 3, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	}
+	err := validateArgument("report", c.Arguments[0],
+		func() ([]string, error) {
+			return []string{requestsReport, templatesReport}, nil
+		})
+	if err != nil {
+		return err
+	}
+	conf, err := parseStatsConfiguration(c)
+	if err != nil {
+		return err
+	}
+
+	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
+	defer mainCtxCancel()
+	if conf.isStreaming && conf.savePath != "" {
+		// the templates are saved once following ends
+		cancelOnTermination(mainCtxCancel)
+	} else {
+		listenForTermination(mainCtxCancel)
+	}
+	return printStats(mainCtx, c.Arguments[1], c.Arguments[2], conf)
+}
+
+func parseStatsConfiguration(c *components.Context) (*statsConfiguration, error) {
+	conf := &statsConfiguration{
+		report:        c.Arguments[0],
+		isStreaming:   c.GetBoolFlagValue("f"),
+		logNames:      parseList(c.GetStringFlagValue("log")),
+		top:           defaultStatsTop,
+		outputFormat:  c.GetStringFlagValue("output"),
+		templatesPath: c.GetStringFlagValue("templates"),
+		savePath:      c.GetStringFlagValue("save"),
+	}
+	if len(conf.logNames) == 0 {
+		if conf.report == templatesReport {
+			conf.logNames = defaultTemplatesLogNames
+		} else {
+			conf.logNames = []string{defaultRequestLogName}
+		}
+	}
+	if top := c.GetStringFlagValue("top"); top != "" {
+		parsed, err := parsePositiveInt("top", top)
+		if err != nil {
+			return nil, err
+		}
+		conf.top = int(parsed)
+	}
+	var err error
+	if conf.outputFormat, err = parseOutputFormat(conf.outputFormat, "text", "json"); err != nil {
+		return nil, err
+	}
+	if window := c.GetStringFlagValue("window"); window != "" {
+		if conf.window, err = time.ParseDuration(window); err != nil || conf.window <= 0 {
+			return nil, fmt.Errorf("invalid window [%v], expected a positive duration, e.g. 5m", window)
+		}
+	}
+	if conf.report != templatesReport && (conf.window > 0 || conf.templatesPath != "" || conf.savePath != "") {
+		return nil, fmt.Errorf("the window, templates and save flags only apply to the templates report")
+	}
+	if conf.timeRange, err = parseTimeRange(c, time.Now()); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func printStats(ctx context.Context, cliServerId, nodeIdsArg string, conf *statsConfiguration) error {
+	err := validateArgument("server id", cliServerId,
+		func() ([]string, error) {
+			return fetchAllServerIds()
+		})
+	if err != nil {
+		return err
+	}
+	serviceManager, err := newArtifactoryServiceManager(cliServerId)
+	if err != nil {
+		return err
+	}
+	httpStrategy := strategy.NewArtifactoryHttpStrategy(serviceManager)
+	selection, err := validateNodesAndLogs(ctx, httpStrategy, nodeIdsArg, conf.logNames)
+	if err != nil {
+		return err
+	}
+
+	var output livelog.SourceOutput
+	var writeReport func() error
+	finishReport := func() error { return nil }
+	if conf.report == requestsReport {
+		report := stats.NewReport()
+		output = report.SourceOutput()
+		writeReport = func() error {
+			if conf.outputFormat == "text" {
+				return stats.WriteText(os.Stdout, report.Summary(conf.top))
+			}
+			return writeJsonLine(os.Stdout, report.Summary(conf.top))
+		}
+	} else {
+		report, err := newTemplatesReport(conf)
+		if err != nil {
+			return err
+		}
+		output = report.SourceOutput()
+		writeReport = func() error {
+			if conf.outputFormat == "text" {
+				return drain.WriteText(os.Stdout, report.Summary(conf.top))
+			}
+			return writeJsonLine(os.Stdout, report.Summary(conf.top))
+		}
+		if conf.savePath != "" {
+			finishReport = func() error {
+				return saveTemplates(report, conf.savePath)
+			}
+		}
+	}
+
+	client := livelog.NewMultiNodeClient(httpStrategy)
+	client.SetNodeIds(selection.nodeIds)
+	client.SetLogFileNames(conf.logNames)
+	client.SetLogsRefreshRate(selection.logsRefreshRate)
+	client.SetRetryPolicy(livelog.DefaultRetryPolicy())
+	client.SetNoticeOutput(os.Stderr)
+	if !conf.isStreaming {
+		client.SetTimeRange(conf.timeRange)
+		if err = client.CatLog(ctx, output); err != nil {
+			return err
+		}
+		if err = writeReport(); err != nil {
+			return err
+		}
+		return finishReport()
+	}
+
+	timeRange := conf.timeRange
+	if timeRange.Since.IsZero() {
+		// the report covers the entries logged while following, rather than the whole log
+		timeRange.Since = time.Now()
+	}
+	client.SetTimeRange(timeRange)
+	clearBetweenReports := conf.outputFormat == "text" && terminal.IsTerminal(int(os.Stdout.Fd()))
+	if err = tailAndReport(ctx, client, output, selection.logsRefreshRate, clearBetweenReports, writeReport); err != nil {
+		return err
+	}
+	return finishReport()
+}
+
+func newTemplatesReport(conf *statsConfiguration) (report *drain.Report, err error) {
+	report = drain.NewReport()
+	report.SetWindow(conf.window)
+	if conf.templatesPath == "" {
+		return report, nil
+	}
+	templatesFile, err := os.Open(conf.templatesPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := templatesFile.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	if err = report.LoadTemplates(templatesFile); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Saves the templates into a temporary file that is renamed over the passed path,
+// so that failing to save them, such as once interrupted again, does not lose the templates saved before.
+func saveTemplates(report *drain.Report, savePath string) error {
+	var content bytes.Buffer
+	if err := report.SaveTemplates(&content); err != nil {
+		return err
+	}
+	if err := util.WriteFileAtomically(savePath, content.Bytes(), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "- Saved the templates to %s\n", savePath)
+	return nil
+}
+
+// Tails the logs of the passed client into the passed output, writing a report every refresh interval,
+// 1 second if unknown, after clearing the screen if clearBetweenReports is set, and a last one once tailing ends.
+func tailAndReport(ctx context.Context, client livelog.MultiNodeClient, output livelog.SourceOutput, refreshRate time.Duration,
+	clearBetweenReports bool, writeReport func() error) error {
+	tailErr := make(chan error, 1)
+	go func() {
+		tailErr <- client.TailLog(ctx, output)
+	}()
+	if refreshRate <= 0 {
+		refreshRate = time.Second
+	}
+	ticker := time.NewTicker(refreshRate)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-tailErr:
+			if err != nil {
+				return err
+			}
+			return writeReport()
+		case <-ticker.C:
+			if clearBetweenReports {
+				fmt.Print(clearScreen)
+			}
+			if err := writeReport(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Writes the passed summary as a single line of JSON, without escaping HTML characters, such as the < and > of placeholders.
+func writeJsonLine(output io.Writer, summary interface{}) error {
+	encoder := json.NewEncoder(output)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(summary)
+}
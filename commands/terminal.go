@@ -21,12 +21,13 @@ func selectLogNameAndFetchRefreshRate(ctx context.Context, client livelog.Client
 	return
 }
 
-func selectNodeId(ctx context.Context, client livelog.Client) (string, error) {
-	nodeIds, err := client.GetServiceNodeIds(ctx)
+func selectNodeId(ctx context.Context, client livelog.Client) (selectedNodeId string, nodeIds []string, err error) {
+	nodeIds, err = client.GetServiceNodeIds(ctx)
 	if err != nil {
-		return "", err
+		return
 	}
-	return runInteractiveMenu("Select node id", "Available nodes", nodeIds)
+	selectedNodeId, err = runInteractiveMenu("Select node id", "Available nodes", append([]string{allNodesValue}, nodeIds...))
+	return
 }
 
 func selectCliServerId() (string, error) {
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/format"
+	"github.com/hanoch-jfrog/forest/trace"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"os"
+	"strconv"
+)
+
+const defaultTraceParallel = 4
+
+func GetTraceCommand() components.Command {
+	return components.Command{
+		Name:        "trace",
+		Description: "Show the entries of a trace id in every log of every node",
+		Aliases:     []string{"t"},
+		Arguments:   getTraceArguments(),
+		Flags:       getTraceFlags(),
+		Action:      traceCmd,
+	}
+}
+
+func getTraceArguments() []components.Argument {
+	return []components.Argument{
+		{Name: "server_id", Description: "JFrog CLI Artifactory server id"},
+		{Name: "trace_id", Description: "The trace id to look for"},
+	}
+}
+
+func getTraceFlags() []components.Flag {
+	return []components.Flag{
+		components.StringFlag{
+			Name:         "output",
+			Description:  "The output format, one of text, json, ndjson, logfmt or csv. Text groups the entries by service and node, while the other formats write them in time order",
+			DefaultValue: string(format.Text),
+		},
+		components.StringFlag{
+			Name:         "parallel",
+			Description:  "The maximal number of logs read at a time",
+			DefaultValue: strconv.Itoa(defaultTraceParallel),
+		},
+	}
+}
+
+func traceCmd(c *components.Context) error {
+	if len(c.Arguments) != 2 {
+		return fmt.Errorf("wrong number of arguments. Expected: 2, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	}
+	outputFormat, err := format.ParseFormat(c.GetStringFlagValue("output"))
+	if err != nil {
+		return err
+	}
+	parallel := int64(defaultTraceParallel)
+	if parallelArg := c.GetStringFlagValue("parallel"); parallelArg != "" {
+		if parallel, err = parsePositiveInt("parallel", parallelArg); err != nil {
+			return err
+		}
+	}
+
+	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
+	defer mainCtxCancel()
+	listenForTermination(mainCtxCancel)
+	return printTrace(mainCtx, c.Arguments[0], c.Arguments[1], outputFormat, int(parallel))
+}
+
+func printTrace(ctx context.Context, cliServerId, traceId string, outputFormat format.Format, parallel int) error {
+	err := validateArgument("server id", cliServerId,
+		func() ([]string, error) {
+			return fetchAllServerIds()
+		})
+	if err != nil {
+		return err
+	}
+	serviceManager, err := newArtifactoryServiceManager(cliServerId)
+	if err != nil {
+		return err
+	}
+
+	client := livelog.NewMultiNodeClient(strategy.NewArtifactoryHttpStrategy(serviceManager))
+	client.SetNoticeOutput(os.Stderr)
+	client.SetMaxConcurrency(parallel)
+	// the entries found in the other logs are still shown
+	client.SetSkipFailedSources(true)
+	collector := trace.NewCollector(traceId)
+	if err = client.CatLog(ctx, collector.SourceOutput()); err != nil {
+		return err
+	}
+	entries := collector.Entries()
+	if len(entries) == 0 {
+		return fmt.Errorf("no entries of trace id [%v] were found", traceId)
+	}
+
+	if outputFormat == format.Text {
+		return trace.WriteGroups(os.Stdout, trace.GroupEntries(entries))
+	}
+	if _, err = os.Stdout.Write(format.Header(outputFormat)); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		origin := format.Origin{ServerId: cliServerId, NodeId: entry.Source.NodeId, LogName: entry.Source.LogName}
+		if _, err = os.Stdout.Write(format.Encode(outputFormat, origin, entry.LogLine)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/format"
+	"github.com/hanoch-jfrog/forest/tui"
+	"github.com/hanoch-jfrog/forest/util"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const defaultTuiBufferSize = "64M"
+
+var byteSizeSuffixes = map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30}
+
+// Follows the log of every passed pane spec, "<node_id> <log_name>", side by side in the full-screen viewer,
+// until the user quits or the passed context is cancelled.
+func runTui(ctx context.Context, httpStrategy strategy.Http, cliServerId string, specs []string, conf *logsConfiguration) (err error) {
+	terminal, err := tui.OpenTerminal()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := terminal.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	app := tui.NewApp(func(ctx context.Context, spec string) (*tui.Session, livelog.Client, error) {
+		return openTuiPane(ctx, httpStrategy, cliServerId, spec, conf)
+	})
+	app.SetSyncScrolling(conf.tuiSync)
+	return app.Run(ctx, terminal, specs)
+}
+
+// Returns the pane spec of every pair of the passed node ids and log names.
+func tuiPaneSpecs(nodeIds, logNames []string) []string {
+	var specs []string
+	for _, nodeId := range nodeIds {
+		for _, logName := range logNames {
+			specs = append(specs, nodeId+" "+logName)
+		}
+	}
+	return specs
+}
+
+// Validates the node id and log name of the passed pane spec, and returns the Session and Client that follow its log.
+func openTuiPane(ctx context.Context, httpStrategy strategy.Http, cliServerId, spec string, conf *logsConfiguration) (*tui.Session, livelog.Client, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 2 {
+		return nil, nil, fmt.Errorf("invalid pane [%v], expected a node id and a log name separated by a space", spec)
+	}
+	origin := format.Origin{ServerId: cliServerId, NodeId: fields[0], LogName: fields[1]}
+	session := tui.NewSession(strings.Join([]string{origin.ServerId, origin.NodeId, origin.LogName}, " "), tui.NewBuffer(conf.tuiBufferSize))
+	if conf.filter != nil {
+		session.SetFilter(conf.filter)
+	}
+	client := livelog.NewClient(session.TrackPolls(httpStrategy))
+	err := validateArgument("node id", origin.NodeId,
+		func() ([]string, error) {
+			return client.GetServiceNodeIds(ctx)
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	client.SetNodeId(origin.NodeId)
+	srvConfig, err := client.GetConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = validateArgument("log name", origin.LogName,
+		func() ([]string, error) {
+			return srvConfig.LogFileNames, nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	client.SetLogFileName(origin.LogName)
+	client.SetLogsRefreshRate(util.MillisToDuration(srvConfig.RefreshRateMillis))
+	if err = configureClient(client, origin, conf); err != nil {
+		return nil, nil, err
+	}
+	return session, client, nil
+}
+
+// Cancels the passed context on termination signals, without exiting, so that the terminal can be restored.
+func cancelOnTermination(cancelCtx context.CancelFunc) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGABRT)
+	go func() {
+		<-c
+		cancelCtx()
+	}()
+}
+
+// Parses a positive number of bytes, with an optional K, M or G suffix, such as 64M.
+func parseByteSize(flagName, value string) (int64, error) {
+	number, multiplier := strings.ToUpper(value), int64(1)
+	if len(number) > 0 {
+		if suffixMultiplier, ok := byteSizeSuffixes[number[len(number)-1:]]; ok {
+			number, multiplier = number[:len(number)-1], suffixMultiplier
+		}
+	}
+	parsed, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || parsed <= 0 || parsed > (1<<62)/multiplier {
+		return 0, fmt.Errorf("invalid %v value [%v], expected a positive number of bytes, optionally with a K, M or G suffix", flagName, value)
+	}
+	return parsed * multiplier, nil
+}
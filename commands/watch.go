@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/alert"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"os"
+	"strconv"
+	"time"
+)
+
+type watchConfiguration struct {
+	rulesPath string
+	logNames  []string
+	// The time of the first entries to evaluate, by default when watching starts, so that the entries logged before do not fire the rules.
+	since time.Time
+}
+
+func GetWatchCommand() components.Command {
+	return components.Command{
+		Name:        "watch",
+		Description: "Follow the logs of every selected node, and run the actions of the alert rules that their entries fire",
+		Aliases:     []string{"w"},
+		Arguments:   getWatchArguments(),
+		Flags:       getWatchFlags(),
+		Action:      watchCmd,
+	}
+}
+
+func getWatchArguments() []components.Argument {
+	return []components.Argument{
+		{Name: "server_id", Description: "JFrog CLI Artifactory server id"},
+		{Name: "node_id", Description: "Selected node id, a comma-separated list of node ids, or 'all' for every node"},
+	}
+}
+
+func getWatchFlags() []components.Flag {
+	return []components.Flag{
+		components.StringFlag{
+			Name:        "rules",
+			Description: "Path to a JSON file of the alert rules, with their match conditions, thresholds, windows, cooldowns and actions",
+			Mandatory:   true,
+		},
+		components.StringFlag{
+			Name:        "log",
+			Description: "Selected log name, or a comma-separated list of log names, defaulting to every log",
+		},
+		components.StringFlag{
+			Name:        "since",
+			Description: "Start from the first entry at or after the given time, either a duration ago, e.g. '30m', or a timestamp, e.g. '2020-12-06T19:00:00Z', defaulting to now",
+		},
+	}
+}
+
+func watchCmd(c *components.Context) error {
+	if len(c.Arguments) != 2 {
+		return fmt.Errorf("wrong number of arguments. Expected: 2, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	}
+	conf := &watchConfiguration{
+		rulesPath: c.GetStringFlagValue("rules"),
+		logNames:  parseList(c.GetStringFlagValue("log")),
+		since:     time.Now(),
+	}
+	if since := c.GetStringFlagValue("since"); since != "" {
+		var err error
+		if conf.since, err = parseTime("since", since, conf.since); err != nil {
+			return err
+		}
+	}
+
+	mainCtx, mainCtxCancel := context.WithCancel(context.Background())
+	defer mainCtxCancel()
+	// the running actions are waited for before exiting
+	cancelOnTermination(mainCtxCancel)
+	return watchLogs(mainCtx, c.Arguments[0], c.Arguments[1], conf)
+}
+
+func watchLogs(ctx context.Context, cliServerId, nodeIdsArg string, conf *watchConfiguration) error {
+	rules, err := alert.LoadRules(conf.rulesPath)
+	if err != nil {
+		return err
+	}
+	err = validateArgument("server id", cliServerId,
+		func() ([]string, error) {
+			return fetchAllServerIds()
+		})
+	if err != nil {
+		return err
+	}
+	serviceManager, err := newArtifactoryServiceManager(cliServerId)
+	if err != nil {
+		return err
+	}
+	httpStrategy := strategy.NewArtifactoryHttpStrategy(serviceManager)
+	return watch(ctx, httpStrategy, cliServerId, nodeIdsArg, rules, conf)
+}
+
+// Evaluates the passed rules against the entries of the selected nodes and logs, until the passed context is cancelled.
+func watch(ctx context.Context, httpStrategy strategy.Http, cliServerId, nodeIdsArg string, rules *alert.Rules, conf *watchConfiguration) (err error) {
+	selection, err := validateNodesAndLogs(ctx, httpStrategy, nodeIdsArg, conf.logNames)
+	if err != nil {
+		return err
+	}
+
+	client := livelog.NewMultiNodeClient(httpStrategy)
+	client.SetNodeIds(selection.nodeIds)
+	client.SetLogFileNames(conf.logNames)
+	client.SetLogsRefreshRate(selection.logsRefreshRate)
+	client.SetTimeRange(livelog.TimeRange{Since: conf.since})
+	client.SetRetryPolicy(livelog.DefaultRetryPolicy())
+	client.SetNoticeOutput(os.Stderr)
+	watcher := alert.NewWatcher(rules)
+	watcher.SetServerId(cliServerId)
+	watcher.SetNoticeOutput(os.Stdout)
+	defer func() {
+		if closeErr := watcher.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	fmt.Printf("- Watching with the rules of %s\n", conf.rulesPath)
+	return client.TailLog(ctx, watcher.SourceOutput())
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hanoch-jfrog/forest/alert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatch_startsNow(t *testing.T) {
+	var mu sync.Mutex
+	var alerts []alert.Alert
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		firedAlert := alert.Alert{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&firedAlert))
+		mu.Lock()
+		defer mu.Unlock()
+		alerts = append(alerts, firedAlert)
+	}))
+	defer receiver.Close()
+	firedAlerts := func() []alert.Alert {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]alert.Alert(nil), alerts...)
+	}
+
+	// the existing entries of the log match the rule, but were logged before watching starts
+	server, httpStrategy, stopServer := startFakeServer(t, map[string]string{
+		"node-1/artifactory-service.log": "2020-12-06T19:21:54.000Z [jfrt ] [ERROR] [                ] [o.a.DbPool:58] [main] - Old pool exhausted\n",
+	})
+	defer stopServer()
+	rules := &alert.Rules{Rules: []alert.Rule{{
+		Name:    "db-pool",
+		Match:   alert.Match{Regex: "pool exhausted"},
+		Actions: []alert.Action{{Type: alert.WebhookAction, Url: receiver.URL}},
+	}}}
+	require.NoError(t, rules.Validate())
+
+	// truncated to the precision of the timestamps of the log, so that the appended entry is not before it
+	since := time.Now().Truncate(time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watch(ctx, httpStrategy, "local-arti", "all", rules, &watchConfiguration{since: since})
+	}()
+	newLine := time.Now().UTC().Format("2006-01-02T15:04:05.000Z") + " [jfrt ] [ERROR] [                ] [o.a.DbPool:58] [main] - New pool exhausted\n"
+	require.NoError(t, server.AppendLog("node-1", "artifactory-service.log", newLine))
+	require.Eventually(t, func() bool { return len(firedAlerts()) > 0 }, 5*time.Second, 10*time.Millisecond)
+	// polled a few more times, to catch any alert of the existing entries
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	require.NoError(t, <-watchErr)
+
+	fired := firedAlerts()
+	require.Len(t, fired, 1)
+	assert.Equal(t, 1, fired[0].Count)
+	assert.Contains(t, fired[0].Sample, "New pool exhausted")
+}
@@ -0,0 +1,236 @@
+package drain
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// The placeholder of a variable token of a template.
+	Wildcard = "<*>"
+
+	DefaultDepth       = 4
+	DefaultSimilarity  = 0.4
+	DefaultMaxChildren = 100
+)
+
+// A message template, whose variable tokens are Wildcard.
+type Cluster struct {
+	Id     int
+	Tokens []string
+	// whether the template was added rather than learned, so that it is never generalized
+	frozen bool
+}
+
+func (c *Cluster) Template() string {
+	return strings.Join(c.Tokens, " ")
+}
+
+// Returns whether the passed masked tokens are an instance of the template.
+func (c *Cluster) matches(tokens []string) bool {
+	if len(tokens) != len(c.Tokens) {
+		return false
+	}
+	for idx, token := range c.Tokens {
+		if token != Wildcard && token != tokens[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the share of the tokens of the template that are equal to the passed masked tokens, along with its number of wildcards.
+func (c *Cluster) similarity(tokens []string) (float64, int) {
+	if len(tokens) == 0 {
+		return 1, 0
+	}
+	equal, wildcards := 0, 0
+	for idx, token := range c.Tokens {
+		if token == Wildcard {
+			wildcards++
+		} else if token == tokens[idx] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(tokens)), wildcards
+}
+
+// A node of the parse tree, whose children are keyed by the number of tokens in the first layer,
+// and by a leading token in each of the following layers, up to the leaves, which hold the clusters.
+type node struct {
+	children map[string]*node
+	clusters []*Cluster
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+// Learns message templates online, with the Drain algorithm: a message is routed through a fixed depth parse tree
+// by its number of tokens and its leading tokens, to the clusters of a leaf, and either joins the most similar one,
+// replacing the tokens that differ with Wildcard, or starts a new cluster of its own.
+// It is not safe for concurrent use.
+type Miner struct {
+	depth       int
+	similarity  float64
+	maxChildren int
+	root        *node
+	clusters    []*Cluster
+}
+
+func NewMiner() *Miner {
+	return &Miner{
+		depth:       DefaultDepth,
+		similarity:  DefaultSimilarity,
+		maxChildren: DefaultMaxChildren,
+		root:        newNode(),
+	}
+}
+
+// Sets the depth of the parse tree, which routes messages by depth-2 leading tokens. The depth is at least 3.
+func (m *Miner) SetDepth(depth int) {
+	if depth < 3 {
+		depth = 3
+	}
+	m.depth = depth
+}
+
+// Sets the share of equal tokens, between 0 and 1, that a message must have with a template to join its cluster.
+func (m *Miner) SetSimilarity(similarity float64) {
+	m.similarity = similarity
+}
+
+// Sets the number of distinct tokens a node of the parse tree routes by, beyond which tokens are routed as Wildcard.
+func (m *Miner) SetMaxChildren(maxChildren int) {
+	m.maxChildren = maxChildren
+}
+
+// Returns the clusters in the order they were made.
+func (m *Miner) Clusters() []*Cluster {
+	return m.clusters
+}
+
+// Adds the passed message to the cluster it is most similar to, or to a new cluster,
+// and returns that cluster along with whether it is new.
+func (m *Miner) Learn(message string) (*Cluster, bool) {
+	tokens := Tokenize(message)
+	if cluster := m.match(tokens); cluster != nil {
+		return cluster, false
+	}
+	leaf := m.leaf(tokens)
+	var best *Cluster
+	bestSimilarity, bestWildcards := -1.0, -1
+	for _, cluster := range leaf.clusters {
+		if cluster.frozen {
+			continue
+		}
+		similarity, wildcards := cluster.similarity(tokens)
+		if similarity > bestSimilarity || similarity == bestSimilarity && wildcards > bestWildcards {
+			best, bestSimilarity, bestWildcards = cluster, similarity, wildcards
+		}
+	}
+	if best != nil && bestSimilarity >= m.similarity {
+		for idx, token := range best.Tokens {
+			if token != tokens[idx] {
+				best.Tokens[idx] = Wildcard
+			}
+		}
+		return best, false
+	}
+	return m.add(0, tokens, leaf), true
+}
+
+// Returns the cluster whose template the passed message is an instance of, or nil if there is none.
+func (m *Miner) Match(message string) *Cluster {
+	return m.match(Tokenize(message))
+}
+
+// Adds a cluster of the passed template, such as a template learned by a former run, keeping its id if it is positive.
+// An added template is never generalized, so messages that are not instances of it start clusters of their own.
+func (m *Miner) AddTemplate(id int, template string) *Cluster {
+	tokens := strings.Fields(template)
+	cluster := m.add(id, tokens, m.leaf(tokens))
+	cluster.frozen = true
+	return cluster
+}
+
+func (m *Miner) add(id int, tokens []string, leaf *node) *Cluster {
+	if id <= 0 {
+		id = 1
+		for _, cluster := range m.clusters {
+			if cluster.Id >= id {
+				id = cluster.Id + 1
+			}
+		}
+	}
+	cluster := &Cluster{Id: id, Tokens: append([]string(nil), tokens...)}
+	leaf.clusters = append(leaf.clusters, cluster)
+	m.clusters = append(m.clusters, cluster)
+	return cluster
+}
+
+// Returns the leaf that the passed tokens are routed to, adding the nodes on the way as needed.
+func (m *Miner) leaf(tokens []string) *node {
+	current := m.child(m.root, strconv.Itoa(len(tokens)), false)
+	for idx := 0; idx < len(tokens) && idx < m.depth-2; idx++ {
+		current = m.child(current, tokens[idx], true)
+	}
+	return current
+}
+
+func (m *Miner) child(parent *node, key string, limited bool) *node {
+	if child, ok := parent.children[key]; ok {
+		return child
+	}
+	if limited && len(parent.children) >= m.maxChildren {
+		key = Wildcard
+		if child, ok := parent.children[key]; ok {
+			return child
+		}
+	}
+	child := newNode()
+	parent.children[key] = child
+	return child
+}
+
+// Returns the cluster whose template the passed tokens are an instance of, searching both the exact and the Wildcard
+// routes, as templates are routed by their own tokens, which may be more general than those of the messages they match.
+func (m *Miner) match(tokens []string) *Cluster {
+	lengthNode, ok := m.root.children[strconv.Itoa(len(tokens))]
+	if !ok {
+		return nil
+	}
+	return m.matchFrom(lengthNode, tokens, 0)
+}
+
+func (m *Miner) matchFrom(current *node, tokens []string, idx int) *Cluster {
+	if idx >= len(tokens) || idx >= m.depth-2 {
+		for _, cluster := range current.clusters {
+			if cluster.matches(tokens) {
+				return cluster
+			}
+		}
+		return nil
+	}
+	if child, ok := current.children[tokens[idx]]; ok {
+		if cluster := m.matchFrom(child, tokens, idx+1); cluster != nil {
+			return cluster
+		}
+	}
+	if child, ok := current.children[Wildcard]; ok && tokens[idx] != Wildcard {
+		return m.matchFrom(child, tokens, idx+1)
+	}
+	return nil
+}
+
+// Splits a message into its tokens, separated by white space, where tokens with a digit, such as ids, numbers,
+// addresses and versions, are masked as Wildcard, as they are most likely variable.
+func Tokenize(message string) []string {
+	tokens := strings.Fields(message)
+	for idx, token := range tokens {
+		if strings.IndexAny(token, "0123456789") >= 0 {
+			tokens[idx] = Wildcard
+		}
+	}
+	return tokens
+}
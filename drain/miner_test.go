@@ -0,0 +1,79 @@
+package drain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"Cluster", "join:", "joined", Wildcard, "with", "id", Wildcard},
+		Tokenize("Cluster join:  joined art1 with id 0b1c4a2e-12ab-4cde-9f00-1234567890ab"))
+	assert.Empty(t, Tokenize(" "))
+}
+
+func TestMiner_Learn(t *testing.T) {
+	miner := NewMiner()
+	joined, isNew := miner.Learn("Cluster join: Successfully joined art1 with node id 4f2a")
+	require.True(t, isNew)
+	assert.Equal(t, "Cluster join: Successfully joined <*> with node id <*>", joined.Template())
+
+	// the node name differs, and has no digits, so the template is generalized
+	cluster, isNew := miner.Learn("Cluster join: Successfully joined primary with node id 9c0d")
+	assert.False(t, isNew)
+	assert.Same(t, joined, cluster)
+	assert.Equal(t, "Cluster join: Successfully joined <*> with node id <*>", joined.Template())
+
+	// of the same length and leading tokens, but too different to join
+	other, isNew := miner.Learn("Cluster join: Failed because of missing license key for node")
+	assert.True(t, isNew)
+	assert.NotEqual(t, joined.Id, other.Id)
+
+	// of another length
+	short, isNew := miner.Learn("Cluster join: Successfully joined")
+	assert.True(t, isNew)
+	assert.Len(t, miner.Clusters(), 3)
+
+	assert.Same(t, joined, miner.Match("Cluster join: Successfully joined art2 with node id 77"))
+	assert.Same(t, short, miner.Match("Cluster join: Successfully joined"))
+	assert.Nil(t, miner.Match("Cluster join: Successfully left art2 with node id 77"))
+}
+
+func TestMiner_similarity(t *testing.T) {
+	miner := NewMiner()
+	miner.SetSimilarity(0.9)
+	first, _ := miner.Learn("Storage quota is low on disk data")
+	second, isNew := miner.Learn("Storage quota is low on disk logs")
+	assert.True(t, isNew)
+	assert.NotEqual(t, first.Id, second.Id)
+}
+
+func TestMiner_maxChildren(t *testing.T) {
+	miner := NewMiner()
+	miner.SetMaxChildren(2)
+	miner.Learn("alpha started")
+	miner.Learn("beta started")
+	// routed as a wildcard, as the first layer is full
+	gamma, _ := miner.Learn("gamma started")
+	assert.Same(t, gamma, miner.Match("gamma started"))
+	delta, isNew := miner.Learn("delta started")
+	assert.False(t, isNew)
+	assert.Same(t, gamma, delta)
+	assert.Equal(t, "<*> started", gamma.Template())
+}
+
+func TestMiner_AddTemplate(t *testing.T) {
+	miner := NewMiner()
+	known := miner.AddTemplate(7, "<*> joined <*> with node id <*>")
+	assert.Equal(t, 7, known.Id)
+	// routed by its wildcard, yet matched by a message with a leading token
+	cluster, isNew := miner.Learn("Cluster joined art1 with node id 12")
+	assert.False(t, isNew)
+	assert.Same(t, known, cluster)
+
+	// a similar message that is not an instance of the added template does not generalize it
+	cluster, isNew = miner.Learn("Cluster left art1 with node id 12")
+	assert.True(t, isNew)
+	assert.Equal(t, 8, cluster.Id)
+	assert.Equal(t, "<*> joined <*> with node id <*>", known.Template())
+}
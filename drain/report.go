@@ -0,0 +1,298 @@
+package drain
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/parser"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The version of the templates file, which is incremented on incompatible changes.
+const templatesFileVersion = 1
+
+// Learns the templates of the messages of the logs of many sources, and counts the entries of every template,
+// both in total and within a window that ends at the latest entry. It is safe for concurrent use.
+type Report struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	miner   *Miner
+	entries int64
+	from    time.Time
+	to      time.Time
+	// the number of entries that matched no known template
+	anomalies int64
+	// the number of templates that were loaded, which are the known templates
+	known int
+	stats map[*Cluster]*clusterStats
+}
+
+type clusterStats struct {
+	count      int64
+	savedCount int64
+	known      bool
+	firstSeen  time.Time
+	lastSeen   time.Time
+	// the message and node of the first entry
+	sample       string
+	sampleNodeId string
+	// the entries of every second within the window, in time order
+	seconds []secondCount
+}
+
+type secondCount struct {
+	second int64
+	count  int64
+}
+
+func NewReport() *Report {
+	return &Report{
+		miner: NewMiner(),
+		stats: make(map[*Cluster]*clusterStats),
+	}
+}
+
+// Returns the Miner of the Report, to configure it before any entry is added.
+func (r *Report) Miner() *Miner {
+	return r.miner
+}
+
+// Sets the window that the entries of every template are also counted within, ending at the latest entry.
+// No window is used by default.
+func (r *Report) SetWindow(window time.Duration) {
+	r.window = window
+}
+
+// Adds the templates saved by SaveTemplates as known templates, so that entries which match none of them are anomalies.
+func (r *Report) LoadTemplates(input io.Reader) error {
+	var file templatesFile
+	if err := json.NewDecoder(input).Decode(&file); err != nil {
+		return fmt.Errorf("invalid templates file: %w", err)
+	}
+	if file.Version != templatesFileVersion {
+		return fmt.Errorf("unsupported templates file version [%v], expected [%v]", file.Version, templatesFileVersion)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, template := range file.Templates {
+		cluster := r.miner.AddTemplate(template.Id, template.Template)
+		r.stats[cluster] = &clusterStats{known: true, savedCount: template.Count}
+		r.known++
+	}
+	return nil
+}
+
+// Saves every template, both the known ones and those learned since, along with their total counts.
+func (r *Report) SaveTemplates(output io.Writer) error {
+	r.mu.Lock()
+	file := templatesFile{Version: templatesFileVersion, Templates: []savedTemplate{}}
+	for _, cluster := range r.miner.Clusters() {
+		stats := r.stats[cluster]
+		file.Templates = append(file.Templates, savedTemplate{Id: cluster.Id, Template: cluster.Template(), Count: stats.savedCount + stats.count})
+	}
+	r.mu.Unlock()
+	sort.Slice(file.Templates, func(i, j int) bool { return file.Templates[i].Id < file.Templates[j].Id })
+	encoder := json.NewEncoder(output)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(file)
+}
+
+type templatesFile struct {
+	Version   int             `json:"version"`
+	Templates []savedTemplate `json:"templates"`
+}
+
+type savedTemplate struct {
+	Id       int    `json:"id"`
+	Template string `json:"template"`
+	Count    int64  `json:"count"`
+}
+
+// Adds the first line of the message of the passed entry of the passed node.
+// Continuation entries are not added, as they cannot be told apart from the entries of other formats.
+func (r *Report) Add(nodeId string, logLine *parser.LogLine) {
+	if logLine.IsContinuation() {
+		return
+	}
+	message := logLine.Message
+	if lineEnd := strings.IndexByte(message, '\n'); lineEnd >= 0 {
+		message = message[:lineEnd]
+	}
+	message = strings.TrimSuffix(message, "\r")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cluster, _ := r.miner.Learn(message)
+	r.entries++
+	if r.from.IsZero() || logLine.Timestamp.Before(r.from) {
+		r.from = logLine.Timestamp
+	}
+	if logLine.Timestamp.After(r.to) {
+		r.to = logLine.Timestamp
+	}
+	stats := r.stats[cluster]
+	if stats == nil {
+		stats = &clusterStats{}
+		r.stats[cluster] = stats
+	}
+	if r.known > 0 && !stats.known {
+		r.anomalies++
+	}
+	stats.count++
+	if stats.firstSeen.IsZero() || logLine.Timestamp.Before(stats.firstSeen) {
+		stats.firstSeen = logLine.Timestamp
+		stats.sample, stats.sampleNodeId = message, nodeId
+	}
+	if logLine.Timestamp.After(stats.lastSeen) {
+		stats.lastSeen = logLine.Timestamp
+	}
+	if r.window > 0 {
+		stats.addSecond(logLine.Timestamp.Unix(), r.to.Add(-r.window).Unix())
+	}
+}
+
+// Counts an entry of the passed second, and drops the seconds at or before the passed one, which are out of the window.
+func (s *clusterStats) addSecond(second, windowStart int64) {
+	idx := len(s.seconds)
+	for idx > 0 && s.seconds[idx-1].second > second {
+		idx--
+	}
+	if idx > 0 && s.seconds[idx-1].second == second {
+		s.seconds[idx-1].count++
+	} else {
+		s.seconds = append(s.seconds, secondCount{})
+		copy(s.seconds[idx+1:], s.seconds[idx:])
+		s.seconds[idx] = secondCount{second: second, count: 1}
+	}
+	dropped := 0
+	for dropped < len(s.seconds) && s.seconds[dropped].second <= windowStart {
+		dropped++
+	}
+	s.seconds = s.seconds[dropped:]
+}
+
+func (s *clusterStats) windowCount(windowStart int64) int64 {
+	var count int64
+	for _, second := range s.seconds {
+		if second.second > windowStart {
+			count += second.count
+		}
+	}
+	return count
+}
+
+// Returns a SourceOutput whose io.Writers add every entry written into them to the Report.
+func (r *Report) SourceOutput() livelog.SourceOutput {
+	return func(source livelog.Source) io.Writer {
+		return parser.NewEntryWriter(func(logLine *parser.LogLine) error {
+			r.Add(source.NodeId, logLine)
+			return nil
+		})
+	}
+}
+
+type TemplateStats struct {
+	Id       int    `json:"id"`
+	Template string `json:"template"`
+	Count    int64  `json:"count"`
+	// The number of entries within the window, if one is set.
+	WindowCount int64     `json:"window_count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	// The message and node of the first entry of the template.
+	Sample       string `json:"sample"`
+	SampleNodeId string `json:"sample_node_id"`
+}
+
+// A snapshot of a Report.
+type Summary struct {
+	Entries int64 `json:"entries"`
+	// The number of templates that entries were added to.
+	Templates   int        `json:"templates"`
+	From        *time.Time `json:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty"`
+	WindowStart *time.Time `json:"window_start,omitempty"`
+	// Ordered by the number of entries within the window, if one is set, and otherwise by count, descending.
+	Top []TemplateStats `json:"top"`
+	// The number of loaded templates, and of the entries that matched none of them.
+	KnownTemplates int   `json:"known_templates"`
+	Anomalies      int64 `json:"anomalies"`
+	// The templates that are not known, if templates were loaded, ordered by first seen.
+	AnomalousTemplates []TemplateStats `json:"anomalous_templates"`
+}
+
+// Returns a Summary of the entries added so far, keeping only the top passed number of templates, and of anomalous templates.
+func (r *Report) Summary(top int) *Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	summary := &Summary{
+		Entries:            r.entries,
+		Top:                []TemplateStats{},
+		KnownTemplates:     r.known,
+		Anomalies:          r.anomalies,
+		AnomalousTemplates: []TemplateStats{},
+	}
+	var windowStart int64
+	if r.entries > 0 {
+		from, to := r.from, r.to
+		summary.From, summary.To = &from, &to
+		if r.window > 0 {
+			start := to.Add(-r.window)
+			summary.WindowStart = &start
+			windowStart = start.Unix()
+		}
+	}
+	for _, cluster := range r.miner.Clusters() {
+		stats := r.stats[cluster]
+		if stats.count == 0 {
+			continue
+		}
+		summary.Templates++
+		templateStats := TemplateStats{
+			Id:           cluster.Id,
+			Template:     cluster.Template(),
+			Count:        stats.count,
+			FirstSeen:    stats.firstSeen,
+			LastSeen:     stats.lastSeen,
+			Sample:       stats.sample,
+			SampleNodeId: stats.sampleNodeId,
+		}
+		if r.window > 0 {
+			templateStats.WindowCount = stats.windowCount(windowStart)
+		}
+		summary.Top = append(summary.Top, templateStats)
+		if r.known > 0 && !stats.known {
+			summary.AnomalousTemplates = append(summary.AnomalousTemplates, templateStats)
+		}
+	}
+	sort.Slice(summary.Top, func(i, j int) bool {
+		first, second := summary.Top[i], summary.Top[j]
+		if first.WindowCount != second.WindowCount {
+			return first.WindowCount > second.WindowCount
+		}
+		if first.Count != second.Count {
+			return first.Count > second.Count
+		}
+		return first.Id < second.Id
+	})
+	sort.Slice(summary.AnomalousTemplates, func(i, j int) bool {
+		first, second := summary.AnomalousTemplates[i], summary.AnomalousTemplates[j]
+		if !first.FirstSeen.Equal(second.FirstSeen) {
+			return first.FirstSeen.Before(second.FirstSeen)
+		}
+		return first.Id < second.Id
+	})
+	if len(summary.Top) > top {
+		summary.Top = summary.Top[:top]
+	}
+	if len(summary.AnomalousTemplates) > top {
+		summary.AnomalousTemplates = summary.AnomalousTemplates[:top]
+	}
+	return summary
+}
@@ -0,0 +1,155 @@
+package drain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/format"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func entry(second int, message string) *parser.LogLine {
+	return parser.Parse(fmt.Sprintf("2020-12-06T19:%02d:%02dZ [jfrt ] [INFO ] [                ] [a.b.C:12] [main] - %s", second/60, second%60, message))
+}
+
+func TestReport(t *testing.T) {
+	report := NewReport()
+	report.SetWindow(time.Minute)
+	report.Add("node-1", entry(0, "Cluster join: Successfully joined art1 with node id 1"))
+	report.Add("node-1", entry(30, "Cluster join: Successfully joined art2 with node id 2"))
+	report.Add("node-1", entry(70, "Storage quota is low\njava.lang.IllegalStateException: quota"))
+	report.Add("node-1", entry(90, "Cluster join: Successfully joined art3 with node id 3"))
+	report.Add("node-2", entry(80, "Storage quota is low"))
+	report.Add("node-2", parser.Parse("continuation without a timestamp"))
+
+	summary := report.Summary(10)
+	assert.Equal(t, int64(5), summary.Entries)
+	assert.Equal(t, 2, summary.Templates)
+	assert.Equal(t, "2020-12-06T19:00:30Z", format.Time(*summary.WindowStart))
+	require.Len(t, summary.Top, 2)
+	// ordered by the entries within the window, rather than by count
+	assert.Equal(t, "Storage quota is low", summary.Top[0].Template)
+	assert.Equal(t, int64(2), summary.Top[0].Count)
+	assert.Equal(t, int64(2), summary.Top[0].WindowCount)
+	assert.Equal(t, "Cluster join: Successfully joined <*> with node id <*>", summary.Top[1].Template)
+	assert.Equal(t, int64(3), summary.Top[1].Count)
+	assert.Equal(t, int64(1), summary.Top[1].WindowCount)
+	assert.Equal(t, "Cluster join: Successfully joined art1 with node id 1", summary.Top[1].Sample)
+	assert.Equal(t, "2020-12-06T19:01:30Z", format.Time(summary.Top[1].LastSeen))
+	assert.Equal(t, 0, summary.KnownTemplates)
+	assert.Empty(t, summary.AnomalousTemplates)
+	assert.Len(t, report.Summary(1).Top, 1)
+}
+
+func TestReport_SourceOutput(t *testing.T) {
+	const quotaLine = "2020-12-06T19:00:01Z [jfrt ] [WARN ] [                ] [a.b.C:12] [main] - Storage quota is low\n"
+	tests := []struct {
+		name         string
+		writes       []string
+		wantEntries  int64
+		wantTemplate string
+	}{
+		{name: "single write", writes: []string{quotaLine + quotaLine}, wantEntries: 2, wantTemplate: "Storage quota is low"},
+		{name: "line split over writes", writes: []string{quotaLine[:40], quotaLine[40:]}, wantEntries: 1, wantTemplate: "Storage quota is low"},
+		{name: "partial line added once closed", writes: []string{strings.TrimSuffix(quotaLine, "\n")}, wantEntries: 1, wantTemplate: "Storage quota is low"},
+		{name: "stack trace of a later write", writes: []string{quotaLine, "java.lang.IllegalStateException: quota\n"}, wantEntries: 1, wantTemplate: "Storage quota is low"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewReport()
+			output := report.SourceOutput()(livelog.Source{NodeId: "node-1", LogName: "artifactory-service.log"})
+			for _, content := range tt.writes {
+				_, err := output.Write([]byte(content))
+				require.NoError(t, err)
+			}
+			require.NoError(t, output.(io.Closer).Close())
+			summary := report.Summary(10)
+			assert.Equal(t, tt.wantEntries, summary.Entries)
+			require.Len(t, summary.Top, 1)
+			assert.Equal(t, tt.wantTemplate, summary.Top[0].Template)
+			assert.Equal(t, "node-1", summary.Top[0].SampleNodeId)
+		})
+	}
+}
+
+func TestReport_templates(t *testing.T) {
+	report := NewReport()
+	report.Add("node-1", entry(0, "Cluster join: Successfully joined art1 with node id 1"))
+	report.Add("node-1", entry(1, "Storage quota is low"))
+	saved := &bytes.Buffer{}
+	require.NoError(t, report.SaveTemplates(saved))
+	assert.Equal(t, `{
+  "version": 1,
+  "templates": [
+    {
+      "id": 1,
+      "template": "Cluster join: Successfully joined <*> with node id <*>",
+      "count": 1
+    },
+    {
+      "id": 2,
+      "template": "Storage quota is low",
+      "count": 1
+    }
+  ]
+}
+`, saved.String())
+
+	later := NewReport()
+	require.NoError(t, later.LoadTemplates(bytes.NewReader(saved.Bytes())))
+	later.Add("node-2", entry(10, "Cluster join: Successfully joined art2 with node id 2"))
+	later.Add("node-2", entry(11, "Cluster join: Failed to join art2"))
+	later.Add("node-2", entry(12, "Cluster join: Failed to join art3"))
+	summary := later.Summary(10)
+	assert.Equal(t, 2, summary.KnownTemplates)
+	assert.Equal(t, int64(2), summary.Anomalies)
+	assert.Equal(t, 2, summary.Templates)
+	require.Len(t, summary.AnomalousTemplates, 1)
+	anomalous := summary.AnomalousTemplates[0]
+	assert.Equal(t, 3, anomalous.Id)
+	assert.Equal(t, "Cluster join: Failed to join <*>", anomalous.Template)
+	assert.Equal(t, "Cluster join: Failed to join art2", anomalous.Sample)
+	assert.Equal(t, "node-2", anomalous.SampleNodeId)
+
+	// the counts of the known templates accumulate over runs
+	resaved := &bytes.Buffer{}
+	require.NoError(t, later.SaveTemplates(resaved))
+	var file templatesFile
+	require.NoError(t, json.Unmarshal(resaved.Bytes(), &file))
+	assert.Equal(t, []savedTemplate{
+		{Id: 1, Template: "Cluster join: Successfully joined <*> with node id <*>", Count: 2},
+		{Id: 2, Template: "Storage quota is low", Count: 1},
+		{Id: 3, Template: "Cluster join: Failed to join <*>", Count: 2},
+	}, file.Templates)
+}
+
+func TestReport_LoadTemplates_invalid(t *testing.T) {
+	assert.Error(t, NewReport().LoadTemplates(bytes.NewReader([]byte("not json"))))
+	assert.EqualError(t, NewReport().LoadTemplates(bytes.NewReader([]byte(`{"version":2}`))),
+		"unsupported templates file version [2], expected [1]")
+}
+
+func TestWriteText(t *testing.T) {
+	report := NewReport()
+	require.NoError(t, report.LoadTemplates(bytes.NewReader([]byte(`{"version":1,"templates":[{"id":1,"template":"Storage quota is low","count":5}]}`))))
+	report.Add("node-1", entry(0, "Storage quota is low"))
+	report.Add("node-1", entry(1, "Storage quota is low"))
+	report.Add("node-1", entry(2, "Disk failure on /dev/sda1"))
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteText(out, report.Summary(10)))
+	assert.Equal(t, "Entries: 3, templates: 2, from 2020-12-06T19:00:00Z to 2020-12-06T19:00:02Z, known templates: 1, anomalies: 1\n"+
+		"\n"+
+		"ID  COUNT  SHARE  TEMPLATE\n"+
+		"1   2      66.7%  Storage quota is low\n"+
+		"2   1      33.3%  Disk failure on <*>\n"+
+		"\n"+
+		"ANOMALY  COUNT  FIRST SEEN            NODE    SAMPLE\n"+
+		"2        1      2020-12-06T19:00:02Z  node-1  Disk failure on /dev/sda1\n", out.String())
+}
@@ -0,0 +1,49 @@
+package drain
+
+import (
+	"fmt"
+	"github.com/hanoch-jfrog/forest/format"
+	"io"
+	"text/tabwriter"
+)
+
+// The number of characters of a template or sample that is shown, before it is cut.
+const maxTextLength = 120
+
+// Writes the passed Summary as aligned tables of its top templates and, if templates were loaded, of its anomalous templates.
+func WriteText(output io.Writer, summary *Summary) error {
+	table := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "Entries: %d, templates: %d", summary.Entries, summary.Templates)
+	if summary.From != nil {
+		fmt.Fprintf(table, ", from %s to %s", format.Time(*summary.From), format.Time(*summary.To))
+	}
+	if summary.WindowStart != nil {
+		fmt.Fprintf(table, ", window from %s", format.Time(*summary.WindowStart))
+	}
+	if summary.KnownTemplates > 0 {
+		fmt.Fprintf(table, ", known templates: %d, anomalies: %d", summary.KnownTemplates, summary.Anomalies)
+	}
+	fmt.Fprint(table, "\n")
+
+	if len(summary.Top) > 0 {
+		fmt.Fprint(table, "\nID\tCOUNT\t")
+		if summary.WindowStart != nil {
+			fmt.Fprint(table, "IN WINDOW\t")
+		}
+		fmt.Fprint(table, "SHARE\tTEMPLATE\n")
+		for _, template := range summary.Top {
+			fmt.Fprintf(table, "%d\t%d\t", template.Id, template.Count)
+			if summary.WindowStart != nil {
+				fmt.Fprintf(table, "%d\t", template.WindowCount)
+			}
+			fmt.Fprintf(table, "%.1f%%\t%s\n", 100*float64(template.Count)/float64(summary.Entries), format.Cut(template.Template, maxTextLength))
+		}
+	}
+	if len(summary.AnomalousTemplates) > 0 {
+		fmt.Fprint(table, "\nANOMALY\tCOUNT\tFIRST SEEN\tNODE\tSAMPLE\n")
+		for _, template := range summary.AnomalousTemplates {
+			fmt.Fprintf(table, "%d\t%d\t%s\t%s\t%s\n", template.Id, template.Count, format.Time(template.FirstSeen), template.SampleNodeId, format.Cut(template.Sample, maxTextLength))
+		}
+	}
+	return table.Flush()
+}
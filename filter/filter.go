@@ -0,0 +1,109 @@
+package filter
+
+import (
+	"fmt"
+	"github.com/hanoch-jfrog/forest/parser"
+	"sort"
+	"strings"
+)
+
+// Matches parsed log entries by their fields.
+// Every non-empty criterion must match, and a criterion matches if any of its values matches.
+type Filter struct {
+	// Levels to match exactly.
+	Levels []parser.Level
+	// Service types to match exactly, such as jfrt or jfac.
+	Services []string
+	// Substrings to match in the thread name.
+	Threads []string
+	// Substrings to match in the logging location.
+	Loggers []string
+	// Trace ids to match exactly.
+	TraceIds []string
+}
+
+// Parses a level criterion: a comma-separated list of levels, where a level suffixed by '+',
+// such as "warn+", stands for that level and every level more severe than it.
+func ParseLevels(levelsSpec string) ([]parser.Level, error) {
+	matched := make(map[parser.Level]bool)
+	for _, levelSpec := range strings.Split(levelsSpec, ",") {
+		levelSpec = strings.TrimSpace(levelSpec)
+		if levelSpec == "" {
+			continue
+		}
+		andAbove := strings.HasSuffix(levelSpec, "+")
+		level, err := parser.ParseLevel(strings.TrimSuffix(levelSpec, "+"))
+		if err != nil {
+			return nil, err
+		}
+		matched[level] = true
+		for andAbove && level < parser.LevelFatal {
+			level++
+			matched[level] = true
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no log level found in [%v]", levelsSpec)
+	}
+	levels := make([]parser.Level, 0, len(matched))
+	for level := range matched {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i] < levels[j]
+	})
+	return levels, nil
+}
+
+// Returns whether the filter has no criteria, and therefore matches every entry.
+func (f *Filter) IsEmpty() bool {
+	return len(f.Levels) == 0 && len(f.Services) == 0 && len(f.Threads) == 0 && len(f.Loggers) == 0 && len(f.TraceIds) == 0
+}
+
+// Returns whether the passed entry matches every criterion of the filter.
+// A continuation entry has no fields of its own, and only matches an empty filter.
+func (f *Filter) Match(logLine *parser.LogLine) bool {
+	if len(f.Levels) > 0 && !containsLevel(f.Levels, logLine.Level) {
+		return false
+	}
+	if len(f.Services) > 0 && !containsEqual(f.Services, logLine.Service) {
+		return false
+	}
+	if len(f.Threads) > 0 && !containsSubstring(f.Threads, logLine.Thread) {
+		return false
+	}
+	if len(f.Loggers) > 0 && !containsSubstring(f.Loggers, logLine.Logger) {
+		return false
+	}
+	if len(f.TraceIds) > 0 && !containsEqual(f.TraceIds, logLine.TraceId) {
+		return false
+	}
+	return true
+}
+
+func containsLevel(levels []parser.Level, level parser.Level) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEqual(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSubstring(substrings []string, value string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(value, substring) {
+			return true
+		}
+	}
+	return false
+}
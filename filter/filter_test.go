@@ -0,0 +1,113 @@
+package filter
+
+import (
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	tests := []struct {
+		name       string
+		levelsSpec string
+		want       []parser.Level
+		wantErr    bool
+	}{
+		{
+			name:       "single level",
+			levelsSpec: "error",
+			want:       []parser.Level{parser.LevelError},
+		},
+		{
+			name:       "level and above",
+			levelsSpec: "warn+",
+			want:       []parser.Level{parser.LevelWarn, parser.LevelError, parser.LevelFatal},
+		},
+		{
+			name:       "level list",
+			levelsSpec: "DEBUG, error,debug",
+			want:       []parser.Level{parser.LevelDebug, parser.LevelError},
+		},
+		{
+			name:       "unknown level",
+			levelsSpec: "warn,loud",
+			wantErr:    true,
+		},
+		{
+			name:       "no level",
+			levelsSpec: " , ",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevels(tt.levelsSpec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	logLine := parser.Parse("2020-12-06T19:21:52.549Z [jfac ] [WARN ] [6469d8c8e2ece130] [a.s.b.AccessServerRegistrar:73] [pool-26-thread-1    ] - message")
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{
+			name: "empty filter",
+			want: true,
+		},
+		{
+			name:   "matching level",
+			filter: Filter{Levels: []parser.Level{parser.LevelWarn, parser.LevelError}},
+			want:   true,
+		},
+		{
+			name:   "mismatching level",
+			filter: Filter{Levels: []parser.Level{parser.LevelError}},
+		},
+		{
+			name:   "matching service",
+			filter: Filter{Services: []string{"jfrt", "JFAC"}},
+			want:   true,
+		},
+		{
+			name:   "mismatching service",
+			filter: Filter{Services: []string{"jfrt"}},
+		},
+		{
+			name:   "matching thread",
+			filter: Filter{Threads: []string{"pool-26"}},
+			want:   true,
+		},
+		{
+			name:   "matching logger",
+			filter: Filter{Loggers: []string{"AccessServerRegistrar"}},
+			want:   true,
+		},
+		{
+			name:   "mismatching logger",
+			filter: Filter{Loggers: []string{"NodeRegistryServiceImpl"}},
+		},
+		{
+			name:   "matching trace id",
+			filter: Filter{TraceIds: []string{"6469d8c8e2ece130"}},
+			want:   true,
+		},
+		{
+			name:   "one mismatching criterion",
+			filter: Filter{Services: []string{"jfac"}, TraceIds: []string{"7ccdb881f0258729"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(logLine))
+		})
+	}
+}
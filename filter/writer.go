@@ -0,0 +1,44 @@
+package filter
+
+import (
+	"github.com/hanoch-jfrog/forest/parser"
+	"io"
+)
+
+// An io.WriteCloser that writes only the entries matching a Filter into its output, keeping multi-line entries together.
+// Continuation entries, whose first line arrived in an earlier write, follow the decision made for that first line.
+// Closing the Writer writes the remaining partial entry, if it matches, but does not close the output.
+type Writer struct {
+	entryWriter *parser.EntryWriter
+	output      io.Writer
+	filter      *Filter
+	lastMatched bool
+}
+
+func NewWriter(output io.Writer, filter *Filter) *Writer {
+	w := &Writer{
+		output: output,
+		filter: filter,
+	}
+	w.entryWriter = parser.NewEntryWriter(w.writeMatching)
+	return w
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.entryWriter.Write(p)
+}
+
+func (w *Writer) Close() error {
+	return w.entryWriter.Close()
+}
+
+func (w *Writer) writeMatching(logLine *parser.LogLine) error {
+	if !logLine.IsContinuation() {
+		w.lastMatched = w.filter.Match(logLine)
+	}
+	if !w.lastMatched {
+		return nil
+	}
+	_, err := io.WriteString(w.output, logLine.Raw+"\n")
+	return err
+}
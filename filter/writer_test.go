@@ -0,0 +1,26 @@
+package filter
+
+import (
+	"bytes"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := NewWriter(out, &Filter{Levels: []parser.Level{parser.LevelError}})
+
+	_, err := w.Write([]byte("2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - an error message\n" +
+		"2020-12-06T19:21:52.550Z [jfrt ] [ERROR] - failure\n\tat one\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("\tat two\n2020-12-06T19:21:52.551Z [jfrt ] [INFO ] - info\n\tat three\n" +
+		"2020-12-06T19:21:52.552Z [jfrt ] [ERROR] - partial"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "2020-12-06T19:21:52.550Z [jfrt ] [ERROR] - failure\n\tat one\n"+
+		"\tat two\n"+
+		"2020-12-06T19:21:52.552Z [jfrt ] [ERROR] - partial\n", out.String())
+}
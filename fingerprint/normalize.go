@@ -0,0 +1,46 @@
+package fingerprint
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The number of lines of an entry, including its first line, that its fingerprint is made of,
+// so that deep stack traces that only differ in their outermost frames are still grouped together.
+const maxFingerprintLines = 30
+
+var (
+	uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	// URLs, and paths of at least two segments, such as /var/opt/jfrog or libs-release-local/org/acme
+	pathPattern   = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s'"<>]+|[^\s'"():,=\[\]<>/]*(?:/[^\s'"():,=\[\]<>/]+){2,}/?`)
+	hexPattern    = regexp.MustCompile(`\b(?:0x[0-9a-fA-F]+|[0-9a-fA-F]{8,})\b`)
+	digitsPattern = regexp.MustCompile(`\d+`)
+)
+
+// Replaces the parts of the passed text that vary between occurrences of the same message with placeholders:
+// UUIDs with <uuid>, URLs and paths with <path>, hex values of at least 8 digits or with a 0x prefix with <hex>,
+// and any other run of digits, such as ids, counts, ports and line numbers, with <num>.
+func Normalize(text string) string {
+	text = uuidPattern.ReplaceAllString(text, "<uuid>")
+	text = pathPattern.ReplaceAllString(text, "<path>")
+	text = hexPattern.ReplaceAllStringFunc(text, func(hex string) string {
+		// words made of the letters a to f only, such as "deadbeef" or "facade", are not hex values
+		if strings.HasPrefix(hex, "0x") || strings.IndexAny(hex, "0123456789") >= 0 {
+			return "<hex>"
+		}
+		return hex
+	})
+	return digitsPattern.ReplaceAllString(text, "<num>")
+}
+
+// Normalizes every line of the passed message, up to maxFingerprintLines lines, trimming the indentation of stack frames.
+func normalizeMessage(message string) string {
+	lines := strings.SplitN(message, "\n", maxFingerprintLines+1)
+	if len(lines) > maxFingerprintLines {
+		lines = lines[:maxFingerprintLines]
+	}
+	for idx, line := range lines {
+		lines[idx] = Normalize(strings.TrimSpace(line))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,28 @@
+package fingerprint
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Cluster join: Successfully joined art1 with node id 0b1c4a2e-12ab-4cde-9f00-1234567890ab", "Cluster join: Successfully joined art<num> with node id <uuid>"},
+		{"Failed to read /var/opt/jfrog/artifactory/data/a.jar for user 12", "Failed to read <path> for user <num>"},
+		{"Could not deploy libs-release-local/org/acme/app-1.0.jar", "Could not deploy <path>"},
+		{"Connection to http://localhost:8046/access/api refused", "Connection to <path> refused"},
+		{"Checksum 9c9d2a4e0b7f1c2d mismatch at 0x1f", "Checksum <hex> mismatch at <hex>"},
+		{"at org.acme.Foo$$Lambda$123/0x0000000800c4b440.run(Foo.java:42)", "at org.acme.Foo$$Lambda$<num>/<hex>.run(Foo.java:<num>)"},
+		{"the deadbeef facade is not hex", "the deadbeef facade is not hex"},
+		{"Timeout after 30000ms of 2 retries", "Timeout after <num>ms of <num> retries"},
+		{"no variable parts", "no variable parts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.text))
+		})
+	}
+}
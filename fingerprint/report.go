@@ -0,0 +1,227 @@
+package fingerprint
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/hanoch-jfrog/forest/util"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The number of hex digits of a fingerprint id.
+const idLength = 12
+
+// Groups the WARN, ERROR and FATAL entries of the logs of many sources by their fingerprints.
+// It is safe for concurrent use, so that a Summary can be taken while entries are added.
+type Report struct {
+	newSince time.Time
+
+	mu           sync.Mutex
+	entries      int64
+	from         time.Time
+	to           time.Time
+	fingerprints map[string]*Fingerprint
+}
+
+// The entries that share a single fingerprint, which is made of their level, service, logging location
+// and normalized message, including their stack trace.
+type Fingerprint struct {
+	Id      string `json:"id"`
+	Level   string `json:"level"`
+	Service string `json:"service"`
+	// The logging location, without its line number.
+	Logger string `json:"logger"`
+	// The normalized first line of the message.
+	Template  string    `json:"template"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	NodeIds   []string  `json:"node_ids"`
+	// The original text of the first entry of the fingerprint, including its stack trace.
+	Sample string `json:"sample"`
+	// Whether the fingerprint was first seen at or after the time set by SetNewSince.
+	New bool `json:"new"`
+}
+
+func NewReport() *Report {
+	return &Report{
+		fingerprints: make(map[string]*Fingerprint),
+	}
+}
+
+// Sets the time that fingerprints first seen at or after are new, such as the start of a session following the logs.
+// No fingerprint is new by default.
+func (r *Report) SetNewSince(newSince time.Time) {
+	r.newSince = newSince
+}
+
+// Returns whether the passed entry is grouped, which is when it is a WARN, ERROR or FATAL entry.
+// Continuation entries are not grouped on their own, as they cannot be told apart from the entries of other formats;
+// the io.Writers of SourceOutput attach them to the entry preceding them instead.
+func IsGrouped(logLine *parser.LogLine) bool {
+	return !logLine.IsContinuation() && logLine.Level >= parser.LevelWarn
+}
+
+// Adds the passed entry of the passed node, if it is grouped.
+func (r *Report) Add(nodeId string, logLine *parser.LogLine) {
+	if !IsGrouped(logLine) {
+		return
+	}
+	logger := logLine.Logger
+	if lineStart := strings.LastIndexByte(logger, ':'); lineStart >= 0 {
+		logger = logger[:lineStart]
+	}
+	message := normalizeMessage(logLine.Message)
+	digest := sha1.Sum([]byte(strings.Join([]string{logLine.Level.String(), logLine.Service, logger, message}, "\x00")))
+	id := hex.EncodeToString(digest[:])[:idLength]
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries++
+	if r.from.IsZero() || logLine.Timestamp.Before(r.from) {
+		r.from = logLine.Timestamp
+	}
+	if logLine.Timestamp.After(r.to) {
+		r.to = logLine.Timestamp
+	}
+	fingerprint := r.fingerprints[id]
+	if fingerprint == nil {
+		template := message
+		if lineEnd := strings.IndexByte(template, '\n'); lineEnd >= 0 {
+			template = template[:lineEnd]
+		}
+		fingerprint = &Fingerprint{
+			Id:        id,
+			Level:     logLine.Level.String(),
+			Service:   logLine.Service,
+			Logger:    logger,
+			Template:  template,
+			FirstSeen: logLine.Timestamp,
+			LastSeen:  logLine.Timestamp,
+			Sample:    logLine.Raw,
+		}
+		r.fingerprints[id] = fingerprint
+	}
+	fingerprint.Count++
+	if logLine.Timestamp.Before(fingerprint.FirstSeen) {
+		// the logs of many nodes interleave, so the sample is of the earliest entry rather than of the first one added
+		fingerprint.FirstSeen = logLine.Timestamp
+		fingerprint.Sample = logLine.Raw
+	}
+	if logLine.Timestamp.After(fingerprint.LastSeen) {
+		fingerprint.LastSeen = logLine.Timestamp
+	}
+	if nodeId != "" && !util.InSlice(fingerprint.NodeIds, nodeId) {
+		fingerprint.NodeIds = append(fingerprint.NodeIds, nodeId)
+		sort.Strings(fingerprint.NodeIds)
+	}
+}
+
+// Returns a SourceOutput whose io.Writers add every entry written into them to the Report.
+// Continuation entries, such as stack traces that arrived in a later write, are attached to the entry preceding them,
+// so the last grouped entry of every source is only added once the entry following it is written, or once the io.Writer is closed.
+func (r *Report) SourceOutput() livelog.SourceOutput {
+	return func(source livelog.Source) io.Writer {
+		w := &sourceWriter{
+			report: r,
+			nodeId: source.NodeId,
+		}
+		w.entryWriter = parser.NewEntryWriter(w.handle)
+		return w
+	}
+}
+
+// An io.WriteCloser that adds the entries of a single source to a Report, holding its last grouped entry.
+type sourceWriter struct {
+	report      *Report
+	nodeId      string
+	entryWriter *parser.EntryWriter
+	lastEntry   *parser.LogLine
+}
+
+func (w *sourceWriter) Write(p []byte) (int, error) {
+	return w.entryWriter.Write(p)
+}
+
+// Adds the held entry, once the remaining log data is handled.
+func (w *sourceWriter) Close() error {
+	err := w.entryWriter.Close()
+	w.addLastEntry()
+	return err
+}
+
+func (w *sourceWriter) handle(logLine *parser.LogLine) error {
+	if logLine.IsContinuation() {
+		if w.lastEntry != nil {
+			w.lastEntry.Raw += "\n" + logLine.Raw
+			w.lastEntry.Message += "\n" + logLine.Raw
+		}
+		return nil
+	}
+	w.addLastEntry()
+	if IsGrouped(logLine) {
+		w.lastEntry = logLine
+	}
+	return nil
+}
+
+func (w *sourceWriter) addLastEntry() {
+	if w.lastEntry != nil {
+		w.report.Add(w.nodeId, w.lastEntry)
+		w.lastEntry = nil
+	}
+}
+
+// A snapshot of a Report.
+type Summary struct {
+	// The number of grouped entries.
+	Entries int64 `json:"entries"`
+	// The number of distinct fingerprints, of which only the top ones are kept.
+	Total    int        `json:"total"`
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+	NewSince *time.Time `json:"new_since,omitempty"`
+	// Ordered by count, descending.
+	Fingerprints []Fingerprint `json:"fingerprints"`
+}
+
+// Returns a Summary of the entries added so far, keeping only the top passed number of fingerprints.
+func (r *Report) Summary(top int) *Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	summary := &Summary{
+		Entries:      r.entries,
+		Total:        len(r.fingerprints),
+		Fingerprints: make([]Fingerprint, 0, len(r.fingerprints)),
+	}
+	if r.entries > 0 {
+		from, to := r.from, r.to
+		summary.From, summary.To = &from, &to
+	}
+	if !r.newSince.IsZero() {
+		newSince := r.newSince
+		summary.NewSince = &newSince
+	}
+	for _, fingerprint := range r.fingerprints {
+		fingerprintCopy := *fingerprint
+		fingerprintCopy.NodeIds = append([]string(nil), fingerprint.NodeIds...)
+		fingerprintCopy.New = !r.newSince.IsZero() && !fingerprint.FirstSeen.Before(r.newSince)
+		summary.Fingerprints = append(summary.Fingerprints, fingerprintCopy)
+	}
+	sort.Slice(summary.Fingerprints, func(i, j int) bool {
+		first, second := summary.Fingerprints[i], summary.Fingerprints[j]
+		if first.Count != second.Count {
+			return first.Count > second.Count
+		}
+		return first.FirstSeen.Before(second.FirstSeen) || first.FirstSeen.Equal(second.FirstSeen) && first.Id < second.Id
+	})
+	if len(summary.Fingerprints) > top {
+		summary.Fingerprints = summary.Fingerprints[:top]
+	}
+	return summary
+}
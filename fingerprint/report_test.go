@@ -0,0 +1,163 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/format"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	timeoutEntry1 = "2020-12-06T19:21:52.540Z [jfrt ] [ERROR] [6469d8c8e2ece130] [o.a.h.Client:120] [http-nio-8081-exec-1] - Request to http://localhost:8046/access/api/v1/tokens timed out after 30000ms\n" +
+		"java.net.SocketTimeoutException: Read timed out\n" +
+		"\tat java.net.SocketInputStream.read(SocketInputStream.java:171)"
+	timeoutEntry2 = "2020-12-06T19:22:10.000Z [jfrt ] [ERROR] [0a1b2c3d4e5f6a7b] [o.a.h.Client:121] [http-nio-8081-exec-7] - Request to http://localhost:8046/access/api/v1/users/12 timed out after 60000ms\n" +
+		"java.net.SocketTimeoutException: Read timed out\n" +
+		"\tat java.net.SocketInputStream.read(SocketInputStream.java:171)"
+	// the same message, but with another exception
+	refusedEntry = "2020-12-06T19:21:53.000Z [jfrt ] [ERROR] [6469d8c8e2ece130] [o.a.h.Client:120] [http-nio-8081-exec-2] - Request to http://localhost:8046/access/api/v1/tokens timed out after 30000ms\n" +
+		"java.net.ConnectException: Connection refused"
+	warnEntry = "2020-12-06T19:21:54.000Z [jfac ] [WARN ] [                ] [a.s.Registrar:73] [pool-26-thread-1] - Retrying registration 3"
+	infoEntry = "2020-12-06T19:21:55.000Z [jfrt ] [INFO ] [                ] [a.b.C:12] [main] - Started"
+)
+
+func TestReport(t *testing.T) {
+	report := NewReport()
+	report.SetNewSince(time.Date(2020, 12, 6, 19, 21, 53, 0, time.UTC))
+	report.Add("node-2", parser.Parse(timeoutEntry2))
+	report.Add("node-2", parser.Parse(infoEntry))
+	report.Add("node-2", parser.Parse(warnEntry))
+	report.Add("node-1", parser.Parse(timeoutEntry1))
+	report.Add("node-1", parser.Parse(refusedEntry))
+
+	summary := report.Summary(10)
+	assert.Equal(t, int64(4), summary.Entries)
+	assert.Equal(t, 3, summary.Total)
+	assert.Equal(t, "2020-12-06T19:21:52Z", format.Time(*summary.From))
+	assert.Equal(t, "2020-12-06T19:22:10Z", format.Time(*summary.To))
+	require.Len(t, summary.Fingerprints, 3)
+
+	timeouts := summary.Fingerprints[0]
+	assert.Len(t, timeouts.Id, idLength)
+	assert.Equal(t, int64(2), timeouts.Count)
+	assert.Equal(t, "ERROR", timeouts.Level)
+	assert.Equal(t, "jfrt", timeouts.Service)
+	assert.Equal(t, "o.a.h.Client", timeouts.Logger)
+	assert.Equal(t, "Request to <path> timed out after <num>ms", timeouts.Template)
+	assert.Equal(t, "2020-12-06T19:21:52Z", format.Time(timeouts.FirstSeen))
+	assert.Equal(t, "2020-12-06T19:22:10Z", format.Time(timeouts.LastSeen))
+	assert.Equal(t, []string{"node-1", "node-2"}, timeouts.NodeIds)
+	// the sample is of the earliest entry, even though a later one was added first
+	assert.Equal(t, timeoutEntry1, timeouts.Sample)
+	assert.False(t, timeouts.New)
+
+	refused, warn := summary.Fingerprints[1], summary.Fingerprints[2]
+	assert.NotEqual(t, timeouts.Id, refused.Id)
+	assert.True(t, refused.New)
+	assert.Equal(t, "WARN", warn.Level)
+	assert.Equal(t, "Retrying registration <num>", warn.Template)
+	assert.True(t, warn.New)
+
+	assert.Len(t, report.Summary(1).Fingerprints, 1)
+	_, err := json.Marshal(summary)
+	require.NoError(t, err)
+}
+
+func TestReport_SourceOutput(t *testing.T) {
+	firstLineEnd := strings.IndexByte(timeoutEntry1, '\n') + 1
+	tests := []struct {
+		name   string
+		writes []string
+		// the entries added before the writer is closed, as the last grouped entry is held until the entry following it
+		wantHeldEntries int64
+	}{
+		{
+			name:            "single write",
+			writes:          []string{timeoutEntry1 + "\n" + infoEntry + "\n"},
+			wantHeldEntries: 1,
+		},
+		{
+			name:            "stack trace of a later write",
+			writes:          []string{timeoutEntry1[:firstLineEnd], timeoutEntry1[firstLineEnd:] + "\n" + infoEntry + "\n"},
+			wantHeldEntries: 1,
+		},
+		{
+			name:   "stack trace completed once closed",
+			writes: []string{timeoutEntry1[:firstLineEnd], timeoutEntry1[firstLineEnd:]},
+		},
+	}
+	// the fingerprint of the whole entry
+	wholeReport := NewReport()
+	wholeReport.Add("node-1", parser.Parse(timeoutEntry1))
+	wantId := wholeReport.Summary(10).Fingerprints[0].Id
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewReport()
+			output := report.SourceOutput()(livelog.Source{NodeId: "node-1", LogName: "artifactory-service.log"})
+			for _, content := range tt.writes {
+				_, err := output.Write([]byte(content))
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantHeldEntries, report.Summary(10).Entries)
+			require.NoError(t, output.(io.Closer).Close())
+
+			summary := report.Summary(10)
+			require.Len(t, summary.Fingerprints, 1)
+			assert.Equal(t, wantId, summary.Fingerprints[0].Id)
+			assert.Equal(t, int64(1), summary.Fingerprints[0].Count)
+			assert.Equal(t, timeoutEntry1, summary.Fingerprints[0].Sample)
+		})
+	}
+}
+
+func TestReport_emptySummary(t *testing.T) {
+	summaryJson, err := json.Marshal(NewReport().Summary(10))
+	require.NoError(t, err)
+	assert.Equal(t, `{"entries":0,"total":0,"fingerprints":[]}`, string(summaryJson))
+}
+
+func TestWriteText(t *testing.T) {
+	report := NewReport()
+	report.Add("node-1", parser.Parse(warnEntry))
+	report.Add("node-1", parser.Parse(warnEntry))
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteText(out, report.Summary(10), true))
+	summary := report.Summary(10)
+	id := summary.Fingerprints[0].Id
+	assert.Equal(t, "Entries: 2, fingerprints: 1, from 2020-12-06T19:21:54Z to 2020-12-06T19:21:54Z\n"+
+		"\n"+
+		"ID            COUNT  LEVEL  SERVICE  FIRST SEEN            LAST SEEN             NODES   MESSAGE\n"+
+		id+"  2      WARN   jfac     2020-12-06T19:21:54Z  2020-12-06T19:21:54Z  node-1  Retrying registration <num>\n"+
+		"\n"+
+		"== "+id+", 2 entries ==\n"+
+		warnEntry+"\n", out.String())
+}
+
+func TestWriteText_new(t *testing.T) {
+	report := NewReport()
+	report.SetNewSince(time.Date(2020, 12, 6, 19, 21, 54, 0, time.UTC))
+	report.Add("node-1", parser.Parse(warnEntry))
+	var lines []string
+	for idx := 0; idx < maxSampleLines+2; idx++ {
+		lines = append(lines, "\tat a.b.C.run(C.java:1)")
+	}
+	report.Add("node-1", parser.Parse(strings.Replace(timeoutEntry1, "2020-12-06T19:21:52.540Z", "2020-12-06T19:20:00Z", 1)+"\n"+strings.Join(lines, "\n")))
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteText(out, report.Summary(10), true))
+	rows := strings.Split(out.String(), "\n")
+	assert.Equal(t, "Entries: 2, fingerprints: 2, from 2020-12-06T19:20:00Z to 2020-12-06T19:21:54Z, new since 2020-12-06T19:21:54Z", rows[0])
+	assert.True(t, strings.HasPrefix(rows[2], "NEW  ID"))
+	// equal counts are ordered by first seen, so the old timeout comes first, and only the new warning is highlighted
+	assert.True(t, strings.HasPrefix(rows[3], "     "))
+	assert.True(t, strings.HasPrefix(rows[4], styleNew+"*    "))
+	assert.True(t, strings.HasSuffix(rows[4], styleReset))
+	assert.Contains(t, out.String(), "\n... 5 more lines\n")
+	assert.Contains(t, out.String(), styleNew+"== "+report.Summary(10).Fingerprints[1].Id+", 1 entry, new ==")
+}
@@ -0,0 +1,87 @@
+package fingerprint
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/format"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	// The number of characters of a template that is shown in the table, before it is cut.
+	maxTemplateLength = 100
+	// The number of lines of a sample that are shown, before it is cut.
+	maxSampleLines = 10
+
+	styleNew   = "\x1b[1;33m"
+	styleReset = "\x1b[0m"
+)
+
+// Writes the passed Summary as a table of its fingerprints, followed by a sample of each of them.
+// If the Summary tells which fingerprints are new, they are marked in a NEW column, and are also highlighted if highlight is set.
+func WriteText(output io.Writer, summary *Summary, highlight bool) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Entries: %d, fingerprints: %d", summary.Entries, summary.Total)
+	if summary.From != nil {
+		fmt.Fprintf(&buf, ", from %s to %s", format.Time(*summary.From), format.Time(*summary.To))
+	}
+	if summary.NewSince != nil {
+		fmt.Fprintf(&buf, ", new since %s", format.Time(*summary.NewSince))
+	}
+	buf.WriteString("\n")
+	if len(summary.Fingerprints) == 0 {
+		_, err := output.Write(buf.Bytes())
+		return err
+	}
+
+	var tableBuf bytes.Buffer
+	table := tabwriter.NewWriter(&tableBuf, 0, 0, 2, ' ', 0)
+	if summary.NewSince != nil {
+		fmt.Fprint(table, "NEW\t")
+	}
+	fmt.Fprint(table, "ID\tCOUNT\tLEVEL\tSERVICE\tFIRST SEEN\tLAST SEEN\tNODES\tMESSAGE\n")
+	for _, fingerprint := range summary.Fingerprints {
+		if summary.NewSince != nil {
+			fmt.Fprint(table, newMark(fingerprint.New)+"\t")
+		}
+		fmt.Fprintf(table, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", fingerprint.Id, fingerprint.Count, fingerprint.Level, fingerprint.Service,
+			format.Time(fingerprint.FirstSeen), format.Time(fingerprint.LastSeen), strings.Join(fingerprint.NodeIds, ","), format.Cut(fingerprint.Template, maxTemplateLength))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	// rows are highlighted once aligned, as the tabwriter counts the bytes of styles as part of the width of a cell
+	buf.WriteString("\n")
+	for idx, row := range strings.Split(strings.TrimSuffix(tableBuf.String(), "\n"), "\n") {
+		if highlight && idx > 0 && summary.Fingerprints[idx-1].New {
+			row = styleNew + row + styleReset
+		}
+		buf.WriteString(row + "\n")
+	}
+
+	for _, fingerprint := range summary.Fingerprints {
+		header := fmt.Sprintf("== %s, %d entries", fingerprint.Id, fingerprint.Count)
+		if fingerprint.Count == 1 {
+			header = fmt.Sprintf("== %s, 1 entry", fingerprint.Id)
+		}
+		if fingerprint.New {
+			header += ", new"
+		}
+		header += " =="
+		if highlight && fingerprint.New {
+			header = styleNew + header + styleReset
+		}
+		fmt.Fprintf(&buf, "\n%s\n%s\n", header, format.CutLines(fingerprint.Sample, maxSampleLines))
+	}
+	_, err := output.Write(buf.Bytes())
+	return err
+}
+
+func newMark(isNew bool) string {
+	if isNew {
+		return "*"
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/parser"
+	"strconv"
+	"strings"
+)
+
+// The version of the record schema, written into every machine-readable record.
+// Adding fields keeps the version; renaming, removing or changing the meaning of fields increments it.
+const SchemaVersion = 1
+
+// The layout of record timestamps, with a fixed millisecond precision, as in the JFrog unified log format,
+// so that records sort lexically by time.
+const timestampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+type Format string
+
+const (
+	// The original log text.
+	Text Format = "text"
+	// A JSON object per line. "json" is accepted as an alias.
+	Ndjson Format = "ndjson"
+	// A line of key=value pairs per entry.
+	Logfmt Format = "logfmt"
+	// A CSV row per entry, following a header row.
+	Csv Format = "csv"
+)
+
+// Parses a format name, case insensitively.
+func ParseFormat(formatName string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(formatName)) {
+	case "", string(Text):
+		return Text, nil
+	case "json", string(Ndjson):
+		return Ndjson, nil
+	case string(Logfmt):
+		return Logfmt, nil
+	case string(Csv):
+		return Csv, nil
+	}
+	return "", fmt.Errorf("unknown output format [%v], expected one of text, json, ndjson, logfmt or csv", formatName)
+}
+
+// Identifies where an entry was read from.
+type Origin struct {
+	ServerId string
+	NodeId   string
+	LogName  string
+}
+
+// The machine-readable form of a single entry. Every field is always present, empty when unknown.
+// The timestamp is in RFC 3339 format, in UTC with millisecond precision, and is empty for continuation entries.
+type Record struct {
+	SchemaVersion int    `json:"schema_version"`
+	Timestamp     string `json:"timestamp"`
+	ServerId      string `json:"server_id"`
+	NodeId        string `json:"node_id"`
+	LogName       string `json:"log_name"`
+	Service       string `json:"service"`
+	Level         string `json:"level"`
+	TraceId       string `json:"trace_id"`
+	Logger        string `json:"logger"`
+	Thread        string `json:"thread"`
+	Message       string `json:"message"`
+}
+
+// The field names of a Record, in the order they are written in.
+var FieldNames = []string{"schema_version", "timestamp", "server_id", "node_id", "log_name",
+	"service", "level", "trace_id", "logger", "thread", "message"}
+
+func NewRecord(origin Origin, logLine *parser.LogLine) *Record {
+	record := &Record{
+		SchemaVersion: SchemaVersion,
+		ServerId:      origin.ServerId,
+		NodeId:        origin.NodeId,
+		LogName:       origin.LogName,
+		Service:       logLine.Service,
+		Level:         logLine.Level.String(),
+		TraceId:       logLine.TraceId,
+		Logger:        logLine.Logger,
+		Thread:        logLine.Thread,
+		Message:       logLine.Message,
+	}
+	if !logLine.IsContinuation() {
+		record.Timestamp = logLine.Timestamp.UTC().Format(timestampLayout)
+	}
+	return record
+}
+
+// Returns the field values of the Record, in the order of FieldNames.
+func (r *Record) Values() []string {
+	return []string{strconv.Itoa(r.SchemaVersion), r.Timestamp, r.ServerId, r.NodeId, r.LogName,
+		r.Service, r.Level, r.TraceId, r.Logger, r.Thread, r.Message}
+}
+
+// Returns what precedes the records of the passed format, which is the header row for CSV, and nothing otherwise.
+func Header(format Format) []byte {
+	if format != Csv {
+		return nil
+	}
+	return encodeCsv(FieldNames)
+}
+
+// Encodes a single entry, including its trailing new line.
+// Encoding is stateless, so entries of many sources may be encoded concurrently and written in any order.
+func Encode(format Format, origin Origin, logLine *parser.LogLine) []byte {
+	if format == Text || format == "" {
+		return []byte(logLine.Raw + "\n")
+	}
+	record := NewRecord(origin, logLine)
+	switch format {
+	case Logfmt:
+		return encodeLogfmt(record)
+	case Csv:
+		return encodeCsv(record.Values())
+	default:
+		// A Record holds strings and an int only, so marshaling it does not fail
+		encoded, _ := json.Marshal(record)
+		return append(encoded, '\n')
+	}
+}
+
+func encodeLogfmt(record *Record) []byte {
+	var buf bytes.Buffer
+	for idx, value := range record.Values() {
+		if idx > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(FieldNames[idx])
+		buf.WriteByte('=')
+		if strings.ContainsAny(value, " =\"\\") || strings.IndexFunc(value, isControl) >= 0 {
+			value = strconv.Quote(value)
+		}
+		buf.WriteString(value)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
+
+func encodeCsv(values []string) []byte {
+	var buf bytes.Buffer
+	csvWriter := csv.NewWriter(&buf)
+	// Writing into a bytes.Buffer does not fail
+	_ = csvWriter.Write(values)
+	csvWriter.Flush()
+	return buf.Bytes()
+}
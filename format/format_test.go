@@ -0,0 +1,96 @@
+package format
+
+import (
+	"encoding/json"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testEntry = "2020-12-06T19:21:52.612+02:00 [jfac ] [ERROR] [7ccdb881f0258729] [s.r.NodeRegistryServiceImpl:68] [exec-8] - Join failed: \"a=b\"\n\tat stack.trace"
+
+var testOrigin = Origin{ServerId: "local-arti", NodeId: "node-1", LogName: "access-service.log"}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		formatName string
+		want       Format
+		wantErr    bool
+	}{
+		{name: "default", formatName: "", want: Text},
+		{name: "text", formatName: "text", want: Text},
+		{name: "json alias", formatName: "JSON", want: Ndjson},
+		{name: "ndjson", formatName: "ndjson", want: Ndjson},
+		{name: "logfmt", formatName: "logfmt", want: Logfmt},
+		{name: "csv", formatName: "csv", want: Csv},
+		{name: "unknown", formatName: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.formatName)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	logLine := parser.Parse(testEntry)
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{
+			name:   "text",
+			format: Text,
+			want:   testEntry + "\n",
+		},
+		{
+			name:   "ndjson",
+			format: Ndjson,
+			want: `{"schema_version":1,"timestamp":"2020-12-06T17:21:52.612Z","server_id":"local-arti","node_id":"node-1",` +
+				`"log_name":"access-service.log","service":"jfac","level":"ERROR","trace_id":"7ccdb881f0258729",` +
+				`"logger":"s.r.NodeRegistryServiceImpl:68","thread":"exec-8","message":"Join failed: \"a=b\"\n\tat stack.trace"}` + "\n",
+		},
+		{
+			name:   "logfmt",
+			format: Logfmt,
+			want: `schema_version=1 timestamp=2020-12-06T17:21:52.612Z server_id=local-arti node_id=node-1 log_name=access-service.log ` +
+				`service=jfac level=ERROR trace_id=7ccdb881f0258729 logger=s.r.NodeRegistryServiceImpl:68 thread=exec-8 ` +
+				`message="Join failed: \"a=b\"\n\tat stack.trace"` + "\n",
+		},
+		{
+			name:   "csv",
+			format: Csv,
+			want: `1,2020-12-06T17:21:52.612Z,local-arti,node-1,access-service.log,jfac,ERROR,7ccdb881f0258729,` +
+				`s.r.NodeRegistryServiceImpl:68,exec-8,"Join failed: ""a=b""` + "\n\tat stack.trace\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(Encode(tt.format, testOrigin, logLine)))
+		})
+	}
+}
+
+func TestEncode_continuation(t *testing.T) {
+	encoded := Encode(Ndjson, testOrigin, parser.Parse("\tat stack.trace"))
+	record := &Record{}
+	require.NoError(t, json.Unmarshal(encoded, record))
+	assert.Equal(t, "", record.Timestamp)
+	assert.Equal(t, "", record.Level)
+	assert.Equal(t, "\tat stack.trace", record.Message)
+}
+
+func TestHeader(t *testing.T) {
+	assert.Equal(t, "schema_version,timestamp,server_id,node_id,log_name,service,level,trace_id,logger,thread,message\n", string(Header(Csv)))
+	assert.Empty(t, Header(Ndjson))
+	assert.Len(t, (&Record{}).Values(), len(FieldNames))
+}
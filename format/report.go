@@ -0,0 +1,29 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Formats a time of a text report, in UTC to the second.
+func Time(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Cuts the passed text to the passed number of characters, ending it with "..." if it was cut.
+func Cut(text string, maxLength int) string {
+	if runes := []rune(text); len(runes) > maxLength {
+		return string(runes[:maxLength-3]) + "..."
+	}
+	return text
+}
+
+// Cuts the passed text to the passed number of lines, followed by a line of the number of lines that were cut, if any.
+func CutLines(text string, maxLines int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= maxLines {
+		return text
+	}
+	return strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n... %d more lines", len(lines)-maxLines)
+}
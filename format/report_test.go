@@ -0,0 +1,47 @@
+package format
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTime(t *testing.T) {
+	assert.Equal(t, "2020-12-06T17:21:52Z", Time(time.Date(2020, 12, 6, 19, 21, 52, 612000000, time.FixedZone("", 2*60*60))))
+}
+
+func TestCut(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxLength int
+		want      string
+	}{
+		{name: "short", text: "pool exhausted", maxLength: 14, want: "pool exhausted"},
+		{name: "long", text: "pool exhausted", maxLength: 10, want: "pool ex..."},
+		{name: "multi-byte characters", text: "חיבור נכשל שוב", maxLength: 8, want: "חיבור..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Cut(tt.text, tt.maxLength))
+		})
+	}
+}
+
+func TestCutLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxLines int
+		want     string
+	}{
+		{name: "single line", text: "Read timed out", maxLines: 2, want: "Read timed out"},
+		{name: "as many lines", text: "Read timed out\n\tat a.b.C", maxLines: 2, want: "Read timed out\n\tat a.b.C"},
+		{name: "more lines", text: "Read timed out\n\tat a.b.C\n\tat d.e.F\n\tat g.h.I", maxLines: 2, want: "Read timed out\n\tat a.b.C\n... 2 more lines"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CutLines(tt.text, tt.maxLines))
+		})
+	}
+}
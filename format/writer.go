@@ -0,0 +1,39 @@
+package format
+
+import (
+	"github.com/hanoch-jfrog/forest/parser"
+	"io"
+)
+
+// An io.WriteCloser that writes the entries of a single log into its output, encoded in a Format.
+// The Header of the format is not written, as the output may be shared by many Writers.
+// Closing the Writer writes the remaining partial entry, but does not close the output.
+type Writer struct {
+	entryWriter *parser.EntryWriter
+	output      io.Writer
+	format      Format
+	origin      Origin
+}
+
+func NewWriter(output io.Writer, format Format, origin Origin) *Writer {
+	w := &Writer{
+		output: output,
+		format: format,
+		origin: origin,
+	}
+	w.entryWriter = parser.NewEntryWriter(w.writeEntry)
+	return w
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.entryWriter.Write(p)
+}
+
+func (w *Writer) Close() error {
+	return w.entryWriter.Close()
+}
+
+func (w *Writer) writeEntry(logLine *parser.LogLine) error {
+	_, err := w.output.Write(Encode(w.format, w.origin, logLine))
+	return err
+}
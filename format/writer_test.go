@@ -0,0 +1,23 @@
+package format
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := NewWriter(out, Csv, Origin{NodeId: "node-1", LogName: "console.log"})
+
+	_, err := w.Write([]byte("2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first\n2020-12-06T19:21:52.550Z [jfrt ] [ERROR] - failure\n\tat one\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("2020-12-06T19:21:52.551Z [jfrt ] [WARN ] - partial"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "1,2020-12-06T19:21:52.549Z,,node-1,console.log,jfrt,INFO,,,,first\n"+
+		"1,2020-12-06T19:21:52.550Z,,node-1,console.log,jfrt,ERROR,,,,\"failure\n\tat one\"\n"+
+		"1,2020-12-06T19:21:52.551Z,,node-1,console.log,jfrt,WARN,,,,partial\n", out.String())
+}
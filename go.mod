@@ -8,6 +8,7 @@ require (
 	github.com/jfrog/jfrog-client-go v0.16.0
 	github.com/manifoldco/promptui v0.8.0
 	github.com/stretchr/testify v1.4.0
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 )
 
 replace github.com/jfrog/jfrog-cli-core => github.com/jfrog/jfrog-cli-core v1.1.2
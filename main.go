@@ -4,6 +4,7 @@ import (
 	"github.com/hanoch-jfrog/forest/commands"
 	"github.com/jfrog/jfrog-cli-core/plugins"
 	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"os"
 )
 
 func main() {
@@ -20,6 +21,18 @@ func getApp() components.App {
 }
 
 func getCommands() []components.Command {
-	return []components.Command{
-		commands.GetLogsCommand()}
+	cmds := []components.Command{
+		commands.GetLogsCommand(),
+		commands.GetCollectCommand(),
+		commands.GetTraceCommand(),
+		commands.GetStatsCommand(),
+		commands.GetAuditCommand(),
+		commands.GetErrorsCommand(),
+		commands.GetWatchCommand(),
+		commands.GetServeCommand(),
+		commands.GetProxyCommand()}
+	if os.Getenv(commands.DevCommandsEnvVar) != "" {
+		cmds = append(cmds, commands.GetFakeServerCommand())
+	}
+	return cmds
 }
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Handles a single parsed entry. Any returned error fails the write that produced the entry.
+type EntryHandler func(logLine *LogLine) error
+
+// An io.WriteCloser that splits the log data written into it into parsed entries.
+// The entry in progress is handled at the end of every write, since log data is written one complete snapshot at a time;
+// continuation lines that arrive in a later write are handled as a separate continuation entry.
+type EntryWriter struct {
+	handler     EntryHandler
+	partialLine []byte
+	entryLines  []string
+}
+
+func NewEntryWriter(handler EntryHandler) *EntryWriter {
+	return &EntryWriter{
+		handler: handler,
+	}
+}
+
+func (w *EntryWriter) Write(p []byte) (int, error) {
+	w.partialLine = append(w.partialLine, p...)
+	lines := w.partialLine
+	for {
+		lineEnd := bytes.IndexByte(lines, '\n')
+		if lineEnd < 0 {
+			break
+		}
+		if err := w.addLine(string(lines[:lineEnd])); err != nil {
+			return 0, err
+		}
+		lines = lines[lineEnd+1:]
+	}
+	w.partialLine = append(w.partialLine[:0], lines...)
+	if err := w.handleEntry(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Handles the remaining partial line, if any, and the entry in progress.
+func (w *EntryWriter) Close() error {
+	if len(w.partialLine) > 0 {
+		if err := w.addLine(string(w.partialLine)); err != nil {
+			return err
+		}
+		w.partialLine = nil
+	}
+	return w.handleEntry()
+}
+
+func (w *EntryWriter) addLine(line string) error {
+	if IsFirstLine(line) {
+		if err := w.handleEntry(); err != nil {
+			return err
+		}
+	}
+	w.entryLines = append(w.entryLines, line)
+	return nil
+}
+
+func (w *EntryWriter) handleEntry() error {
+	if len(w.entryLines) == 0 {
+		return nil
+	}
+	logLine := Parse(strings.Join(w.entryLines, "\n"))
+	w.entryLines = w.entryLines[:0]
+	return w.handler(logLine)
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestEntryWriter(t *testing.T) {
+	var raws []string
+	w := NewEntryWriter(func(logLine *LogLine) error {
+		raws = append(raws, logLine.Raw)
+		return nil
+	})
+
+	_, err := w.Write([]byte("2020-12-06T19:21:52.549Z [jfrt ] [ERROR] - first\n\tat one\n\tat two\n2020-12-06T19:21:52.550Z [jfrt ] [INFO ] - sec"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2020-12-06T19:21:52.549Z [jfrt ] [ERROR] - first\n\tat one\n\tat two"}, raws)
+
+	_, err = w.Write([]byte("ond\n\tat three\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("\tat four\n2020-12-06T19:21:52.551Z [jfrt ] [INFO ] - third"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, []string{
+		"2020-12-06T19:21:52.549Z [jfrt ] [ERROR] - first\n\tat one\n\tat two",
+		"2020-12-06T19:21:52.550Z [jfrt ] [INFO ] - second\n\tat three",
+		"\tat four",
+		"2020-12-06T19:21:52.551Z [jfrt ] [INFO ] - third",
+	}, raws)
+}
+
+func TestEntryWriter_handlerError(t *testing.T) {
+	w := NewEntryWriter(func(logLine *LogLine) error {
+		return fmt.Errorf("some-error")
+	})
+	_, err := w.Write([]byte("2020-12-06T19:21:52.549Z - first\n"))
+	assert.Error(t, err)
+}
+
+func TestScanner(t *testing.T) {
+	content := "\tat orphan\n" +
+		"2020-12-06T19:21:52.549Z [jfrt ] [ERROR] - first\n\tat one\n" +
+		"2020-12-06T19:21:52.550Z [jfrt ] [INFO ] - second\n" +
+		"2020-12-06T19:21:52.551Z [jfrt ] [INFO ] - third"
+	s := NewScanner(strings.NewReader(content))
+	var messages []string
+	for s.Scan() {
+		messages = append(messages, s.LogLine().Message)
+	}
+	require.NoError(t, s.Err())
+	assert.Equal(t, []string{"\tat orphan", "first\n\tat one", "second", "third"}, messages)
+}
@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"bytes"
+)
+
+// Handles a single line, without its new line. Any returned error fails the write that produced the line.
+type LineHandler func(line string) error
+
+// An io.WriteCloser that splits the log data written into it into lines, for logs whose entries are single lines,
+// such as the request and access logs. A trailing partial line is held until a later write completes it.
+type LineWriter struct {
+	handler     LineHandler
+	partialLine []byte
+}
+
+func NewLineWriter(handler LineHandler) *LineWriter {
+	return &LineWriter{
+		handler: handler,
+	}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.partialLine = append(w.partialLine, p...)
+	lines := w.partialLine
+	for {
+		lineEnd := bytes.IndexByte(lines, '\n')
+		if lineEnd < 0 {
+			break
+		}
+		if err := w.handler(string(bytes.TrimSuffix(lines[:lineEnd], []byte("\r")))); err != nil {
+			return 0, err
+		}
+		lines = lines[lineEnd+1:]
+	}
+	w.partialLine = append(w.partialLine[:0], lines...)
+	return len(p), nil
+}
+
+// Handles the remaining partial line, if any.
+func (w *LineWriter) Close() error {
+	if len(w.partialLine) == 0 {
+		return nil
+	}
+	line := string(bytes.TrimSuffix(w.partialLine, []byte("\r")))
+	w.partialLine = nil
+	return w.handler(line)
+}
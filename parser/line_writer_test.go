@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := NewLineWriter(func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	_, err := w.Write([]byte("first\r\nsec"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, lines)
+	_, err = w.Write([]byte("ond\n\nthi"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("rd"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, []string{"first", "second", "", "third"}, lines)
+}
+
+func TestLineWriter_handlerError(t *testing.T) {
+	w := NewLineWriter(func(string) error {
+		return fmt.Errorf("some-error")
+	})
+	_, err := w.Write([]byte("first\n"))
+	assert.Error(t, err)
+}
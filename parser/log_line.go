@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = map[Level]string{
+	LevelUnknown: "",
+	LevelTrace:   "TRACE",
+	LevelDebug:   "DEBUG",
+	LevelInfo:    "INFO",
+	LevelWarn:    "WARN",
+	LevelError:   "ERROR",
+	LevelFatal:   "FATAL",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// Parses a level name, case insensitively. "WARNING" is accepted as an alias of "WARN".
+func ParseLevel(levelName string) (Level, error) {
+	levelName = strings.ToUpper(strings.TrimSpace(levelName))
+	if levelName == "WARNING" {
+		return LevelWarn, nil
+	}
+	for level, name := range levelNames {
+		if level != LevelUnknown && name == levelName {
+			return level, nil
+		}
+	}
+	return LevelUnknown, fmt.Errorf("unknown log level [%v]", levelName)
+}
+
+// A single entry of the JFrog unified log format:
+// timestamp [service] [LEVEL] [trace_id] [class:line] [thread] - message
+// An entry spans its first line and any following lines without a leading timestamp, such as a stack trace.
+type LogLine struct {
+	Timestamp time.Time
+	// The service type, such as jfrt, jfac, jfevt or jfrou.
+	Service string
+	Level   Level
+	TraceId string
+	// The logging location, such as class:line or file.go:line.
+	Logger string
+	Thread string
+	// The message of the first line, followed by any continuation lines.
+	Message string
+	// The original text of every line of the entry, without the trailing new line.
+	Raw string
+}
+
+// Returns whether the entry has no timestamped first line,
+// meaning it continues an entry that was split apart, or is not in a timestamped format at all.
+func (l *LogLine) IsContinuation() bool {
+	return l.Timestamp.IsZero()
+}
+
+// Parses a single, possibly multi-line, entry.
+// Fields that are missing from the entry are left empty, and the original text is always kept in Raw.
+func Parse(raw string) *LogLine {
+	raw = strings.TrimSuffix(raw, "\n")
+	logLine := &LogLine{Raw: raw}
+	firstLine, continuation := raw, ""
+	if lineEnd := strings.IndexByte(raw, '\n'); lineEnd >= 0 {
+		firstLine, continuation = raw[:lineEnd], raw[lineEnd+1:]
+	}
+	firstLine = strings.TrimSuffix(firstLine, "\r")
+
+	timestamp, timestampLen, ok := parseTimestamp(firstLine)
+	if !ok {
+		logLine.Message = raw
+		return logLine
+	}
+	logLine.Timestamp = timestamp
+	rest := firstLine[timestampLen:]
+
+	var fields []string
+	for {
+		trimmed := strings.TrimLeft(rest, " ")
+		if !strings.HasPrefix(trimmed, "[") {
+			break
+		}
+		fieldEnd := strings.IndexByte(trimmed, ']')
+		if fieldEnd < 0 {
+			break
+		}
+		fields = append(fields, strings.TrimSpace(trimmed[1:fieldEnd]))
+		rest = trimmed[fieldEnd+1:]
+	}
+	for idx, field := range fields {
+		switch idx {
+		case 0:
+			logLine.Service = field
+		case 1:
+			logLine.Level, _ = ParseLevel(field)
+		case 2:
+			logLine.TraceId = field
+		case 3:
+			logLine.Logger = field
+		case 4:
+			logLine.Thread = field
+		}
+	}
+
+	rest = strings.TrimLeft(rest, " ")
+	if len(fields) > 0 {
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "-"), " ")
+	}
+	logLine.Message = rest
+	if continuation != "" {
+		logLine.Message += "\n" + continuation
+	}
+	return logLine
+}
+
+// Parses the ISO-8601 timestamp a log line starts with, up to the first space, tab, '|' or '['.
+func ParseTimestamp(line string) (time.Time, bool) {
+	timestamp, _, ok := parseTimestamp(line)
+	return timestamp, ok
+}
+
+// Returns whether the passed line starts a new entry, rather than continuing the entry preceding it.
+func IsFirstLine(line string) bool {
+	_, ok := ParseTimestamp(line)
+	return ok
+}
+
+func parseTimestamp(line string) (timestamp time.Time, timestampLen int, ok bool) {
+	if len(line) < len("2006-01-02T15:04:05Z") || line[4] != '-' || line[10] != 'T' {
+		return
+	}
+	timestampLen = strings.IndexAny(line, " \t|[\r\n")
+	if timestampLen < 0 {
+		timestampLen = len(line)
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, line[:timestampLen])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return timestamp, timestampLen, true
+}
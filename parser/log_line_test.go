@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want *LogLine
+	}{
+		{
+			name: "artifactory line",
+			raw:  "2020-12-06T19:21:52.549Z [jfac ] [INFO ] [6469d8c8e2ece130] [a.s.b.AccessServerRegistrar:73] [pool-26-thread-1    ] - [ACCESS BOOTSTRAP] JFrog Access registrar finished.",
+			want: &LogLine{
+				Timestamp: time.Date(2020, 12, 6, 19, 21, 52, 549000000, time.UTC),
+				Service:   "jfac",
+				Level:     LevelInfo,
+				TraceId:   "6469d8c8e2ece130",
+				Logger:    "a.s.b.AccessServerRegistrar:73",
+				Thread:    "pool-26-thread-1",
+				Message:   "[ACCESS BOOTSTRAP] JFrog Access registrar finished.",
+				Raw:       "2020-12-06T19:21:52.549Z [jfac ] [INFO ] [6469d8c8e2ece130] [a.s.b.AccessServerRegistrar:73] [pool-26-thread-1    ] - [ACCESS BOOTSTRAP] JFrog Access registrar finished.",
+			},
+		},
+		{
+			name: "multi-line entry",
+			raw:  "2020-12-06T19:21:52.622Z [jfrt ] [ERROR] [152a442b8f87bacc] [o.a.Some:58] [main] - failure\njava.lang.IllegalStateException: boom\n\tat org.jfrog.Some.method(Some.java:58)\n",
+			want: &LogLine{
+				Timestamp: time.Date(2020, 12, 6, 19, 21, 52, 622000000, time.UTC),
+				Service:   "jfrt",
+				Level:     LevelError,
+				TraceId:   "152a442b8f87bacc",
+				Logger:    "o.a.Some:58",
+				Thread:    "main",
+				Message:   "failure\njava.lang.IllegalStateException: boom\n\tat org.jfrog.Some.method(Some.java:58)",
+				Raw:       "2020-12-06T19:21:52.622Z [jfrt ] [ERROR] [152a442b8f87bacc] [o.a.Some:58] [main] - failure\njava.lang.IllegalStateException: boom\n\tat org.jfrog.Some.method(Some.java:58)",
+			},
+		},
+		{
+			name: "empty trace id",
+			raw:  "2020-12-06T19:21:52.622Z [jfevt] [WARN ] [                ] [access_join.go:58             ] [main                ] - Cluster join",
+			want: &LogLine{
+				Timestamp: time.Date(2020, 12, 6, 19, 21, 52, 622000000, time.UTC),
+				Service:   "jfevt",
+				Level:     LevelWarn,
+				Logger:    "access_join.go:58",
+				Thread:    "main",
+				Message:   "Cluster join",
+				Raw:       "2020-12-06T19:21:52.622Z [jfevt] [WARN ] [                ] [access_join.go:58             ] [main                ] - Cluster join",
+			},
+		},
+		{
+			name: "timestamp without fields",
+			raw:  "2020-12-06T19:21:52Z|152a442b8f87bacc|127.0.0.1|admin|GET|/api/system/ping|200|-1|0|3|",
+			want: &LogLine{
+				Timestamp: time.Date(2020, 12, 6, 19, 21, 52, 0, time.UTC),
+				Message:   "|152a442b8f87bacc|127.0.0.1|admin|GET|/api/system/ping|200|-1|0|3|",
+				Raw:       "2020-12-06T19:21:52Z|152a442b8f87bacc|127.0.0.1|admin|GET|/api/system/ping|200|-1|0|3|",
+			},
+		},
+		{
+			name: "continuation line",
+			raw:  "\tat org.jfrog.Some.method(Some.java:58)",
+			want: &LogLine{
+				Message: "\tat org.jfrog.Some.method(Some.java:58)",
+				Raw:     "\tat org.jfrog.Some.method(Some.java:58)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.raw)
+			assert.True(t, tt.want.Timestamp.Equal(got.Timestamp))
+			got.Timestamp = tt.want.Timestamp
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		levelName string
+		want      Level
+		wantErr   bool
+	}{
+		{name: "upper case", levelName: "ERROR", want: LevelError},
+		{name: "lower case", levelName: "debug", want: LevelDebug},
+		{name: "padded", levelName: "INFO ", want: LevelInfo},
+		{name: "warning alias", levelName: "warning", want: LevelWarn},
+		{name: "unknown", levelName: "loud", wantErr: true},
+		{name: "empty", levelName: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.levelName)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			name:   "unified log line",
+			line:   "2020-12-06T19:21:52.549Z [jfac ] [INFO ] - message",
+			want:   time.Date(2020, 12, 6, 19, 21, 52, 549000000, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "request log line",
+			line:   "2020-12-06T19:21:52Z|6469d8c8e2ece130|127.0.0.1|admin|GET|/api/system/ping|200|-1|0|3|",
+			want:   time.Date(2020, 12, 6, 19, 21, 52, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "timestamp only",
+			line:   "2020-12-06T19:21:52+02:00",
+			want:   time.Date(2020, 12, 6, 17, 21, 52, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name: "stack trace line",
+			line: "\tat org.jfrog.Something.method(Something.java:12)",
+		},
+		{
+			name: "empty line",
+			line: "",
+		},
+		{
+			name: "invalid timestamp",
+			line: "2020-13-06T19:21:52.549Z - message",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTimestamp(tt.line)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.True(t, tt.want.Equal(got))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+const (
+	maxScannerLineSize = 1024 * 1024
+)
+
+// Reads parsed entries out of an io.Reader, one at a time.
+// Usage follows bufio.Scanner: call Scan until it returns false, and then check Err.
+type Scanner struct {
+	lines     *bufio.Scanner
+	nextLine  *string
+	entry     *LogLine
+	err       error
+	exhausted bool
+}
+
+func NewScanner(reader io.Reader) *Scanner {
+	lines := bufio.NewScanner(reader)
+	lines.Buffer(nil, maxScannerLineSize)
+	return &Scanner{
+		lines: lines,
+	}
+}
+
+// Advances to the next entry, which is then available through LogLine.
+func (s *Scanner) Scan() bool {
+	if s.exhausted {
+		return false
+	}
+	var entryLines []string
+	if s.nextLine != nil {
+		entryLines = append(entryLines, *s.nextLine)
+		s.nextLine = nil
+	}
+	for s.lines.Scan() {
+		line := s.lines.Text()
+		if len(entryLines) > 0 && IsFirstLine(line) {
+			s.nextLine = &line
+			break
+		}
+		entryLines = append(entryLines, line)
+	}
+	if s.nextLine == nil {
+		s.exhausted = true
+		s.err = s.lines.Err()
+	}
+	if len(entryLines) == 0 {
+		return false
+	}
+	s.entry = Parse(strings.Join(entryLines, "\n"))
+	return true
+}
+
+// Returns the entry read by the last call to Scan.
+func (s *Scanner) LogLine() *LogLine {
+	return s.entry
+}
+
+// Returns the first non-EOF error encountered while reading.
+func (s *Scanner) Err() error {
+	return s.err
+}
@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// A single access through the proxy, written as a line of JSON into the audit log.
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remote_addr"`
+	// Empty when the request was not authenticated.
+	User     string `json:"user,omitempty"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	ServerId string `json:"server_id,omitempty"`
+	NodeId   string `json:"node_id,omitempty"`
+	LogName  string `json:"log_name,omitempty"`
+	Status   int    `json:"status"`
+	Allowed  bool   `json:"allowed"`
+	// Why access was denied or failed, empty when it succeeded.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Writes AuditRecords into an io.Writer, one per line, safely for concurrent use.
+type auditLog struct {
+	mu     sync.Mutex
+	output io.Writer
+}
+
+func (a *auditLog) write(record *AuditRecord) error {
+	// marshalling plain fields cannot fail
+	encoded, _ := json.Marshal(record)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err := a.output.Write(append(encoded, '\n'))
+	return err
+}
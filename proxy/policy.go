@@ -0,0 +1,202 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const wildcard = "*"
+
+// Which users may read which logs through the proxy, and how users are authenticated.
+// Access is denied unless a rule allows it.
+type Policy struct {
+	// Authenticates requests that carry one of the tokens in a bearer Authorization header.
+	Tokens []Token `json:"tokens,omitempty"`
+	// Authenticates requests by headers set by a trusted authenticating proxy in front of this one.
+	TrustedHeader *TrustedHeader `json:"trusted_header,omitempty"`
+	Rules         []Rule         `json:"rules"`
+}
+
+// A static token of a user. Exactly one of Token and TokenSha256 must be set.
+type Token struct {
+	Token string `json:"token,omitempty"`
+	// The hex encoded SHA-256 digest of the token, so that the policy file does not hold the token itself.
+	TokenSha256 string   `json:"token_sha256,omitempty"`
+	User        string   `json:"user"`
+	Groups      []string `json:"groups,omitempty"`
+
+	digest []byte
+}
+
+// The headers a trusted authenticating proxy sets to the authenticated user, and optionally to its comma-separated groups.
+// NOTE: the proxy in front must always set or remove these headers, otherwise any client may set them.
+type TrustedHeader struct {
+	UserHeader   string `json:"user_header"`
+	GroupsHeader string `json:"groups_header,omitempty"`
+}
+
+// Allows the listed users, and the members of the listed groups, to read the logs matching Logs on the servers matching Servers.
+// Servers and Logs are glob patterns, such as "*-request.log"; a "*" user or group matches every authenticated user.
+type Rule struct {
+	Users   []string `json:"users,omitempty"`
+	Groups  []string `json:"groups,omitempty"`
+	Servers []string `json:"servers"`
+	Logs    []string `json:"logs"`
+}
+
+// An authenticated user.
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// Reads and validates the JSON policy file of the passed path. Unknown fields are rejected, to catch typos.
+func LoadPolicy(policyPath string) (*Policy, error) {
+	content, err := ioutil.ReadFile(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	policy := &Policy{}
+	if err = decoder.Decode(policy); err != nil {
+		return nil, fmt.Errorf("invalid policy file [%v]: %w", policyPath, err)
+	}
+	if err = policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy file [%v]: %w", policyPath, err)
+	}
+	return policy, nil
+}
+
+// Validates the policy, and prepares its tokens for authentication. Must be called before the policy is used.
+func (p *Policy) Validate() error {
+	if len(p.Tokens) == 0 && p.TrustedHeader == nil {
+		return errors.New("no authentication is configured, set tokens or a trusted header")
+	}
+	for idx := range p.Tokens {
+		token := &p.Tokens[idx]
+		if token.User == "" {
+			return fmt.Errorf("token %d has no user", idx+1)
+		}
+		switch {
+		case token.Token != "" && token.TokenSha256 != "":
+			return fmt.Errorf("token of user [%v] sets both a token and its digest", token.User)
+		case token.Token != "":
+			digest := sha256.Sum256([]byte(token.Token))
+			token.digest = digest[:]
+		default:
+			digest, err := hex.DecodeString(token.TokenSha256)
+			if err != nil || len(digest) != sha256.Size {
+				return fmt.Errorf("token of user [%v] has an invalid digest, expected a hex encoded SHA-256 digest", token.User)
+			}
+			token.digest = digest
+		}
+	}
+	if p.TrustedHeader != nil && p.TrustedHeader.UserHeader == "" {
+		return errors.New("the trusted header has no user header")
+	}
+	for idx, rule := range p.Rules {
+		if len(rule.Users) == 0 && len(rule.Groups) == 0 {
+			return fmt.Errorf("rule %d has no users or groups", idx+1)
+		}
+		if len(rule.Servers) == 0 || len(rule.Logs) == 0 {
+			return fmt.Errorf("rule %d has no servers or logs", idx+1)
+		}
+		for _, pattern := range append(append([]string{}, rule.Servers...), rule.Logs...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %d has an invalid pattern [%v]", idx+1, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the user of the passed request, by its bearer token, or else by the trusted header.
+// A request with an Authorization header is authenticated by it alone.
+func (p *Policy) Authenticate(r *http.Request) (*Identity, error) {
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		const bearerPrefix = "Bearer "
+		if !strings.HasPrefix(authorization, bearerPrefix) {
+			return nil, errors.New("unsupported authorization scheme, expected a bearer token")
+		}
+		digest := sha256.Sum256([]byte(strings.TrimPrefix(authorization, bearerPrefix)))
+		for _, token := range p.Tokens {
+			if subtle.ConstantTimeCompare(digest[:], token.digest) == 1 {
+				return &Identity{User: token.User, Groups: token.Groups}, nil
+			}
+		}
+		return nil, errors.New("unknown token")
+	}
+	if p.TrustedHeader != nil {
+		if user := strings.TrimSpace(r.Header.Get(p.TrustedHeader.UserHeader)); user != "" {
+			identity := &Identity{User: user}
+			if p.TrustedHeader.GroupsHeader != "" {
+				for _, group := range strings.Split(r.Header.Get(p.TrustedHeader.GroupsHeader), ",") {
+					if group = strings.TrimSpace(group); group != "" {
+						identity.Groups = append(identity.Groups, group)
+					}
+				}
+			}
+			return identity, nil
+		}
+	}
+	return nil, errors.New("missing credentials")
+}
+
+// Returns whether any rule allows the passed user to read any log of the passed server.
+func (p *Policy) CanReadServer(identity *Identity, serverId string) bool {
+	for _, rule := range p.Rules {
+		if rule.appliesTo(identity) && matchesAny(rule.Servers, serverId) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether any rule allows the passed user to read the passed log of the passed server.
+func (p *Policy) CanReadLog(identity *Identity, serverId, logName string) bool {
+	for _, rule := range p.Rules {
+		if rule.appliesTo(identity) && matchesAny(rule.Servers, serverId) && matchesAny(rule.Logs, logName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) appliesTo(identity *Identity) bool {
+	for _, user := range r.Users {
+		if user == wildcard || user == identity.User {
+			return true
+		}
+	}
+	for _, group := range r.Groups {
+		if group == wildcard {
+			return true
+		}
+		for _, identityGroup := range identity.Groups {
+			if group == identityGroup {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		// patterns were validated when the policy was loaded
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
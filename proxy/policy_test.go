@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePolicy(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "policy")
+	require.NoError(t, err)
+	policyPath := filepath.Join(dir, "policy.json")
+	require.NoError(t, ioutil.WriteFile(policyPath, []byte(content), 0600))
+	return policyPath, func() {
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: `{"tokens": [{"token": "secret", "user": "alice"}], "rules": [{"users": ["alice"], "servers": ["*"], "logs": ["console.log"]}]}`,
+		},
+		{
+			name:    "unknown field",
+			content: `{"tokens": [{"token": "secret", "user": "alice"}], "rulez": []}`,
+			wantErr: "unknown field",
+		},
+		{
+			name:    "no authentication",
+			content: `{"rules": []}`,
+			wantErr: "no authentication is configured",
+		},
+		{
+			name:    "token without user",
+			content: `{"tokens": [{"token": "secret"}]}`,
+			wantErr: "token 1 has no user",
+		},
+		{
+			name:    "token and digest",
+			content: `{"tokens": [{"token": "secret", "token_sha256": "00", "user": "alice"}]}`,
+			wantErr: "sets both a token and its digest",
+		},
+		{
+			name:    "invalid digest",
+			content: `{"tokens": [{"token_sha256": "abcd", "user": "alice"}]}`,
+			wantErr: "invalid digest",
+		},
+		{
+			name:    "rule without users",
+			content: `{"trusted_header": {"user_header": "X-User"}, "rules": [{"servers": ["*"], "logs": ["*"]}]}`,
+			wantErr: "rule 1 has no users or groups",
+		},
+		{
+			name:    "rule without logs",
+			content: `{"trusted_header": {"user_header": "X-User"}, "rules": [{"groups": ["dev"], "servers": ["*"]}]}`,
+			wantErr: "rule 1 has no servers or logs",
+		},
+		{
+			name:    "invalid pattern",
+			content: `{"trusted_header": {"user_header": "X-User"}, "rules": [{"groups": ["dev"], "servers": ["*"], "logs": ["[a-"]}]}`,
+			wantErr: "rule 1 has an invalid pattern [[a-]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policyPath, cleanup := writePolicy(t, tt.content)
+			defer cleanup()
+			policy, err := LoadPolicy(policyPath)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, policy.Rules, 1)
+		})
+	}
+}
+
+func newTestPolicy(t *testing.T) *Policy {
+	digest := sha256.Sum256([]byte("bob-secret"))
+	policy := &Policy{
+		Tokens: []Token{
+			{Token: "alice-secret", User: "alice"},
+			{TokenSha256: hex.EncodeToString(digest[:]), User: "bob", Groups: []string{"dev"}},
+		},
+		TrustedHeader: &TrustedHeader{UserHeader: "X-Forwarded-User", GroupsHeader: "X-Forwarded-Groups"},
+		Rules: []Rule{
+			{Users: []string{"alice"}, Servers: []string{"*"}, Logs: []string{"*"}},
+			{Groups: []string{"dev"}, Servers: []string{"local-*"}, Logs: []string{"console.log", "*-request.log"}},
+		},
+	}
+	require.NoError(t, policy.Validate())
+	return policy
+}
+
+func TestPolicy_Authenticate(t *testing.T) {
+	policy := newTestPolicy(t)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    *Identity
+		wantErr string
+	}{
+		{"token", map[string]string{"Authorization": "Bearer alice-secret"}, &Identity{User: "alice"}, ""},
+		{"token digest", map[string]string{"Authorization": "Bearer bob-secret"}, &Identity{User: "bob", Groups: []string{"dev"}}, ""},
+		{"unknown token", map[string]string{"Authorization": "Bearer guess"}, nil, "unknown token"},
+		{"basic authentication", map[string]string{"Authorization": "Basic YWxpY2U6c2VjcmV0"}, nil, "unsupported authorization scheme"},
+		{"unknown token with trusted header", map[string]string{"Authorization": "Bearer guess", "X-Forwarded-User": "alice"}, nil, "unknown token"},
+		{"trusted header", map[string]string{"X-Forwarded-User": "carol", "X-Forwarded-Groups": "dev, ops,"}, &Identity{User: "carol", Groups: []string{"dev", "ops"}}, ""},
+		{"no credentials", map[string]string{}, nil, "missing credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "http://proxy/", nil)
+			require.NoError(t, err)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			got, err := policy.Authenticate(req)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPolicy_CanReadLog(t *testing.T) {
+	policy := newTestPolicy(t)
+	alice := &Identity{User: "alice"}
+	developer := &Identity{User: "carol", Groups: []string{"dev"}}
+	stranger := &Identity{User: "mallory"}
+
+	assert.True(t, policy.CanReadLog(alice, "remote-arti", "access-audit.log"))
+	assert.True(t, policy.CanReadLog(developer, "local-arti", "console.log"))
+	assert.True(t, policy.CanReadLog(developer, "local-arti", "artifactory-request.log"))
+	assert.False(t, policy.CanReadLog(developer, "local-arti", "access-audit.log"))
+	assert.False(t, policy.CanReadLog(developer, "remote-arti", "console.log"))
+	assert.False(t, policy.CanReadLog(stranger, "local-arti", "console.log"))
+
+	assert.True(t, policy.CanReadServer(developer, "local-arti"))
+	assert.False(t, policy.CanReadServer(developer, "remote-arti"))
+	assert.False(t, policy.CanReadServer(stranger, "local-arti"))
+}
@@ -0,0 +1,351 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/constants"
+	"github.com/hanoch-jfrog/forest/client/livelog/model"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/util"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// The path of the nodes endpoint under the base path of every server, as sent by both the Artifactory and the standalone strategies.
+	nodesEndpoint = "api/system/nodes"
+	// How long the node ids of a server, and the log names of a node, are kept before they are queried again.
+	namesTtl = time.Minute
+)
+
+// The JFrog CLI servers the proxy reads logs from, using their admin credentials.
+type Servers interface {
+	// Returns the ids of the configured servers.
+	ServerIds() ([]string, error)
+	// Returns the strategy.Http that sends requests to the server of the passed id.
+	HttpStrategy(serverId string) (strategy.Http, error)
+}
+
+// An http.Handler serving the read-only live log endpoints of every server under its own base path, http://<proxy>/<server_id>/,
+// so that any livelog client can read logs through it, without holding admin credentials.
+// Every request is authenticated and authorized by a Policy, and written into the audit log.
+// The config endpoint lists only the logs the user may read, and log data is only read of the log names the node lists.
+// Log data is read through a livelog.Hub, so that users following the same log share a single poller of the server.
+type Proxy struct {
+	policy   *Policy
+	servers  Servers
+	auditLog *auditLog
+	hub      *livelog.Hub
+
+	mu         sync.Mutex
+	strategies map[string]strategy.Http
+	// by server id, of the configured servers only
+	nodeIds map[string]*queriedNames
+	// of the node ids that were found in nodeIds only, and removed once expired
+	logNames map[nodeKey]*queriedNames
+}
+
+type nodeKey struct {
+	serverId string
+	nodeId   string
+}
+
+// The node ids of a server, or the log names of a node, as last queried from the server.
+type queriedNames struct {
+	names     []string
+	queriedAt time.Time
+}
+
+func (q *queriedNames) expired(now time.Time) bool {
+	return now.Sub(q.queriedAt) >= namesTtl
+}
+
+// Creates a Proxy of the passed servers, enforcing the passed validated Policy.
+func NewProxy(policy *Policy, servers Servers) *Proxy {
+	p := &Proxy{
+		policy:     policy,
+		servers:    servers,
+		auditLog:   &auditLog{output: ioutil.Discard},
+		strategies: make(map[string]strategy.Http),
+		nodeIds:    make(map[string]*queriedNames),
+		logNames:   make(map[nodeKey]*queriedNames),
+	}
+	p.hub = livelog.NewHub(func(key livelog.HubKey) (livelog.Client, error) {
+		httpStrategy, err := p.httpStrategy(key.ServerId)
+		if err != nil {
+			return nil, err
+		}
+		return livelog.NewClient(httpStrategy), nil
+	})
+	return p
+}
+
+// Sets the io.Writer that an AuditRecord of every request is written into. Records are discarded by default.
+func (p *Proxy) SetAuditOutput(auditOutput io.Writer) {
+	p.auditLog = &auditLog{output: auditOutput}
+}
+
+// Stops polling the logs read through the proxy.
+func (p *Proxy) Close() {
+	p.hub.Close()
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	record := &AuditRecord{
+		Time:       time.Now().UTC(),
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		NodeId:     r.Header.Get(constants.NodeIdHeader),
+	}
+	p.serve(w, r, record)
+	// a request that cannot be audited was already served, so a failing audit log can only fail the following ones
+	_ = p.auditLog.write(record)
+}
+
+func (p *Proxy) serve(w http.ResponseWriter, r *http.Request, record *AuditRecord) {
+	if r.Method != http.MethodGet {
+		deny(w, record, http.StatusMethodNotAllowed, "only GET requests are supported")
+		return
+	}
+	identity, err := p.policy.Authenticate(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="forest"`)
+		deny(w, record, http.StatusUnauthorized, err.Error())
+		return
+	}
+	record.User = identity.User
+
+	pathParts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if len(pathParts) != 2 {
+		deny(w, record, http.StatusNotFound, "not found")
+		return
+	}
+	serverId, endpoint := pathParts[0], pathParts[1]
+	record.ServerId = serverId
+	// authorized before the server is looked up, so that users cannot probe for servers they may not read
+	if !p.policy.CanReadServer(identity, serverId) {
+		deny(w, record, http.StatusForbidden, "no rule allows reading the logs of this server")
+		return
+	}
+	httpStrategy, err := p.httpStrategy(serverId)
+	if err != nil {
+		fail(w, record, http.StatusNotFound, err)
+		return
+	}
+
+	switch endpoint {
+	case nodesEndpoint:
+		p.forward(w, r, record, httpStrategy, httpStrategy.NodesEndpoint())
+	case constants.ConfigEndpoint:
+		p.serveConfig(w, r, record, identity, httpStrategy)
+	case constants.DataEndpoint:
+		p.serveData(w, r, record, identity, httpStrategy)
+	default:
+		deny(w, record, http.StatusNotFound, "not found")
+	}
+}
+
+func (p *Proxy) serveConfig(w http.ResponseWriter, r *http.Request, record *AuditRecord, identity *Identity, httpStrategy strategy.Http) {
+	if !p.checkNodeId(w, r, record, httpStrategy) {
+		return
+	}
+	srvConfig, err := p.queryConfig(r.Context(), httpStrategy, record.ServerId, record.NodeId)
+	if err != nil {
+		fail(w, record, http.StatusBadGateway, err)
+		return
+	}
+	var readableLogNames []string
+	for _, logName := range srvConfig.LogFileNames {
+		if p.policy.CanReadLog(identity, record.ServerId, logName) {
+			readableLogNames = append(readableLogNames, logName)
+		}
+	}
+	srvConfig.LogFileNames = readableLogNames
+	// marshalling a config cannot fail
+	resBody, _ := json.Marshal(srvConfig)
+	respond(w, record, resBody)
+}
+
+// Denies the request unless its node id is one of the nodes of the server,
+// so that log names are only queried and kept of the nodes the server has.
+func (p *Proxy) checkNodeId(w http.ResponseWriter, r *http.Request, record *AuditRecord, httpStrategy strategy.Http) bool {
+	if record.NodeId == "" {
+		deny(w, record, http.StatusBadRequest, "missing node id")
+		return false
+	}
+	nodeIds, err := p.serverNodeIds(r.Context(), httpStrategy, record.ServerId)
+	if err != nil {
+		fail(w, record, http.StatusBadGateway, err)
+		return false
+	}
+	if !util.InSlice(nodeIds, record.NodeId) {
+		deny(w, record, http.StatusNotFound, "node id not found")
+		return false
+	}
+	return true
+}
+
+// Returns the node ids of the passed server, querying them unless they were queried within the last namesTtl.
+func (p *Proxy) serverNodeIds(ctx context.Context, httpStrategy strategy.Http, serverId string) ([]string, error) {
+	p.mu.Lock()
+	cached, ok := p.nodeIds[serverId]
+	p.mu.Unlock()
+	if ok && !cached.expired(time.Now()) {
+		return cached.names, nil
+	}
+	resBody, err := httpStrategy.SendGet(ctx, httpStrategy.NodesEndpoint(), "")
+	if err != nil {
+		return nil, err
+	}
+	serviceNodes := model.ServiceNodes{}
+	if err = json.Unmarshal(resBody, &serviceNodes); err != nil {
+		return nil, err
+	}
+	nodeIds := make([]string, len(serviceNodes.Nodes))
+	for idx, serviceNode := range serviceNodes.Nodes {
+		nodeIds[idx] = serviceNode.NodeId
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nodeIds[serverId] = &queriedNames{names: nodeIds, queriedAt: time.Now()}
+	return nodeIds, nil
+}
+
+// Queries the config of the passed node, keeping its log names, and removing the expired log names of every node.
+func (p *Proxy) queryConfig(ctx context.Context, httpStrategy strategy.Http, serverId, nodeId string) (*model.Config, error) {
+	resBody, err := httpStrategy.SendGet(ctx, constants.ConfigEndpoint, nodeId)
+	if err != nil {
+		return nil, err
+	}
+	srvConfig := &model.Config{}
+	if err = json.Unmarshal(resBody, srvConfig); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, cached := range p.logNames {
+		if cached.expired(now) {
+			delete(p.logNames, key)
+		}
+	}
+	p.logNames[nodeKey{serverId: serverId, nodeId: nodeId}] = &queriedNames{names: srvConfig.LogFileNames, queriedAt: now}
+	return srvConfig, nil
+}
+
+// Returns the log names of the passed node, querying them unless they were queried within the last namesTtl.
+func (p *Proxy) nodeLogNames(ctx context.Context, httpStrategy strategy.Http, serverId, nodeId string) ([]string, error) {
+	p.mu.Lock()
+	cached, ok := p.logNames[nodeKey{serverId: serverId, nodeId: nodeId}]
+	p.mu.Unlock()
+	if ok && !cached.expired(time.Now()) {
+		return cached.names, nil
+	}
+	srvConfig, err := p.queryConfig(ctx, httpStrategy, serverId, nodeId)
+	if err != nil {
+		return nil, err
+	}
+	return srvConfig.LogFileNames, nil
+}
+
+func (p *Proxy) serveData(w http.ResponseWriter, r *http.Request, record *AuditRecord, identity *Identity, httpStrategy strategy.Http) {
+	query := r.URL.Query()
+	record.LogName = query.Get("id")
+	if record.LogName == "" {
+		deny(w, record, http.StatusBadRequest, "missing log name")
+		return
+	}
+	pageMarker, err := strconv.ParseInt(query.Get("$file_size"), 10, 64)
+	if err != nil || pageMarker < 0 {
+		deny(w, record, http.StatusBadRequest, "invalid page marker")
+		return
+	}
+	// authorized before the node and its log names are looked up, so that users cannot probe for logs they may not read
+	if !p.policy.CanReadLog(identity, record.ServerId, record.LogName) {
+		deny(w, record, http.StatusForbidden, "no rule allows reading this log")
+		return
+	}
+	if !p.checkNodeId(w, r, record, httpStrategy) {
+		return
+	}
+	// the log name is matched by the globs of the policy, so only the exact log names of the node are read,
+	// rather than anything else a log name might be made to mean to the server
+	logNames, err := p.nodeLogNames(r.Context(), httpStrategy, record.ServerId, record.NodeId)
+	if err != nil {
+		fail(w, record, http.StatusBadGateway, err)
+		return
+	}
+	if !util.InSlice(logNames, record.LogName) {
+		deny(w, record, http.StatusNotFound, "log name not found")
+		return
+	}
+	content := &strings.Builder{}
+	key := livelog.HubKey{ServerId: record.ServerId, NodeId: record.NodeId, LogName: record.LogName}
+	newPageMarker, err := p.hub.ReadLog(r.Context(), key, pageMarker, content)
+	if err != nil {
+		fail(w, record, http.StatusBadGateway, err)
+		return
+	}
+	// marshalling log data cannot fail
+	resBody, _ := json.Marshal(model.Data{Content: content.String(), PageMarker: newPageMarker})
+	respond(w, record, resBody)
+}
+
+// Sends the passed endpoint to the server, and responds with the server's response.
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request, record *AuditRecord, httpStrategy strategy.Http, endpoint string) {
+	resBody, err := httpStrategy.SendGet(r.Context(), endpoint, record.NodeId)
+	if err != nil {
+		fail(w, record, http.StatusBadGateway, err)
+		return
+	}
+	respond(w, record, resBody)
+}
+
+// Returns the strategy.Http of the passed server, which must exist.
+func (p *Proxy) httpStrategy(serverId string) (strategy.Http, error) {
+	serverIds, err := p.servers.ServerIds()
+	if err != nil {
+		return nil, err
+	}
+	if !util.InSlice(serverIds, serverId) {
+		return nil, fmt.Errorf("server id not found [%v]", serverId)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	httpStrategy, ok := p.strategies[serverId]
+	if !ok {
+		if httpStrategy, err = p.servers.HttpStrategy(serverId); err != nil {
+			return nil, err
+		}
+		p.strategies[serverId] = httpStrategy
+	}
+	return httpStrategy, nil
+}
+
+func respond(w http.ResponseWriter, record *AuditRecord, resBody []byte) {
+	record.Status = http.StatusOK
+	record.Allowed = true
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resBody)
+}
+
+// Responds to a request that was denied access.
+func deny(w http.ResponseWriter, record *AuditRecord, statusCode int, reason string) {
+	record.Status = statusCode
+	record.Reason = reason
+	http.Error(w, reason, statusCode)
+}
+
+// Responds to a request that was allowed access, but could not be served.
+func fail(w http.ResponseWriter, record *AuditRecord, statusCode int, err error) {
+	record.Allowed = true
+	deny(w, record, statusCode, err.Error())
+}
@@ -0,0 +1,239 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/constants"
+	"github.com/hanoch-jfrog/forest/client/livelog/fakeserver"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+type testServers struct {
+	httpStrategy strategy.Http
+}
+
+func (s *testServers) ServerIds() ([]string, error) {
+	return []string{"local-arti", "remote-arti"}, nil
+}
+
+func (s *testServers) HttpStrategy(string) (strategy.Http, error) {
+	return s.httpStrategy, nil
+}
+
+// Starts a Proxy of a fake server that holds console.log and access-audit.log of node-1, whose audit log is written into the returned buffer.
+func startProxy(t *testing.T) (*httptest.Server, *bytes.Buffer, func()) {
+	logsDir, err := ioutil.TempDir("", "proxy")
+	require.NoError(t, err)
+	fakeServer := fakeserver.New(fakeserver.Config{LogsDir: logsDir})
+	require.NoError(t, fakeServer.AppendLog("node-1", "console.log", "console line\n"))
+	require.NoError(t, fakeServer.AppendLog("node-1", "access-audit.log", "audit line\n"))
+	fakeHttpServer := httptest.NewServer(fakeServer)
+	httpStrategy, err := strategy.NewStandaloneHttpStrategy(strategy.StandaloneHttpConfig{BaseUrl: fakeHttpServer.URL + "/artifactory/"})
+	require.NoError(t, err)
+
+	proxy := NewProxy(newTestPolicy(t), &testServers{httpStrategy: httpStrategy})
+	auditOutput := &bytes.Buffer{}
+	proxy.SetAuditOutput(auditOutput)
+	proxyServer := httptest.NewServer(proxy)
+	return proxyServer, auditOutput, func() {
+		proxyServer.Close()
+		proxy.Close()
+		fakeHttpServer.Close()
+		_ = os.RemoveAll(logsDir)
+	}
+}
+
+// Returns a livelog.Client that reads the passed server through the passed proxy, authenticated by the passed token.
+func newProxiedClient(t *testing.T, proxyServer *httptest.Server, serverId, token string) livelog.Client {
+	httpStrategy, err := strategy.NewStandaloneHttpStrategy(strategy.StandaloneHttpConfig{BaseUrl: proxyServer.URL + "/" + serverId + "/", AccessToken: token})
+	require.NoError(t, err)
+	return livelog.NewClient(httpStrategy)
+}
+
+func readAuditLog(t *testing.T, auditOutput *bytes.Buffer) []AuditRecord {
+	var records []AuditRecord
+	scanner := bufio.NewScanner(auditOutput)
+	for scanner.Scan() {
+		var record AuditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestProxy(t *testing.T) {
+	proxyServer, auditOutput, stop := startProxy(t)
+	defer stop()
+	ctx := context.Background()
+
+	// bob may read only console.log of local-arti
+	client := newProxiedClient(t, proxyServer, "local-arti", "bob-secret")
+	nodeIds, err := client.GetServiceNodeIds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-1"}, nodeIds)
+	client.SetNodeId("node-1")
+	srvConfig, err := client.GetConfig(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"console.log"}, srvConfig.LogFileNames)
+
+	client.SetLogFileName("console.log")
+	output := &bytes.Buffer{}
+	require.NoError(t, client.CatLog(ctx, output))
+	assert.Equal(t, "console line\n", output.String())
+
+	client.SetLogFileName("access-audit.log")
+	err = client.CatLog(ctx, output)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status code: 403")
+
+	_, err = newProxiedClient(t, proxyServer, "remote-arti", "bob-secret").GetServiceNodeIds(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status code: 403")
+
+	records := readAuditLog(t, auditOutput)
+	require.Len(t, records, 5)
+	assert.Equal(t, "bob", records[0].User)
+	assert.Equal(t, "local-arti", records[0].ServerId)
+	assert.True(t, records[0].Allowed)
+	assert.Equal(t, "node-1", records[1].NodeId)
+	assert.Equal(t, "console.log", records[2].LogName)
+	assert.Equal(t, http.StatusOK, records[2].Status)
+	assert.Equal(t, "access-audit.log", records[3].LogName)
+	assert.False(t, records[3].Allowed)
+	assert.Equal(t, http.StatusForbidden, records[3].Status)
+	assert.Equal(t, "no rule allows reading this log", records[3].Reason)
+	assert.Equal(t, "remote-arti", records[4].ServerId)
+	assert.False(t, records[4].Allowed)
+}
+
+func TestProxy_denied(t *testing.T) {
+	proxyServer, auditOutput, stop := startProxy(t)
+	defer stop()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		headers    map[string]string
+		wantStatus int
+		wantReason string
+	}{
+		{"no credentials", http.MethodGet, "/local-arti/api/system/nodes", nil, http.StatusUnauthorized, "missing credentials"},
+		{"unknown token", http.MethodGet, "/local-arti/api/system/nodes", map[string]string{"Authorization": "Bearer guess"}, http.StatusUnauthorized, "unknown token"},
+		{"write", http.MethodPost, "/local-arti/api/system/nodes", map[string]string{"Authorization": "Bearer alice-secret"}, http.StatusMethodNotAllowed, "only GET requests are supported"},
+		{"unknown endpoint", http.MethodGet, "/local-arti/api/security/users", map[string]string{"Authorization": "Bearer alice-secret"}, http.StatusNotFound, "not found"},
+		{"unknown server", http.MethodGet, "/other-arti/api/system/nodes", map[string]string{"Authorization": "Bearer alice-secret"}, http.StatusNotFound, "server id not found [other-arti]"},
+		{"missing log name", http.MethodGet, "/local-arti/api/v1/system/logs/data?$file_size=0", map[string]string{"Authorization": "Bearer alice-secret"}, http.StatusBadRequest, "missing log name"},
+		{"invalid page marker", http.MethodGet, "/local-arti/api/v1/system/logs/data?$file_size=-1&id=console.log", map[string]string{"Authorization": "Bearer alice-secret"}, http.StatusBadRequest, "invalid page marker"},
+		{"trusted header", http.MethodGet, "/local-arti/api/v1/system/logs/data?$file_size=0&id=access-audit.log", map[string]string{"X-Forwarded-User": "carol", "X-Forwarded-Groups": "dev", constants.NodeIdHeader: "node-1"}, http.StatusForbidden, "no rule allows reading this log"},
+		{"unreadable unknown log", http.MethodGet, "/local-arti/api/v1/system/logs/data?$file_size=0&id=secret.log", map[string]string{"Authorization": "Bearer bob-secret", constants.NodeIdHeader: "node-1"}, http.StatusForbidden, "no rule allows reading this log"},
+		{"unknown node", http.MethodGet, "/local-arti/api/v1/system/logs/data?$file_size=0&id=console.log", map[string]string{"Authorization": "Bearer alice-secret", constants.NodeIdHeader: "node-2"}, http.StatusNotFound, "node id not found"},
+		{"config of unknown node", http.MethodGet, "/local-arti/api/v1/system/logs/config", map[string]string{"Authorization": "Bearer alice-secret", constants.NodeIdHeader: "node-2"}, http.StatusNotFound, "node id not found"},
+		{"config without node", http.MethodGet, "/local-arti/api/v1/system/logs/config", map[string]string{"Authorization": "Bearer alice-secret"}, http.StatusBadRequest, "missing node id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, proxyServer.URL+tt.path, nil)
+			require.NoError(t, err)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			_ = res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+
+			records := readAuditLog(t, auditOutput)
+			require.Len(t, records, 1)
+			assert.Equal(t, tt.wantStatus, records[0].Status)
+			assert.Equal(t, tt.wantReason, records[0].Reason)
+		})
+	}
+}
+
+func TestProxy_craftedLogNames(t *testing.T) {
+	proxyServer, auditOutput, stop := startProxy(t)
+	defer stop()
+	ctx := context.Background()
+
+	// bob may read console.log and *-request.log, whose glob also matches names that smuggle parameters of their own
+	tests := []struct {
+		name    string
+		logName string
+	}{
+		{"ampersand and equals", "access-audit.log&x=-request.log"},
+		{"hash", "access-audit.log#-request.log"},
+		{"percent", "access-audit.log%26x%3D-request.log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newProxiedClient(t, proxyServer, "local-arti", "bob-secret")
+			client.SetNodeId("node-1")
+			client.SetLogFileName(tt.logName)
+			output := &bytes.Buffer{}
+			err := client.CatLog(ctx, output)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "status code: 404")
+			assert.Empty(t, output.String())
+
+			records := readAuditLog(t, auditOutput)
+			require.Len(t, records, 1)
+			assert.Equal(t, tt.logName, records[0].LogName)
+			assert.Equal(t, "log name not found", records[0].Reason)
+		})
+	}
+
+	// raw queries of requests that are not sent by a livelog client, where only the first id is read
+	rawQueries := map[string]int{
+		"id=access-audit.log%26x%3D-request.log": http.StatusNotFound,
+		"id=access-audit.log%23-request.log":     http.StatusNotFound,
+		"id=access-audit.log&id=x-request.log":   http.StatusForbidden,
+	}
+	for rawQuery, wantStatus := range rawQueries {
+		req, err := http.NewRequest(http.MethodGet, proxyServer.URL+"/local-arti/api/v1/system/logs/data?$file_size=0&"+rawQuery, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer bob-secret")
+		req.Header.Set(constants.NodeIdHeader, "node-1")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(res.Body)
+		_ = res.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, wantStatus, res.StatusCode, rawQuery)
+		assert.NotContains(t, string(body), "audit line")
+		auditOutput.Reset()
+	}
+}
+
+func TestProxy_logNamesExpire(t *testing.T) {
+	logsDir, err := ioutil.TempDir("", "proxy")
+	require.NoError(t, err)
+	defer os.RemoveAll(logsDir)
+	fakeServer := fakeserver.New(fakeserver.Config{LogsDir: logsDir})
+	require.NoError(t, fakeServer.AppendLog("node-1", "console.log", "console line\n"))
+	fakeHttpServer := httptest.NewServer(fakeServer)
+	defer fakeHttpServer.Close()
+	httpStrategy, err := strategy.NewStandaloneHttpStrategy(strategy.StandaloneHttpConfig{BaseUrl: fakeHttpServer.URL + "/artifactory/"})
+	require.NoError(t, err)
+	proxy := NewProxy(newTestPolicy(t), &testServers{httpStrategy: httpStrategy})
+	defer proxy.Close()
+
+	expiredKey := nodeKey{serverId: "local-arti", nodeId: "node-gone"}
+	proxy.logNames[expiredKey] = &queriedNames{names: []string{"console.log"}, queriedAt: time.Now().Add(-namesTtl)}
+	logNames, err := proxy.nodeLogNames(context.Background(), httpStrategy, "local-arti", "node-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"console.log"}, logNames)
+	assert.NotContains(t, proxy.logNames, expiredKey)
+	assert.Contains(t, proxy.logNames, nodeKey{serverId: "local-arti", nodeId: "node-1"})
+}
@@ -0,0 +1,281 @@
+package stats
+
+import (
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/parser"
+	"io"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Aggregates the requests of the request logs of many sources.
+// It is safe for concurrent use, so that a Summary can be taken while requests are added.
+type Report struct {
+	mu           sync.Mutex
+	requests     int64
+	unparsed     int64
+	from         time.Time
+	to           time.Time
+	bytesServed  int64
+	endpoints    map[string]*latencies
+	repositories map[string]*latencies
+	statuses     map[int]int64
+	users        map[string]int64
+	addresses    map[string]int64
+}
+
+// Durations of less than 2^exactBucketsPower milliseconds have a histogram bucket of their own,
+// while every following power of two of milliseconds is split into 2^subBucketsPower buckets,
+// so that the durations of a bucket differ by less than 1/32 of them.
+const (
+	exactBucketsPower = 7
+	subBucketsPower   = 5
+	exactBuckets      = 1 << exactBucketsPower
+	subBuckets        = 1 << subBucketsPower
+)
+
+// The duration histogram and the bytes served of the requests of a single endpoint or repository.
+// The histogram has log-scale millisecond buckets, so that its size is bounded however many requests are added.
+type latencies struct {
+	requests    int64
+	buckets     []int64
+	maxMillis   int64
+	bytesServed int64
+}
+
+func (l *latencies) add(request *Request) {
+	millis := request.Duration.Milliseconds()
+	if millis < 0 {
+		millis = 0
+	}
+	bucket := bucketOf(millis)
+	if bucket >= len(l.buckets) {
+		l.buckets = append(l.buckets, make([]int64, bucket+1-len(l.buckets))...)
+	}
+	l.buckets[bucket]++
+	l.requests++
+	if millis > l.maxMillis {
+		l.maxMillis = millis
+	}
+	if request.ResponseLength > 0 {
+		l.bytesServed += request.ResponseLength
+	}
+}
+
+// Returns the nearest-rank percentile of the added, non-empty durations in milliseconds,
+// as the largest duration of its bucket, but not more than the largest duration added.
+func (l *latencies) percentileMillis(p int) int64 {
+	rank := (int64(p)*l.requests + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for bucket, count := range l.buckets {
+		if seen += count; seen >= rank {
+			if millis := bucketMaxMillis(bucket); millis < l.maxMillis {
+				return millis
+			}
+			break
+		}
+	}
+	return l.maxMillis
+}
+
+// Returns the histogram bucket of the passed non-negative number of milliseconds.
+func bucketOf(millis int64) int {
+	if millis < exactBuckets {
+		return int(millis)
+	}
+	power := bits.Len64(uint64(millis)) - 1
+	subBucket := int(millis>>uint(power-subBucketsPower)) - subBuckets
+	return exactBuckets + (power-exactBucketsPower)*subBuckets + subBucket
+}
+
+// Returns the largest number of milliseconds of the passed histogram bucket.
+func bucketMaxMillis(bucket int) int64 {
+	if bucket < exactBuckets {
+		return int64(bucket)
+	}
+	power := exactBucketsPower + (bucket-exactBuckets)/subBuckets
+	subBucket := int64(subBuckets + (bucket-exactBuckets)%subBuckets)
+	return (subBucket+1)<<uint(power-subBucketsPower) - 1
+}
+
+func NewReport() *Report {
+	return &Report{
+		endpoints:    make(map[string]*latencies),
+		repositories: make(map[string]*latencies),
+		statuses:     make(map[int]int64),
+		users:        make(map[string]int64),
+		addresses:    make(map[string]int64),
+	}
+}
+
+// Adds a line of a request log, counting it as unparsed if it is not a request.
+func (r *Report) AddLine(line string) {
+	request, ok := ParseRequest(line)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !ok {
+		r.unparsed++
+		return
+	}
+	r.add(request)
+}
+
+// Adds a single request.
+func (r *Report) Add(request *Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(request)
+}
+
+// NOTE: the caller must hold the Report's lock.
+func (r *Report) add(request *Request) {
+	r.requests++
+	if r.from.IsZero() || request.Timestamp.Before(r.from) {
+		r.from = request.Timestamp
+	}
+	if request.Timestamp.After(r.to) {
+		r.to = request.Timestamp
+	}
+	if request.ResponseLength > 0 {
+		r.bytesServed += request.ResponseLength
+	}
+	pattern, repository := request.Endpoint()
+	endpoint := request.Method + " " + pattern
+	if r.endpoints[endpoint] == nil {
+		r.endpoints[endpoint] = &latencies{}
+	}
+	r.endpoints[endpoint].add(request)
+	if repository != "" {
+		if r.repositories[repository] == nil {
+			r.repositories[repository] = &latencies{}
+		}
+		r.repositories[repository].add(request)
+	}
+	r.statuses[request.Status]++
+	if request.Username != "" {
+		r.users[request.Username]++
+	}
+	if request.RemoteAddress != "" {
+		r.addresses[request.RemoteAddress]++
+	}
+}
+
+// Returns a SourceOutput whose io.Writers add every line written into them to the Report.
+func (r *Report) SourceOutput() livelog.SourceOutput {
+	return func(livelog.Source) io.Writer {
+		return parser.NewLineWriter(func(line string) error {
+			r.AddLine(line)
+			return nil
+		})
+	}
+}
+
+// The latency percentiles and bytes served of the requests of a single endpoint pattern or repository.
+type LatencyStats struct {
+	Name        string `json:"name"`
+	Requests    int64  `json:"requests"`
+	P50Millis   int64  `json:"p50_ms"`
+	P95Millis   int64  `json:"p95_ms"`
+	P99Millis   int64  `json:"p99_ms"`
+	BytesServed int64  `json:"bytes_served"`
+}
+
+type StatusCount struct {
+	Status   int   `json:"status"`
+	Requests int64 `json:"requests"`
+}
+
+type Count struct {
+	Name     string `json:"name"`
+	Requests int64  `json:"requests"`
+}
+
+// A snapshot of a Report.
+type Summary struct {
+	Requests int64 `json:"requests"`
+	// The number of lines that are not requests, such as lines of another log format.
+	UnparsedLines int64      `json:"unparsed_lines"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	BytesServed   int64      `json:"bytes_served"`
+	// Ordered by the number of requests, descending.
+	Endpoints    []LatencyStats `json:"endpoints"`
+	Repositories []LatencyStats `json:"repositories"`
+	// Ordered by status code.
+	Statuses     []StatusCount `json:"statuses"`
+	TopUsers     []Count       `json:"top_users"`
+	TopAddresses []Count       `json:"top_addresses"`
+}
+
+// Returns a Summary of the requests added so far, keeping only the top passed number of endpoints, repositories, users and addresses.
+func (r *Report) Summary(top int) *Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	summary := &Summary{
+		Requests:      r.requests,
+		UnparsedLines: r.unparsed,
+		BytesServed:   r.bytesServed,
+		Endpoints:     latencyStats(r.endpoints, top),
+		Repositories:  latencyStats(r.repositories, top),
+		Statuses:      []StatusCount{},
+		TopUsers:      topCounts(r.users, top),
+		TopAddresses:  topCounts(r.addresses, top),
+	}
+	if r.requests > 0 {
+		from, to := r.from, r.to
+		summary.From, summary.To = &from, &to
+	}
+	for status, requests := range r.statuses {
+		summary.Statuses = append(summary.Statuses, StatusCount{Status: status, Requests: requests})
+	}
+	sort.Slice(summary.Statuses, func(i, j int) bool {
+		return summary.Statuses[i].Status < summary.Statuses[j].Status
+	})
+	return summary
+}
+
+func latencyStats(byName map[string]*latencies, top int) []LatencyStats {
+	stats := make([]LatencyStats, 0, len(byName))
+	for name, l := range byName {
+		stats = append(stats, LatencyStats{Name: name, Requests: l.requests})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Requests != stats[j].Requests {
+			return stats[i].Requests > stats[j].Requests
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	if len(stats) > top {
+		stats = stats[:top]
+	}
+	for idx := range stats {
+		l := byName[stats[idx].Name]
+		stats[idx].P50Millis = l.percentileMillis(50)
+		stats[idx].P95Millis = l.percentileMillis(95)
+		stats[idx].P99Millis = l.percentileMillis(99)
+		stats[idx].BytesServed = l.bytesServed
+	}
+	return stats
+}
+
+func topCounts(byName map[string]int64, top int) []Count {
+	counts := make([]Count, 0, len(byName))
+	for name, requests := range byName {
+		counts = append(counts, Count{Name: name, Requests: requests})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Requests != counts[j].Requests {
+			return counts[i].Requests > counts[j].Requests
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	if len(counts) > top {
+		counts = counts[:top]
+	}
+	return counts
+}
@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+)
+
+func requestLine(second int, address, user, method, path string, status, responseLength, durationMillis int) string {
+	return fmt.Sprintf("2020-12-06T19:21:%02dZ|trace|%s|%s|%s|%s|%d|-1|%d|%d|agent\n", second, address, user, method, path, status, responseLength, durationMillis)
+}
+
+func TestReport(t *testing.T) {
+	report := NewReport()
+	output := report.SourceOutput()(livelog.Source{NodeId: "node-1", LogName: "artifactory-request.log"})
+	var log bytes.Buffer
+	// 100 pings, of 1 to 100 milliseconds
+	for idx := 1; idx <= 100; idx++ {
+		log.WriteString(requestLine(idx%60, "10.0.0.1", "admin", "GET", "/api/system/ping", 200, 2, idx))
+	}
+	log.WriteString(requestLine(0, "10.0.0.2", "deployer", "PUT", "/libs-release-local/a.jar", 201, 0, 300))
+	log.WriteString(requestLine(59, "10.0.0.2", "", "GET", "/libs-release-local/a.jar", 404, 1024, 5))
+	log.WriteString("not a request\n")
+	// written in two parts, where the last line is completed only once the writer is closed
+	content := log.Bytes()
+	_, err := output.Write(content[:100])
+	require.NoError(t, err)
+	_, err = output.Write(append(content[100:], requestLine(30, "10.0.0.3", "admin", "GET", "/api/npm/npm-remote/lodash", 200, 4096, 20)[:90]...))
+	require.NoError(t, err)
+	require.NoError(t, output.(io.Closer).Close())
+
+	summary := report.Summary(2)
+	assert.Equal(t, int64(103), summary.Requests)
+	assert.Equal(t, int64(1), summary.UnparsedLines)
+	assert.Equal(t, int64(100*2+1024+4096), summary.BytesServed)
+	assert.Equal(t, "2020-12-06T19:21:00Z", summary.From.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, "2020-12-06T19:21:59Z", summary.To.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, []LatencyStats{
+		{Name: "GET /api/system/ping", Requests: 100, P50Millis: 50, P95Millis: 95, P99Millis: 99, BytesServed: 200},
+		{Name: "GET /api/npm/{repo}/**", Requests: 1, P50Millis: 20, P95Millis: 20, P99Millis: 20, BytesServed: 4096},
+	}, summary.Endpoints)
+	assert.Equal(t, []LatencyStats{
+		{Name: "libs-release-local", Requests: 2, P50Millis: 5, P95Millis: 300, P99Millis: 300, BytesServed: 1024},
+		{Name: "npm-remote", Requests: 1, P50Millis: 20, P95Millis: 20, P99Millis: 20, BytesServed: 4096},
+	}, summary.Repositories)
+	assert.Equal(t, []StatusCount{{200, 101}, {201, 1}, {404, 1}}, summary.Statuses)
+	assert.Equal(t, []Count{{"admin", 101}, {"deployer", 1}}, summary.TopUsers)
+	assert.Equal(t, []Count{{"10.0.0.1", 100}, {"10.0.0.2", 2}}, summary.TopAddresses)
+
+	// a summary is a snapshot, so it can be marshalled while requests are added
+	_, err = json.Marshal(summary)
+	require.NoError(t, err)
+}
+
+func TestReport_latencyHistogram(t *testing.T) {
+	report := NewReport()
+	// 100000 pings, of 1 to 100000 milliseconds
+	for millis := 1; millis <= 100000; millis++ {
+		report.Add(&Request{Method: "GET", Path: "/api/system/ping", Status: 200, Duration: time.Duration(millis) * time.Millisecond})
+	}
+	// the histogram has a bucket per millisecond up to 128 milliseconds, and 32 buckets per following power of two up to 2^17
+	assert.True(t, len(report.endpoints["GET /api/system/ping"].buckets) <= 128+10*32)
+
+	stats := report.Summary(1).Endpoints[0]
+	assert.Equal(t, int64(100000), stats.Requests)
+	// a percentile is the largest duration of its bucket, which is less than 1/32 more than the exact percentile
+	for _, percentile := range []struct{ exact, actual int64 }{{50000, stats.P50Millis}, {95000, stats.P95Millis}, {99000, stats.P99Millis}} {
+		assert.True(t, percentile.actual >= percentile.exact && percentile.actual < percentile.exact+percentile.exact/32,
+			"percentile %d of exact percentile %d", percentile.actual, percentile.exact)
+	}
+}
+
+func Test_bucketOf(t *testing.T) {
+	for _, millis := range []int64{0, 1, 127, 128, 129, 131, 132, 255, 256, 300, 1000, 65535, 65536, 1 << 40} {
+		bucket := bucketOf(millis)
+		maxMillis := bucketMaxMillis(bucket)
+		assert.True(t, maxMillis >= millis && maxMillis-millis <= millis/32, "bucket %d of %d ends at %d", bucket, millis, maxMillis)
+		assert.Equal(t, bucket, bucketOf(maxMillis))
+		assert.Equal(t, bucket+1, bucketOf(maxMillis+1))
+	}
+}
+
+func TestReport_emptySummary(t *testing.T) {
+	summary := NewReport().Summary(10)
+	assert.Nil(t, summary.From)
+	summaryJson, err := json.Marshal(summary)
+	require.NoError(t, err)
+	assert.Equal(t, `{"requests":0,"unparsed_lines":0,"bytes_served":0,"endpoints":[],"repositories":[],"statuses":[],"top_users":[],"top_addresses":[]}`, string(summaryJson))
+}
+
+func TestWriteText(t *testing.T) {
+	report := NewReport()
+	report.AddLine(requestLine(1, "10.0.0.1", "admin", "GET", "/api/system/ping", 200, 2, 4))
+	report.AddLine(requestLine(2, "10.0.0.1", "admin", "GET", "/libs-release-local/a.jar", 200, 2048, 10))
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteText(out, report.Summary(10)))
+	assert.Equal(t, "Requests: 2, served: 2.0 KB, from 2020-12-06T19:21:01Z to 2020-12-06T19:21:02Z\n"+
+		"\n"+
+		"ENDPOINT              REQUESTS  P50   P95   P99   SERVED\n"+
+		"GET /api/system/ping  1         4ms   4ms   4ms   2 B\n"+
+		"GET /{repo}/**        1         10ms  10ms  10ms  2.0 KB\n"+
+		"\n"+
+		"REPOSITORY          REQUESTS  P50   P95   P99   SERVED\n"+
+		"libs-release-local  1         10ms  10ms  10ms  2.0 KB\n"+
+		"\n"+
+		"STATUS  REQUESTS  SHARE\n"+
+		"200     2         100.0%\n"+
+		"\n"+
+		"USER   REQUESTS\n"+
+		"admin  2\n"+
+		"\n"+
+		"REMOTE ADDRESS  REQUESTS\n"+
+		"10.0.0.1        2\n", out.String())
+}
@@ -0,0 +1,162 @@
+package stats
+
+import (
+	"github.com/hanoch-jfrog/forest/parser"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The layout of the timestamps of the request log of Artifactory 6, in the local time of the server.
+const legacyTimestampLayout = "20060102150405"
+
+// A path segment that identifies a single resource, such as a number, a hex digest or a UUID, rather than an endpoint.
+var idSegmentPattern = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8,}|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// The REST APIs whose path continues with a repository key, as in /api/<api>/<repo_key>/...
+var repositoryApis = map[string]bool{
+	"cargo": true, "cocoapods": true, "composer": true, "conan": true, "conda": true, "deb": true, "docker": true,
+	"gems": true, "go": true, "helm": true, "npm": true, "nuget": true, "pypi": true, "storage": true, "vcs": true,
+}
+
+// A single request of the Artifactory request log, artifactory-request.log.
+type Request struct {
+	Timestamp     time.Time
+	TraceId       string
+	RemoteAddress string
+	Username      string
+	Method        string
+	// The requested path, without its query.
+	Path   string
+	Status int
+	// The sizes of the request and response bodies in bytes, or -1 when unknown.
+	RequestLength  int64
+	ResponseLength int64
+	Duration       time.Duration
+	UserAgent      string
+}
+
+// Parses a line of the request log, either of the Artifactory 7 format:
+// timestamp|trace_id|remote_address|username|method|path|status|request_length|response_length|duration_ms|user_agent
+// or of the Artifactory 6 format:
+// yyyyMMddHHmmss|duration_ms|REQUEST|remote_address|username|method|path|protocol|status|response_length
+// Returns false if the line is of neither format.
+func ParseRequest(line string) (*Request, bool) {
+	fields := strings.Split(strings.TrimRight(line, "\r\n"), "|")
+	if len(fields) >= 10 && fields[2] == "REQUEST" {
+		return parseLegacyRequest(fields)
+	}
+	if len(fields) < 10 {
+		return nil, false
+	}
+	timestamp, ok := parser.ParseTimestamp(fields[0])
+	if !ok {
+		return nil, false
+	}
+	status, err := strconv.Atoi(fields[6])
+	if err != nil {
+		return nil, false
+	}
+	durationMillis, err := strconv.ParseInt(fields[9], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	request := &Request{
+		Timestamp:      timestamp,
+		TraceId:        fields[1],
+		RemoteAddress:  fields[2],
+		Username:       fields[3],
+		Method:         fields[4],
+		Path:           stripQuery(fields[5]),
+		Status:         status,
+		RequestLength:  parseLength(fields[7]),
+		ResponseLength: parseLength(fields[8]),
+		Duration:       time.Duration(durationMillis) * time.Millisecond,
+	}
+	if len(fields) > 10 {
+		// the user agent may contain the separator itself
+		request.UserAgent = strings.Join(fields[10:], "|")
+	}
+	return request, true
+}
+
+func parseLegacyRequest(fields []string) (*Request, bool) {
+	timestamp, err := time.ParseInLocation(legacyTimestampLayout, fields[0], time.Local)
+	if err != nil {
+		return nil, false
+	}
+	durationMillis, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	status, err := strconv.Atoi(fields[8])
+	if err != nil {
+		return nil, false
+	}
+	return &Request{
+		Timestamp:      timestamp,
+		RemoteAddress:  fields[3],
+		Username:       fields[4],
+		Method:         fields[5],
+		Path:           stripQuery(fields[6]),
+		Status:         status,
+		RequestLength:  -1,
+		ResponseLength: parseLength(fields[9]),
+		Duration:       time.Duration(durationMillis) * time.Millisecond,
+	}, true
+}
+
+func stripQuery(path string) string {
+	if queryStart := strings.IndexByte(path, '?'); queryStart >= 0 {
+		return path[:queryStart]
+	}
+	return path
+}
+
+func parseLength(field string) int64 {
+	length, err := strconv.ParseInt(field, 10, 64)
+	if err != nil || length < 0 {
+		return -1
+	}
+	return length
+}
+
+// Returns the endpoint pattern of the request's path, and the key of the repository it accesses, if any.
+// Artifact paths, /<repo_key>/<path>, have the pattern /{repo}/**.
+// REST API paths keep up to their first three segments, where repository keys are replaced by {repo},
+// and segments that identify a single resource, such as numbers, digests and UUIDs, by *.
+// A /artifactory prefix is ignored.
+func (r *Request) Endpoint() (pattern, repository string) {
+	segments := strings.Split(strings.Trim(r.Path, "/"), "/")
+	if segments[0] == "artifactory" {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 || segments[0] == "" {
+		return "/", ""
+	}
+	if segments[0] != "api" && segments[0] != "ui" {
+		return "/{repo}/**", segments[0]
+	}
+	kept := segments
+	if len(kept) > 3 {
+		kept = kept[:3]
+	}
+	patternSegments := make([]string, len(kept))
+	for idx, segment := range kept {
+		switch {
+		case idx == 2 && segments[0] == "api" && repositoryApis[segments[1]]:
+			repository = segment
+			patternSegments[idx] = "{repo}"
+		case idSegmentPattern.MatchString(segment):
+			patternSegments[idx] = "*"
+		default:
+			patternSegments[idx] = segment
+		}
+	}
+	pattern = "/" + strings.Join(patternSegments, "/")
+	if len(segments) > len(kept) {
+		pattern += "/**"
+	}
+	return pattern, repository
+}
@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   *Request
+		wantOk bool
+	}{
+		{
+			name: "artifactory 7",
+			line: "2020-12-06T19:21:52.540Z|6469d8c8e2ece130|10.0.0.1|admin|GET|/api/npm/npm-remote/lodash?foo=bar|200|-1|5120|42|npm/6.14.8 node/v14.15.1",
+			want: &Request{
+				Timestamp:      time.Date(2020, 12, 6, 19, 21, 52, 540000000, time.UTC),
+				TraceId:        "6469d8c8e2ece130",
+				RemoteAddress:  "10.0.0.1",
+				Username:       "admin",
+				Method:         "GET",
+				Path:           "/api/npm/npm-remote/lodash",
+				Status:         200,
+				RequestLength:  -1,
+				ResponseLength: 5120,
+				Duration:       42 * time.Millisecond,
+				UserAgent:      "npm/6.14.8 node/v14.15.1",
+			},
+			wantOk: true,
+		},
+		{
+			name: "artifactory 7 without a user agent",
+			line: "2020-12-06T19:21:52Z|6469d8c8e2ece130|127.0.0.1|anonymous|PUT|/libs-release-local/a.jar|201|1024|0|3|",
+			want: &Request{
+				Timestamp:      time.Date(2020, 12, 6, 19, 21, 52, 0, time.UTC),
+				TraceId:        "6469d8c8e2ece130",
+				RemoteAddress:  "127.0.0.1",
+				Username:       "anonymous",
+				Method:         "PUT",
+				Path:           "/libs-release-local/a.jar",
+				Status:         201,
+				RequestLength:  1024,
+				ResponseLength: 0,
+				Duration:       3 * time.Millisecond,
+			},
+			wantOk: true,
+		},
+		{
+			name: "artifactory 6",
+			line: "20201206192152|7|REQUEST|10.0.0.2|deployer|GET|/api/system/ping|HTTP/1.1|200|2",
+			want: &Request{
+				Timestamp:      time.Date(2020, 12, 6, 19, 21, 52, 0, time.Local),
+				RemoteAddress:  "10.0.0.2",
+				Username:       "deployer",
+				Method:         "GET",
+				Path:           "/api/system/ping",
+				Status:         200,
+				RequestLength:  -1,
+				ResponseLength: 2,
+				Duration:       7 * time.Millisecond,
+			},
+			wantOk: true,
+		},
+		{
+			name: "unified log line",
+			line: "2020-12-06T19:21:52.540Z [jfrt ] [INFO ] [6469d8c8e2ece130] [a.b.C:12] [main] - message",
+		},
+		{
+			name: "invalid status",
+			line: "2020-12-06T19:21:52Z|6469d8c8e2ece130|127.0.0.1|admin|GET|/api/system/ping|OK|-1|0|3|",
+		},
+		{
+			name: "empty line",
+			line: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseRequest(tt.line)
+			require.Equal(t, tt.wantOk, ok)
+			if !tt.wantOk {
+				return
+			}
+			assert.True(t, tt.want.Timestamp.Equal(got.Timestamp))
+			got.Timestamp = tt.want.Timestamp
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRequest_Endpoint(t *testing.T) {
+	tests := []struct {
+		path           string
+		wantPattern    string
+		wantRepository string
+	}{
+		{"/api/system/ping", "/api/system/ping", ""},
+		{"/artifactory/api/system/ping", "/api/system/ping", ""},
+		{"/api/npm/npm-remote/lodash/-/lodash-4.17.20.tgz", "/api/npm/{repo}/**", "npm-remote"},
+		{"/api/storage/libs-release-local", "/api/storage/{repo}", "libs-release-local"},
+		{"/api/build/my-build/42", "/api/build/my-build/**", ""},
+		{"/api/security/users/1234", "/api/security/users/**", ""},
+		{"/api/builds/12345", "/api/builds/*", ""},
+		{"/ui/api/v1/ui/nativeBrowser", "/ui/api/v1/**", ""},
+		{"/libs-release-local/org/acme/app/1.0/app-1.0.jar", "/{repo}/**", "libs-release-local"},
+		{"/artifactory/", "/", ""},
+		{"/", "/", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			pattern, repository := (&Request{Path: tt.path}).Endpoint()
+			assert.Equal(t, tt.wantPattern, pattern)
+			assert.Equal(t, tt.wantRepository, repository)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/hanoch-jfrog/forest/format"
+	"io"
+	"text/tabwriter"
+)
+
+// Writes the passed Summary as aligned tables, separated by empty lines.
+func WriteText(output io.Writer, summary *Summary) error {
+	table := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "Requests: %d, served: %s", summary.Requests, formatBytes(summary.BytesServed))
+	if summary.From != nil {
+		fmt.Fprintf(table, ", from %s to %s", format.Time(*summary.From), format.Time(*summary.To))
+	}
+	if summary.UnparsedLines > 0 {
+		fmt.Fprintf(table, ", unparsed lines: %d", summary.UnparsedLines)
+	}
+	fmt.Fprint(table, "\n")
+
+	writeLatencyTable(table, "ENDPOINT", summary.Endpoints)
+	writeLatencyTable(table, "REPOSITORY", summary.Repositories)
+	if len(summary.Statuses) > 0 {
+		fmt.Fprint(table, "\nSTATUS\tREQUESTS\tSHARE\n")
+		for _, status := range summary.Statuses {
+			fmt.Fprintf(table, "%d\t%d\t%.1f%%\n", status.Status, status.Requests, 100*float64(status.Requests)/float64(summary.Requests))
+		}
+	}
+	writeCountTable(table, "USER", summary.TopUsers)
+	writeCountTable(table, "REMOTE ADDRESS", summary.TopAddresses)
+	return table.Flush()
+}
+
+func writeLatencyTable(table io.Writer, header string, stats []LatencyStats) {
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Fprintf(table, "\n%s\tREQUESTS\tP50\tP95\tP99\tSERVED\n", header)
+	for _, s := range stats {
+		fmt.Fprintf(table, "%s\t%d\t%dms\t%dms\t%dms\t%s\n", s.Name, s.Requests, s.P50Millis, s.P95Millis, s.P99Millis, formatBytes(s.BytesServed))
+	}
+}
+
+func writeCountTable(table io.Writer, header string, counts []Count) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintf(table, "\n%s\tREQUESTS\n", header)
+	for _, count := range counts {
+		fmt.Fprintf(table, "%s\t%d\n", count.Name, count.Requests)
+	}
+}
+
+// Formats a number of bytes with a binary unit, such as 1.5 MB.
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value, suffix := float64(bytes), ""
+	for _, suffix = range []string{"KB", "MB", "GB", "TB"} {
+		value /= unit
+		if value < unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
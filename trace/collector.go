@@ -0,0 +1,90 @@
+package trace
+
+import (
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/parser"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A single entry of a trace, along with the log it was read from.
+type Entry struct {
+	Source  livelog.Source
+	LogLine *parser.LogLine
+}
+
+// Collects the entries of a single trace id out of the log data of many sources.
+type Collector struct {
+	traceId string
+
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+func NewCollector(traceId string) *Collector {
+	return &Collector{
+		traceId: traceId,
+	}
+}
+
+// Returns a SourceOutput whose io.Writers feed the Collector.
+// Trace ids are matched case insensitively, and continuation entries, such as stack traces that arrived
+// in a later write, are attached to the entry preceding them.
+// The lines of request logs are matched by the trace id field of the request.
+func (c *Collector) SourceOutput() livelog.SourceOutput {
+	return func(source livelog.Source) io.Writer {
+		if isRequestLog(source.LogName) {
+			return parser.NewLineWriter(func(line string) error {
+				c.addMatching(source, parseRequestLine(source.LogName, line))
+				return nil
+			})
+		}
+		var lastEntry *Entry
+		return parser.NewEntryWriter(func(logLine *parser.LogLine) error {
+			if logLine.IsContinuation() {
+				if lastEntry != nil {
+					c.mu.Lock()
+					lastEntry.LogLine.Raw += "\n" + logLine.Raw
+					lastEntry.LogLine.Message += "\n" + logLine.Raw
+					c.mu.Unlock()
+				}
+				return nil
+			}
+			lastEntry = c.addMatching(source, logLine)
+			return nil
+		})
+	}
+}
+
+// Adds the entry if it is of the collected trace id, returning the added entry, or nil if it is of another trace.
+func (c *Collector) addMatching(source livelog.Source, logLine *parser.LogLine) *Entry {
+	if !strings.EqualFold(logLine.TraceId, c.traceId) {
+		return nil
+	}
+	entry := &Entry{Source: source, LogLine: logLine}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+	return entry
+}
+
+// Returns the collected entries in time order.
+// Entries of the same time are ordered by node id, log name, and then by the order they were logged in.
+func (c *Collector) Entries() []*Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := append([]*Entry(nil), c.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		first, second := entries[i], entries[j]
+		if !first.LogLine.Timestamp.Equal(second.LogLine.Timestamp) {
+			return first.LogLine.Timestamp.Before(second.LogLine.Timestamp)
+		}
+		if first.Source.NodeId != second.Source.NodeId {
+			return first.Source.NodeId < second.Source.NodeId
+		}
+		return first.Source.LogName < second.Source.LogName
+	})
+	return entries
+}
@@ -0,0 +1,88 @@
+package trace
+
+import (
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector("6469D8C8E2ECE130")
+	output := collector.SourceOutput()
+	router := output(livelog.Source{NodeId: "node-1", LogName: "router-request.log"})
+	artifactory := output(livelog.Source{NodeId: "node-2", LogName: "artifactory-service.log"})
+
+	_, err := router.Write([]byte("2020-12-06T19:21:52.100Z [jfrou] [INFO ] [6469d8c8e2ece130] [main.go:10] [main] - routed\n" +
+		"2020-12-06T19:21:52.150Z [jfrou] [INFO ] [0000000000000000] [main.go:10] [main] - other trace\n"))
+	require.NoError(t, err)
+	_, err = artifactory.Write([]byte("2020-12-06T19:21:52.300Z [jfrt ] [ERROR] [6469d8c8e2ece130] [o.j.Handler:5] [exec-1] - failed\n"))
+	require.NoError(t, err)
+	_, err = artifactory.Write([]byte("\tat stack.trace\n2020-12-06T19:21:52.200Z [jfrt ] [INFO ] [6469d8c8e2ece130] [o.j.Handler:3] [exec-1] - handled\n"))
+	require.NoError(t, err)
+	require.NoError(t, router.(io.Closer).Close())
+	require.NoError(t, artifactory.(io.Closer).Close())
+
+	entries := collector.Entries()
+	require.Len(t, entries, 3)
+	require.Equal(t, "routed", entries[0].LogLine.Message)
+	require.Equal(t, "router-request.log", entries[0].Source.LogName)
+	require.Equal(t, "handled", entries[1].LogLine.Message)
+	require.Equal(t, "failed\n\tat stack.trace", entries[2].LogLine.Message)
+	require.Equal(t, "node-2", entries[2].Source.NodeId)
+}
+
+func TestCollector_requestLogs(t *testing.T) {
+	tests := []struct {
+		name        string
+		logName     string
+		content     string
+		wantService string
+		wantRaw     string
+	}{
+		{
+			name:    "request log",
+			logName: "artifactory-request.log",
+			content: "2020-12-06T19:21:52.100Z|0000000000000000|10.0.0.1|admin|GET|/api/system/ping|200|-1|2|3|curl/7.64.1\n" +
+				"2020-12-06T19:21:52.540Z|6469d8c8e2ece130|10.0.0.1|admin|GET|/api/repositories|200|-1|75|11|JFrog CLI\n",
+			wantService: "jfrt",
+			wantRaw:     "2020-12-06T19:21:52.540Z|6469d8c8e2ece130|10.0.0.1|admin|GET|/api/repositories|200|-1|75|11|JFrog CLI",
+		},
+		{
+			name:    "router request log",
+			logName: "router-request.log",
+			content: `{"DownstreamStatus":200,"RequestMethod":"GET","RequestPath":"/artifactory/api/system/ping","StartUTC":"2020-12-06T19:21:52.100Z","request_Uber-Trace-Id":"0000000000000000:0000000000000000:0000000000000000:0"}` + "\n" +
+				`{"DownstreamStatus":200,"RequestMethod":"GET","RequestPath":"/artifactory/api/repositories","StartUTC":"2020-12-06T19:21:52.540123Z","request_Uber-Trace-Id":"6469d8c8e2ece130:6469d8c8e2ece130:0000000000000000:1"}` + "\n",
+			wantService: "jfrou",
+			wantRaw:     `{"DownstreamStatus":200,"RequestMethod":"GET","RequestPath":"/artifactory/api/repositories","StartUTC":"2020-12-06T19:21:52.540123Z","request_Uber-Trace-Id":"6469d8c8e2ece130:6469d8c8e2ece130:0000000000000000:1"}`,
+		},
+		{
+			name:        "unified log format",
+			logName:     "router-request.log",
+			content:     "2020-12-06T19:21:52.540Z [jfrou] [INFO ] [6469d8c8e2ece130] [main.go:10] [main] - routed\n",
+			wantService: "jfrou",
+			wantRaw:     "2020-12-06T19:21:52.540Z [jfrou] [INFO ] [6469d8c8e2ece130] [main.go:10] [main] - routed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewCollector("6469D8C8E2ECE130")
+			output := collector.SourceOutput()(livelog.Source{NodeId: "node-1", LogName: tt.logName})
+			// split in the middle of the matching line
+			splitAt := len(tt.content) - 20
+			_, err := output.Write([]byte(tt.content[:splitAt]))
+			require.NoError(t, err)
+			_, err = output.Write([]byte(tt.content[splitAt:]))
+			require.NoError(t, err)
+			require.NoError(t, output.(io.Closer).Close())
+
+			entries := collector.Entries()
+			require.Len(t, entries, 1)
+			require.Equal(t, tt.wantService, entries[0].LogLine.Service)
+			require.Equal(t, tt.wantRaw, entries[0].LogLine.Raw)
+			wantTimestamp := time.Date(2020, 12, 6, 19, 21, 52, 540*int(time.Millisecond), time.UTC)
+			require.True(t, wantTimestamp.Equal(entries[0].LogLine.Timestamp.Truncate(time.Millisecond)))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package trace
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The entries of a trace that were logged by a single service on a single node.
+type Group struct {
+	Service string
+	NodeId  string
+	// In time order.
+	Entries []*Entry
+}
+
+// Groups the passed entries, which are expected in time order, by service and node.
+// Groups are ordered by their first entry, following the trace through the services it passed.
+func GroupEntries(entries []*Entry) []*Group {
+	var groups []*Group
+	groupsByKey := make(map[string]*Group)
+	for _, entry := range entries {
+		key := entry.LogLine.Service + "\x00" + entry.Source.NodeId
+		group, ok := groupsByKey[key]
+		if !ok {
+			group = &Group{Service: entry.LogLine.Service, NodeId: entry.Source.NodeId}
+			groupsByKey[key] = group
+			groups = append(groups, group)
+		}
+		group.Entries = append(group.Entries, entry)
+	}
+	return groups
+}
+
+// Writes the passed groups as text, each group under a header, and each entry prefixed by its log name.
+func WriteGroups(output io.Writer, groups []*Group) error {
+	for idx, group := range groups {
+		if idx > 0 {
+			if _, err := io.WriteString(output, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(output, "== %s on node %s ==\n", group.Service, group.NodeId); err != nil {
+			return err
+		}
+		for _, entry := range group.Entries {
+			prefix := "[" + entry.Source.LogName + "] "
+			if _, err := io.WriteString(output, prefix+strings.Replace(entry.LogLine.Raw, "\n", "\n"+prefix, -1)+"\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package trace
+
+import (
+	"bytes"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGroupEntries(t *testing.T) {
+	entry := func(nodeId, logName, raw string) *Entry {
+		return &Entry{Source: livelog.Source{NodeId: nodeId, LogName: logName}, LogLine: parser.Parse(raw)}
+	}
+	entries := []*Entry{
+		entry("node-1", "router-request.log", "2020-12-06T19:21:52.100Z [jfrou] [INFO ] [abc] - routed"),
+		entry("node-2", "access-service.log", "2020-12-06T19:21:52.200Z [jfac ] [INFO ] [abc] - authenticated"),
+		entry("node-1", "artifactory-service.log", "2020-12-06T19:21:52.300Z [jfrt ] [ERROR] [abc] - failed\n\tat stack.trace"),
+		entry("node-2", "access-request.log", "2020-12-06T19:21:52.400Z [jfac ] [INFO ] [abc] - responded"),
+	}
+
+	groups := GroupEntries(entries)
+	require.Len(t, groups, 3)
+	require.Equal(t, "jfrou", groups[0].Service)
+	require.Equal(t, "jfac", groups[1].Service)
+	require.Equal(t, "node-2", groups[1].NodeId)
+	require.Equal(t, []*Entry{entries[1], entries[3]}, groups[1].Entries)
+	require.Equal(t, "jfrt", groups[2].Service)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteGroups(out, groups))
+	require.Equal(t, "== jfrou on node node-1 ==\n"+
+		"[router-request.log] 2020-12-06T19:21:52.100Z [jfrou] [INFO ] [abc] - routed\n"+
+		"\n"+
+		"== jfac on node node-2 ==\n"+
+		"[access-service.log] 2020-12-06T19:21:52.200Z [jfac ] [INFO ] [abc] - authenticated\n"+
+		"[access-request.log] 2020-12-06T19:21:52.400Z [jfac ] [INFO ] [abc] - responded\n"+
+		"\n"+
+		"== jfrt on node node-1 ==\n"+
+		"[artifactory-service.log] 2020-12-06T19:21:52.300Z [jfrt ] [ERROR] [abc] - failed\n"+
+		"[artifactory-service.log] \tat stack.trace\n", out.String())
+}
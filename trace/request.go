@@ -0,0 +1,92 @@
+package trace
+
+import (
+	"encoding/json"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/hanoch-jfrog/forest/stats"
+	"strings"
+	"time"
+)
+
+// The service types of the logs, by the prefix of their name.
+var logServices = map[string]string{
+	"access":      "jfac",
+	"artifactory": "jfrt",
+	"event":       "jfevt",
+	"frontend":    "jffe",
+	"metadata":    "jfmd",
+	"router":      "jfrou",
+}
+
+// The fields of a line of the router request log, router-request.log, which is written by Traefik as JSON.
+type routerRequest struct {
+	StartUTC string `json:"StartUTC"`
+	Time     string `json:"time"`
+	// The Jaeger trace context of the request, trace_id:span_id:parent_span_id:flags
+	UberTraceId string `json:"request_Uber-Trace-Id"`
+}
+
+// Returns whether the log has a request per line, rather than entries of the unified log format,
+// such as artifactory-request.log and router-request.log.
+func isRequestLog(logName string) bool {
+	return strings.Contains(logName, "-request")
+}
+
+// Parses a line of a request log, either of the pipe separated format of stats.ParseRequest,
+// or of the JSON format of the router.
+// Lines of neither format are parsed as entries of the unified log format.
+func parseRequestLine(logName string, line string) *parser.LogLine {
+	line = strings.TrimSuffix(line, "\r")
+	if strings.HasPrefix(line, "{") {
+		if logLine, ok := parseRouterRequest(logName, line); ok {
+			return logLine
+		}
+	}
+	request, ok := stats.ParseRequest(line)
+	if !ok {
+		return parser.Parse(line)
+	}
+	return &parser.LogLine{
+		Timestamp: request.Timestamp,
+		Service:   logService(logName),
+		TraceId:   request.TraceId,
+		Message:   line,
+		Raw:       line,
+	}
+}
+
+func parseRouterRequest(logName string, line string) (*parser.LogLine, bool) {
+	request := routerRequest{}
+	if err := json.Unmarshal([]byte(line), &request); err != nil {
+		return nil, false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, request.StartUTC)
+	if err != nil {
+		if timestamp, err = time.Parse(time.RFC3339Nano, request.Time); err != nil {
+			return nil, false
+		}
+	}
+	traceId := request.UberTraceId
+	if idEnd := strings.IndexByte(traceId, ':'); idEnd >= 0 {
+		traceId = traceId[:idEnd]
+	}
+	return &parser.LogLine{
+		Timestamp: timestamp,
+		Service:   logService(logName),
+		TraceId:   traceId,
+		Message:   line,
+		Raw:       line,
+	}, true
+}
+
+// Returns the service type of the log, or the prefix of its name if it is not known.
+func logService(logName string) string {
+	prefix := logName
+	if prefixEnd := strings.IndexByte(logName, '-'); prefixEnd >= 0 {
+		prefix = logName[:prefixEnd]
+	}
+	if service, ok := logServices[prefix]; ok {
+		return service
+	}
+	return prefix
+}
@@ -0,0 +1,365 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"strings"
+	"time"
+)
+
+const (
+	redrawInterval = 250 * time.Millisecond
+	minPaneWidth   = 20
+	paneSeparator  = "\x1b[2m│\x1b[0m"
+	styleHelp      = "\x1b[2m"
+
+	helpLine = "q quit | tab focus | a add | x close | s sync | / search | n/N next/prev | & filter | p pause | l/L level | g/G top/bottom"
+)
+
+// Opens the pane of the passed spec, as typed by the user, such as "<node_id> <log_name>".
+// Returns the Session to show in the pane, and the Client to follow into it.
+type PaneOpener func(ctx context.Context, spec string) (*Session, livelog.Client, error)
+
+type promptKind int
+
+const (
+	promptNone promptKind = iota
+	promptSearch
+	promptTextFilter
+	promptAddPane
+)
+
+var promptLabels = map[promptKind]string{
+	promptSearch:     "/",
+	promptTextFilter: "&",
+	promptAddPane:    "add pane (<node_id> <log_name>): ",
+}
+
+// A View shown side by side with the others, whose Session is followed until the pane is closed.
+type pane struct {
+	view   *View
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// A full-screen viewer of panes, each following its own log.
+type App struct {
+	openPane      PaneOpener
+	syncScrolling bool
+
+	panes []*pane
+	// Panes that were closed, whose following may still be stopping.
+	closedPanes []*pane
+	focus       int
+	prompt      promptKind
+	promptText  string
+	message     string
+	// The screen width of the last draw.
+	width int
+}
+
+// Creates an App whose panes are opened by the passed PaneOpener.
+func NewApp(openPane PaneOpener) *App {
+	return &App{
+		openPane: openPane,
+	}
+}
+
+// Sets whether scrolling the focused pane scrolls every other pane to the same time, and following in one follows in all.
+// Synced scrolling may also be toggled from the keyboard.
+func (a *App) SetSyncScrolling(syncScrolling bool) {
+	a.syncScrolling = syncScrolling
+}
+
+// Opens a pane of each of the passed specs, and shows them side by side on the passed Terminal,
+// handling the keys pressed until the user quits or the passed context is cancelled.
+// NOTE: this call blocks; the Terminal is not closed.
+func (a *App) Run(ctx context.Context, term *Terminal, specs []string) error {
+	if len(specs) == 0 {
+		return errors.New("no panes to show")
+	}
+	defer a.stopPanes()
+	for _, spec := range specs {
+		if err := a.addPane(ctx, spec); err != nil {
+			return err
+		}
+	}
+	a.setFocus(0)
+
+	keys := make(chan []Key)
+	readErrs := make(chan error, 1)
+	go func() {
+		for {
+			pressed, err := term.ReadKeys()
+			if err != nil {
+				readErrs <- err
+				return
+			}
+			select {
+			case keys <- pressed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(redrawInterval)
+	defer ticker.Stop()
+	for {
+		if err := a.draw(term); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErrs:
+			return err
+		case <-ticker.C:
+		case pressed := <-keys:
+			for _, key := range pressed {
+				if quit := a.handleKey(ctx, key); quit {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (a *App) addPane(ctx context.Context, spec string) error {
+	session, client, err := a.openPane(ctx, spec)
+	if err != nil {
+		return err
+	}
+	paneCtx, cancel := context.WithCancel(ctx)
+	p := &pane{
+		view:   NewView(session),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(p.done)
+		session.Follow(paneCtx, client)
+	}()
+	a.panes = append(a.panes, p)
+	return nil
+}
+
+// Stops following in every pane, and waits for it to stop, so that no checkpoint is left half saved.
+func (a *App) stopPanes() {
+	for _, p := range append(a.panes, a.closedPanes...) {
+		p.cancel()
+	}
+	for _, p := range append(a.panes, a.closedPanes...) {
+		<-p.done
+	}
+}
+
+func (a *App) setFocus(focus int) {
+	a.focus = focus
+	for idx, p := range a.panes {
+		p.view.SetFocused(idx == focus)
+	}
+}
+
+func (a *App) focusedView() *View {
+	return a.panes[a.focus].view
+}
+
+func (a *App) draw(term *Terminal) error {
+	width, height, err := term.Size()
+	if err != nil {
+		return err
+	}
+	a.width = width
+	if height < 2 {
+		return nil
+	}
+	screen := make([]string, height-1)
+	paneWidth := (width - (len(a.panes) - 1)) / len(a.panes)
+	for idx, p := range a.panes {
+		renderWidth := paneWidth
+		if idx == len(a.panes)-1 {
+			// the last pane takes the columns left over by the division
+			renderWidth = width - idx*(paneWidth+1)
+		}
+		if renderWidth < 1 {
+			renderWidth = 1
+		}
+		for row, line := range p.view.Render(renderWidth, height-1) {
+			if idx > 0 {
+				screen[row] += paneSeparator
+			}
+			screen[row] += line
+		}
+	}
+	return term.Draw(append(screen, a.bottomLine(width)))
+}
+
+func (a *App) bottomLine(width int) string {
+	switch {
+	case a.prompt != promptNone:
+		return truncate(promptLabels[a.prompt]+a.promptText, width)
+	case a.message != "":
+		return truncate(a.message, width)
+	default:
+		return styleHelp + truncate(helpLine, width) + styleReset
+	}
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(sanitize(text))
+	if len(runes) > width {
+		runes = runes[:width]
+	}
+	return string(runes)
+}
+
+// Handles a single key press, and returns whether the user quit.
+func (a *App) handleKey(ctx context.Context, key Key) (quit bool) {
+	if a.prompt != promptNone {
+		a.handlePromptKey(ctx, key)
+		return false
+	}
+	a.message = ""
+	view := a.focusedView()
+	switch key {
+	case "q", KeyCtrlC:
+		return true
+	case KeyTab:
+		a.setFocus((a.focus + 1) % len(a.panes))
+		return false
+	case "a":
+		a.openPrompt(promptAddPane, "")
+		return false
+	case "x":
+		a.closeFocusedPane()
+		return false
+	case "s":
+		a.syncScrolling = !a.syncScrolling
+		if a.syncScrolling {
+			a.message = "Synced scrolling on"
+		} else {
+			a.message = "Synced scrolling off"
+		}
+	case "/":
+		a.openPrompt(promptSearch, "")
+	case "&":
+		a.openPrompt(promptTextFilter, view.TextFilter())
+	case "n":
+		a.message = view.FindNext(true)
+	case "N":
+		a.message = view.FindNext(false)
+	case "p", " ":
+		view.TogglePause()
+	case "l":
+		view.CycleMinLevel(true)
+	case "L":
+		view.CycleMinLevel(false)
+	case "g", KeyHome:
+		view.ScrollToTop()
+	case "G", KeyEnd:
+		view.ScrollToBottom()
+	case "k", KeyUp:
+		view.ScrollBy(-1)
+	case "j", KeyDown:
+		view.ScrollBy(1)
+	case "b", KeyPageUp, KeyCtrlB:
+		view.ScrollByPages(-1)
+	case "f", KeyPageDown, KeyCtrlF:
+		view.ScrollByPages(1)
+	case "h", KeyLeft:
+		view.ShiftColumns(-1)
+	case KeyRight:
+		view.ShiftColumns(1)
+	}
+	a.syncPanes()
+	return false
+}
+
+// With synced scrolling, follows in every pane if the focused one follows,
+// or scrolls every pane to the time of the top line of the focused one.
+func (a *App) syncPanes() {
+	if !a.syncScrolling {
+		return
+	}
+	focused := a.focusedView()
+	timestamp := focused.TopTimestamp()
+	for _, p := range a.panes {
+		switch {
+		case p.view == focused:
+		case focused.IsFollowing():
+			p.view.ScrollToBottom()
+		case !timestamp.IsZero():
+			p.view.ScrollToTime(timestamp)
+		}
+	}
+}
+
+func (a *App) closeFocusedPane() {
+	if len(a.panes) == 1 {
+		a.message = "Cannot close the last pane, press q to quit"
+		return
+	}
+	closed := a.panes[a.focus]
+	closed.cancel()
+	a.closedPanes = append(a.closedPanes, closed)
+	a.panes = append(a.panes[:a.focus:a.focus], a.panes[a.focus+1:]...)
+	a.setFocus(a.focus % len(a.panes))
+}
+
+func (a *App) openPrompt(kind promptKind, text string) {
+	a.prompt = kind
+	a.promptText = text
+}
+
+func (a *App) handlePromptKey(ctx context.Context, key Key) {
+	switch key {
+	case KeyEnter:
+		kind := a.prompt
+		a.prompt = promptNone
+		a.submitPrompt(ctx, kind, a.promptText)
+	case KeyEscape, KeyCtrlC:
+		a.prompt = promptNone
+	case KeyBackspace:
+		if runes := []rune(a.promptText); len(runes) > 0 {
+			a.promptText = string(runes[:len(runes)-1])
+		}
+	case KeyCtrlW:
+		a.promptText = strings.TrimRight(a.promptText, " ")
+		a.promptText = a.promptText[:strings.LastIndex(a.promptText, " ")+1]
+	default:
+		if !strings.HasPrefix(string(key), "<") || len(key) == 1 {
+			a.promptText += string(key)
+		}
+	}
+}
+
+func (a *App) submitPrompt(ctx context.Context, kind promptKind, text string) {
+	view := a.focusedView()
+	switch kind {
+	case promptSearch:
+		a.message = view.SetSearch(text)
+		a.syncPanes()
+	case promptTextFilter:
+		view.SetTextFilter(text)
+	case promptAddPane:
+		a.submitAddPane(ctx, text)
+	}
+}
+
+func (a *App) submitAddPane(ctx context.Context, spec string) {
+	if spec = strings.TrimSpace(spec); spec == "" {
+		return
+	}
+	if a.width > 0 && (a.width+1)/(len(a.panes)+1) < minPaneWidth+1 {
+		a.message = "No room for another pane, close one with x first"
+		return
+	}
+	if err := a.addPane(ctx, spec); err != nil {
+		a.message = "Failed opening pane: " + err.Error()
+		return
+	}
+	a.setFocus(len(a.panes) - 1)
+	a.syncPanes()
+}
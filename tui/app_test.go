@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+)
+
+// A livelog.Client whose TailLog blocks until cancelled, without writing anything.
+type idleClient struct {
+	livelog.Client
+}
+
+func (idleClient) TailLog(ctx context.Context, _ io.Writer) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Returns a PaneOpener of panes whose Buffer holds 10 lines, a second apart, starting at the second of the spec number.
+func newTestOpener(t *testing.T) PaneOpener {
+	return func(_ context.Context, spec string) (*Session, livelog.Client, error) {
+		var start int
+		if _, err := fmt.Sscanf(spec, "node-%d", &start); err != nil {
+			return nil, nil, errors.New("unknown pane " + spec)
+		}
+		buffer := NewBuffer(0)
+		for idx := 0; idx < 10; idx++ {
+			_, err := fmt.Fprintf(buffer, "2020-12-06T19:21:%02d.000Z [jfrt ] [INFO ] - %s line %d\n", start+idx, spec, idx)
+			require.NoError(t, err)
+		}
+		return NewSession(spec, buffer), idleClient{}, nil
+	}
+}
+
+func newTestApp(t *testing.T, specs ...string) (*App, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := NewApp(newTestOpener(t))
+	for _, spec := range specs {
+		require.NoError(t, a.addPane(ctx, spec))
+	}
+	a.setFocus(0)
+	return a, func() {
+		cancel()
+		a.stopPanes()
+	}
+}
+
+func pressKeys(a *App, keys ...Key) (quit bool) {
+	for _, key := range keys {
+		if a.handleKey(context.Background(), key) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestApp_focusAndClose(t *testing.T) {
+	a, stop := newTestApp(t, "node-0", "node-1", "node-2")
+	defer stop()
+
+	assert.False(t, pressKeys(a, KeyTab, KeyTab))
+	assert.Equal(t, "node-2", a.focusedView().Session().Title())
+	assert.False(t, a.panes[0].view.focused)
+	assert.True(t, a.panes[2].view.focused)
+
+	pressKeys(a, "x")
+	require.Len(t, a.panes, 2)
+	assert.Equal(t, "node-0", a.focusedView().Session().Title())
+	pressKeys(a, "x")
+	pressKeys(a, "x")
+	require.Len(t, a.panes, 1)
+	assert.Equal(t, "Cannot close the last pane, press q to quit", a.message)
+	assert.True(t, pressKeys(a, "q"))
+}
+
+func TestApp_addPane(t *testing.T) {
+	a, stop := newTestApp(t, "node-0")
+	defer stop()
+
+	pressKeys(a, "a", "n", "o", "d", "e", "-", "5", KeyEnter)
+	require.Len(t, a.panes, 2)
+	assert.Equal(t, "node-5", a.focusedView().Session().Title())
+
+	pressKeys(a, "a", "x", KeyEnter)
+	assert.Len(t, a.panes, 2)
+	assert.Equal(t, "Failed opening pane: unknown pane x", a.message)
+
+	a.width = 2 * minPaneWidth
+	pressKeys(a, "a", "n", "o", "d", "e", "-", "6", KeyEnter)
+	assert.Len(t, a.panes, 2)
+	assert.Equal(t, "No room for another pane, close one with x first", a.message)
+}
+
+func TestApp_prompt(t *testing.T) {
+	a, stop := newTestApp(t, "node-0", "node-1")
+	defer stop()
+
+	pressKeys(a, "&", "l", "i", "n", "e", " ", "3", "x", KeyBackspace, KeyEnter)
+	assert.Equal(t, "line 3", a.focusedView().TextFilter())
+	assert.Equal(t, "", a.panes[1].view.TextFilter())
+
+	pressKeys(a, "/", "l", "i", "n", "e", KeyEscape)
+	assert.Equal(t, "", a.focusedView().Search())
+	pressKeys(a, "/", "m", "i", "s", "s", KeyEnter)
+	assert.Equal(t, "Pattern not found: miss", a.message)
+	// any key clears the message
+	pressKeys(a, "j")
+	assert.Equal(t, "", a.message)
+}
+
+func TestApp_syncScrolling(t *testing.T) {
+	a, stop := newTestApp(t, "node-0", "node-3")
+	defer stop()
+	a.SetSyncScrolling(true)
+	for _, p := range a.panes {
+		p.view.Render(80, 4)
+	}
+
+	pressKeys(a, "g")
+	assert.False(t, a.panes[1].view.IsFollowing())
+	assert.Equal(t, a.panes[0].view.TopTimestamp(), a.panes[1].view.TopTimestamp().Add(-3*time.Second))
+
+	pressKeys(a, "j", "j", "j", "j")
+	assert.Equal(t, a.panes[0].view.TopTimestamp(), a.panes[1].view.TopTimestamp())
+
+	pressKeys(a, "G")
+	assert.True(t, a.panes[1].view.IsFollowing())
+
+	pressKeys(a, "s", "g")
+	assert.Equal(t, "", a.message)
+	assert.True(t, a.panes[1].view.IsFollowing())
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"github.com/hanoch-jfrog/forest/parser"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The memory a Line is assumed to take on top of its text.
+const lineOverhead = 64
+
+// A single line of a Buffer, along with the level and timestamp of the entry it belongs to.
+type Line struct {
+	Text      string
+	Level     parser.Level
+	Timestamp time.Time
+	// The sequence number of the line since the Buffer was created, which does not change when older lines are evicted.
+	Seq int64
+}
+
+// An io.WriteCloser that keeps the lines of the log data written into it, up to a memory cap,
+// after which the oldest lines are evicted.
+type Buffer struct {
+	maxBytes    int64
+	entryWriter *parser.EntryWriter
+
+	mu            sync.Mutex
+	lines         []Line
+	size          int64
+	nextSeq       int64
+	received      int64
+	lastLevel     parser.Level
+	lastTimestamp time.Time
+}
+
+// Creates a Buffer that holds up to maxBytes of lines; zero or less means no cap.
+func NewBuffer(maxBytes int64) *Buffer {
+	b := &Buffer{
+		maxBytes: maxBytes,
+	}
+	b.entryWriter = parser.NewEntryWriter(b.addEntry)
+	return b
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	b.received += int64(len(p))
+	b.mu.Unlock()
+	return b.entryWriter.Write(p)
+}
+
+func (b *Buffer) Close() error {
+	return b.entryWriter.Close()
+}
+
+// Returns the held lines, oldest first.
+// The returned slice is not modified by later writes, so it may be read without holding any lock.
+func (b *Buffer) Lines() []Line {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lines[:len(b.lines):len(b.lines)]
+}
+
+// Returns the number of bytes written into the Buffer, including evicted ones.
+func (b *Buffer) Received() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.received
+}
+
+// Returns the sequence number the next line will get, which is the number of lines written so far.
+func (b *Buffer) NextSeq() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextSeq
+}
+
+// Adds the lines of a parsed entry. Continuation entries take the level and timestamp of the entry preceding them.
+func (b *Buffer) addEntry(logLine *parser.LogLine) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !logLine.IsContinuation() {
+		b.lastLevel = logLine.Level
+		b.lastTimestamp = logLine.Timestamp
+	}
+	for _, text := range strings.Split(logLine.Raw, "\n") {
+		b.lines = append(b.lines, Line{Text: text, Level: b.lastLevel, Timestamp: b.lastTimestamp, Seq: b.nextSeq})
+		b.nextSeq++
+		b.size += int64(len(text)) + lineOverhead
+	}
+	b.evict()
+	return nil
+}
+
+// Once the held lines exceed the memory cap, evicts the oldest lines until they take 90% of it,
+// so that evictions, which copy the held lines, are rare. The newest line is always kept.
+// NOTE: the caller must hold the Buffer's lock.
+func (b *Buffer) evict() {
+	if b.maxBytes <= 0 || b.size <= b.maxBytes {
+		return
+	}
+	evicted := 0
+	for b.size > b.maxBytes/10*9 && evicted < len(b.lines)-1 {
+		b.size -= int64(len(b.lines[evicted].Text)) + lineOverhead
+		evicted++
+	}
+	if evicted == 0 {
+		return
+	}
+	// copy into a new slice rather than in place, since slices returned by Lines may still be read
+	b.lines = append(make([]Line, 0, 2*(len(b.lines)-evicted)), b.lines[evicted:]...)
+}
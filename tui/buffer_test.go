@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	b := NewBuffer(0)
+	data := "2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first\n2020-12-06T19:21:52.550Z [jfrt ] [ERROR] - failure\n\tat one\n"
+	_, err := b.Write([]byte(data))
+	require.NoError(t, err)
+	_, err = b.Write([]byte("\tat two\n"))
+	require.NoError(t, err)
+	require.NoError(t, b.Close())
+
+	lines := b.Lines()
+	require.Len(t, lines, 4)
+	assert.Equal(t, "2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first", lines[0].Text)
+	assert.Equal(t, parser.LevelInfo, lines[0].Level)
+	assert.Equal(t, "\tat one", lines[2].Text)
+	assert.Equal(t, parser.LevelError, lines[2].Level)
+	// a continuation that arrives in a later write keeps the level and timestamp of its entry
+	assert.Equal(t, "\tat two", lines[3].Text)
+	assert.Equal(t, parser.LevelError, lines[3].Level)
+	assert.Equal(t, lines[1].Timestamp, lines[3].Timestamp)
+	for idx, line := range lines {
+		assert.Equal(t, int64(idx), line.Seq)
+	}
+	assert.Equal(t, int64(len(data)+len("\tat two\n")), b.Received())
+	assert.Equal(t, int64(4), b.NextSeq())
+}
+
+func TestBuffer_evict(t *testing.T) {
+	const lineText = "2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - line"
+	maxBytes := int64(10 * (len(lineText) + lineOverhead))
+	b := NewBuffer(maxBytes)
+
+	before := b.Lines()
+	_, err := b.Write([]byte(strings.Repeat(lineText+"\n", 25)))
+	require.NoError(t, err)
+	require.NoError(t, b.Close())
+
+	lines := b.Lines()
+	assert.LessOrEqual(t, int64(len(lines)*(len(lineText)+lineOverhead)), maxBytes)
+	assert.NotEmpty(t, lines)
+	// the newest lines are kept, with their original sequence numbers
+	assert.Equal(t, int64(24), lines[len(lines)-1].Seq)
+	assert.Equal(t, int64(25-len(lines)), lines[0].Seq)
+	assert.Equal(t, int64(25), b.NextSeq())
+	assert.Empty(t, before)
+}
+
+func TestBuffer_evictKeepsNewestLine(t *testing.T) {
+	b := NewBuffer(1)
+	_, err := b.Write([]byte("2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first\n2020-12-06T19:21:52.550Z [jfrt ] [INFO ] - second\n"))
+	require.NoError(t, err)
+
+	lines := b.Lines()
+	require.Len(t, lines, 1)
+	assert.Equal(t, "2020-12-06T19:21:52.550Z [jfrt ] [INFO ] - second", lines[0].Text)
+}
@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"unicode/utf8"
+)
+
+// A key press, either one of the named keys below, or the string of a printable rune.
+type Key string
+
+const (
+	KeyUp        Key = "<up>"
+	KeyDown      Key = "<down>"
+	KeyLeft      Key = "<left>"
+	KeyRight     Key = "<right>"
+	KeyPageUp    Key = "<page-up>"
+	KeyPageDown  Key = "<page-down>"
+	KeyHome      Key = "<home>"
+	KeyEnd       Key = "<end>"
+	KeyEnter     Key = "<enter>"
+	KeyEscape    Key = "<escape>"
+	KeyBackspace Key = "<backspace>"
+	KeyTab       Key = "<tab>"
+	KeyCtrlB     Key = "<ctrl-b>"
+	KeyCtrlC     Key = "<ctrl-c>"
+	KeyCtrlF     Key = "<ctrl-f>"
+	KeyCtrlW     Key = "<ctrl-w>"
+	KeyUnknown   Key = "<unknown>"
+)
+
+var escapeSequences = map[string]Key{
+	"[A": KeyUp, "[B": KeyDown, "[C": KeyRight, "[D": KeyLeft,
+	"OA": KeyUp, "OB": KeyDown, "OC": KeyRight, "OD": KeyLeft,
+	"[H": KeyHome, "[F": KeyEnd, "OH": KeyHome, "OF": KeyEnd,
+	"[1~": KeyHome, "[7~": KeyHome, "[4~": KeyEnd, "[8~": KeyEnd,
+	"[5~": KeyPageUp, "[6~": KeyPageDown,
+}
+
+var controlKeys = map[byte]Key{
+	'\r': KeyEnter, '\n': KeyEnter, '\t': KeyTab,
+	0x7f: KeyBackspace, 0x08: KeyBackspace,
+	0x02: KeyCtrlB, 0x03: KeyCtrlC, 0x06: KeyCtrlF, 0x17: KeyCtrlW,
+}
+
+// Decodes the keys of the bytes read from a terminal in raw mode.
+// An escape byte that does not start a known sequence is decoded as KeyEscape.
+func decodeKeys(p []byte) []Key {
+	var keys []Key
+	for len(p) > 0 {
+		if p[0] == 0x1b {
+			key, length := decodeEscapeSequence(p)
+			keys = append(keys, key)
+			p = p[length:]
+			continue
+		}
+		if key, ok := controlKeys[p[0]]; ok {
+			keys = append(keys, key)
+			p = p[1:]
+			continue
+		}
+		if p[0] < ' ' {
+			keys = append(keys, KeyUnknown)
+			p = p[1:]
+			continue
+		}
+		r, length := utf8.DecodeRune(p)
+		if r == utf8.RuneError {
+			keys = append(keys, KeyUnknown)
+		} else {
+			keys = append(keys, Key(string(r)))
+		}
+		p = p[length:]
+	}
+	return keys
+}
+
+// Decodes the escape sequence the passed bytes start with, returning its key and length.
+func decodeEscapeSequence(p []byte) (Key, int) {
+	if len(p) < 2 || (p[1] != '[' && p[1] != 'O') {
+		return KeyEscape, 1
+	}
+	// a CSI sequence ends with a byte in the range 0x40-0x7e, following optional parameter bytes
+	for end := 2; end < len(p); end++ {
+		if p[end] >= 0x40 && p[end] <= 0x7e {
+			if key, ok := escapeSequences[string(p[1:end+1])]; ok {
+				return key, end + 1
+			}
+			return KeyUnknown, end + 1
+		}
+	}
+	return KeyEscape, 1
+}
@@ -0,0 +1,28 @@
+package tui
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_decodeKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Key
+	}{
+		{"printable", "q/é", []Key{"q", "/", "é"}},
+		{"control", "\r\x7f\x03\x06\x02\x17\t", []Key{KeyEnter, KeyBackspace, KeyCtrlC, KeyCtrlF, KeyCtrlB, KeyCtrlW, KeyTab}},
+		{"arrows", "\x1b[A\x1b[B\x1bOC\x1b[D", []Key{KeyUp, KeyDown, KeyRight, KeyLeft}},
+		{"paging", "\x1b[5~\x1b[6~\x1b[H\x1b[4~", []Key{KeyPageUp, KeyPageDown, KeyHome, KeyEnd}},
+		{"lone escape", "\x1b", []Key{KeyEscape}},
+		{"escape then key", "\x1bq", []Key{KeyEscape, "q"}},
+		{"unknown sequence", "\x1b[1;5Cn", []Key{KeyUnknown, "n"}},
+		{"unknown control", "\x01", []Key{KeyUnknown}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, decodeKeys([]byte(tt.input)))
+		})
+	}
+}
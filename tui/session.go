@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"context"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/filter"
+	"sync"
+	"time"
+)
+
+// A single followed log, feeding a Buffer.
+type Session struct {
+	title  string
+	buffer *Buffer
+	// nil when every entry is kept
+	filter *filter.Filter
+
+	mu       sync.Mutex
+	lastPoll time.Time
+	err      error
+	stopped  bool
+}
+
+// Creates a Session whose lines are kept in the passed Buffer, shown under the passed title, such as "server node log".
+func NewSession(title string, buffer *Buffer) *Session {
+	return &Session{
+		title:  title,
+		buffer: buffer,
+	}
+}
+
+func (s *Session) Title() string {
+	return s.title
+}
+
+func (s *Session) Buffer() *Buffer {
+	return s.buffer
+}
+
+// Keeps only the entries matching the passed filter in the Buffer. Must be called before Follow.
+func (s *Session) SetFilter(sessionFilter *filter.Filter) {
+	s.filter = sessionFilter
+}
+
+// Returns a strategy.Http that sends its requests using the passed one,
+// and records the time of every successful request as the last poll time of the Session.
+func (s *Session) TrackPolls(httpStrategy strategy.Http) strategy.Http {
+	return &pollTrackingStrategy{
+		Http:    httpStrategy,
+		session: s,
+	}
+}
+
+// Tails the log of the passed client into the Buffer, until the passed context is cancelled or tailing fails.
+// NOTE: this call blocks; the error tailing stopped with is kept, to be shown by the Session's View.
+func (s *Session) Follow(ctx context.Context, client livelog.Client) {
+	var err error
+	if s.filter != nil {
+		filterWriter := filter.NewWriter(s.buffer, s.filter)
+		err = client.TailLog(ctx, filterWriter)
+		if closeErr := filterWriter.Close(); err == nil {
+			err = closeErr
+		}
+	} else {
+		err = client.TailLog(ctx, s.buffer)
+	}
+	if closeErr := s.buffer.Close(); err == nil {
+		err = closeErr
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+	s.stopped = true
+}
+
+// Returns the time of the last successful request, or the zero time if there was none.
+func (s *Session) LastPoll() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastPoll
+}
+
+// Returns whether following stopped, and the error it stopped with, if any.
+func (s *Session) Stopped() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopped, s.err
+}
+
+type pollTrackingStrategy struct {
+	strategy.Http
+	session *Session
+}
+
+func (s *pollTrackingStrategy) SendGet(ctx context.Context, endpoint, nodeId string) ([]byte, error) {
+	resBody, err := s.Http.SendGet(ctx, endpoint, nodeId)
+	if err == nil {
+		s.session.mu.Lock()
+		s.session.lastPoll = time.Now()
+		s.session.mu.Unlock()
+	}
+	return resBody, err
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"errors"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	enterAlternateScreen = "\x1b[?1049h\x1b[?25l"
+	leaveAlternateScreen = "\x1b[?25h\x1b[?1049l"
+	cursorHome           = "\x1b[H"
+	clearLineEnd         = "\x1b[K"
+)
+
+// The terminal the TUI is drawn on, switched to raw mode and to its alternate screen until closed.
+type Terminal struct {
+	inFd  int
+	outFd int
+	in    io.Reader
+	out   io.Writer
+	state *terminal.State
+}
+
+// Switches the terminal of the standard input and output to raw mode and to its alternate screen.
+func OpenTerminal() (*Terminal, error) {
+	inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !terminal.IsTerminal(inFd) || !terminal.IsTerminal(outFd) {
+		return nil, errors.New("the tui requires the standard input and output to be an interactive terminal")
+	}
+	state, err := terminal.MakeRaw(inFd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.WriteString(os.Stdout, enterAlternateScreen); err != nil {
+		_ = terminal.Restore(inFd, state)
+		return nil, err
+	}
+	return &Terminal{
+		inFd:  inFd,
+		outFd: outFd,
+		in:    os.Stdin,
+		out:   os.Stdout,
+		state: state,
+	}, nil
+}
+
+// Returns the width and height of the terminal.
+func (t *Terminal) Size() (width, height int, err error) {
+	return terminal.GetSize(t.outFd)
+}
+
+// Reads the next keys pressed.
+// NOTE: this call blocks until a key is pressed.
+func (t *Terminal) ReadKeys() ([]Key, error) {
+	p := make([]byte, 256)
+	n, err := t.in.Read(p)
+	if err != nil {
+		return nil, err
+	}
+	return decodeKeys(p[:n]), nil
+}
+
+// Draws the passed lines from the top of the screen, in a single write.
+func (t *Terminal) Draw(lines []string) error {
+	var screen strings.Builder
+	screen.WriteString(cursorHome)
+	for idx, line := range lines {
+		screen.WriteString(line)
+		screen.WriteString(clearLineEnd)
+		if idx < len(lines)-1 {
+			screen.WriteString("\r\n")
+		}
+	}
+	_, err := io.WriteString(t.out, screen.String())
+	return err
+}
+
+// Leaves the alternate screen, and restores the terminal to the mode it was in when opened.
+func (t *Terminal) Close() error {
+	_, err := io.WriteString(t.out, leaveAlternateScreen)
+	if restoreErr := terminal.Restore(t.inFd, t.state); err == nil {
+		err = restoreErr
+	}
+	return err
+}
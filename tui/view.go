@@ -0,0 +1,477 @@
+package tui
+
+import (
+	"fmt"
+	"github.com/hanoch-jfrog/forest/parser"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	tabWidth        = 4
+	horizontalShift = 8
+
+	styleReset         = "\x1b[0m"
+	styleStatus        = "\x1b[0;7m"
+	styleStatusBlurred = "\x1b[0;2;7m"
+	styleStatusError   = "\x1b[0;7;31m"
+	styleMatch         = "\x1b[7m"
+	styleMatchEnd      = "\x1b[27m"
+	styleCurrentMatch  = "\x1b[1;7m"
+	styleCurrentEnd    = "\x1b[22;27m"
+	styleLevelWarn     = "\x1b[33m"
+	styleLevelError    = "\x1b[31m"
+	styleNone          = ""
+)
+
+// The levels the level filter cycles through, where LevelUnknown shows every line.
+var filterLevels = []parser.Level{parser.LevelUnknown, parser.LevelTrace, parser.LevelDebug, parser.LevelInfo,
+	parser.LevelWarn, parser.LevelError, parser.LevelFatal}
+
+// The scrolling, following, searching and filtering state of a Session, rendered into a rectangle of the screen.
+// A View is not safe for concurrent use; its Session's Buffer may be written into concurrently.
+type View struct {
+	session *Session
+
+	following bool
+	// The sequence number of the top visible line, when not following.
+	topSeq int64
+	// The sequence number the Buffer was at when following was paused, to count the new lines since.
+	pausedAtSeq int64
+	leftColumn  int
+	minLevel    parser.Level
+	// Only lines containing the text filter, case insensitively, are shown, unless it is empty.
+	textFilter string
+	search     string
+	// The sequence number of the current search match, or -1 if there is none.
+	matchSeq int64
+	// The content height of the last render, used for paging.
+	height  int
+	focused bool
+}
+
+func NewView(session *Session) *View {
+	return &View{
+		session:   session,
+		following: true,
+		matchSeq:  -1,
+		height:    1,
+		focused:   true,
+	}
+}
+
+func (v *View) Session() *Session {
+	return v.session
+}
+
+func (v *View) IsFollowing() bool {
+	return v.following
+}
+
+// Sets whether the View has the keyboard focus, which is shown by the style of its status bar.
+func (v *View) SetFocused(focused bool) {
+	v.focused = focused
+}
+
+// Returns the lines that pass the level and text filters, oldest first.
+// While a level filter is set, lines whose entry has no level are hidden.
+func (v *View) visibleLines() []Line {
+	lines := v.session.buffer.Lines()
+	if v.minLevel == parser.LevelUnknown && v.textFilter == "" {
+		return lines
+	}
+	textFilter := strings.ToLower(v.textFilter)
+	visible := make([]Line, 0, len(lines))
+	for _, line := range lines {
+		if line.Level >= v.minLevel && strings.Contains(strings.ToLower(line.Text), textFilter) {
+			visible = append(visible, line)
+		}
+	}
+	return visible
+}
+
+// Returns the index of the top visible line, for a content of the passed height.
+func (v *View) topIndex(lines []Line, height int) int {
+	bottom := len(lines) - height
+	if bottom < 0 {
+		bottom = 0
+	}
+	if v.following {
+		return bottom
+	}
+	top := seqIndex(lines, v.topSeq)
+	if top > bottom {
+		top = bottom
+	}
+	return top
+}
+
+// Returns the index of the first line whose sequence number is at least the passed one.
+func seqIndex(lines []Line, seq int64) int {
+	return sort.Search(len(lines), func(idx int) bool {
+		return lines[idx].Seq >= seq
+	})
+}
+
+func (v *View) setTop(lines []Line, top int) {
+	if top < 0 {
+		top = 0
+	}
+	if top >= len(lines) {
+		top = len(lines) - 1
+	}
+	if v.following {
+		v.following = false
+		v.pausedAtSeq = v.session.buffer.NextSeq()
+	}
+	if top >= 0 {
+		v.topSeq = lines[top].Seq
+	}
+}
+
+// Scrolls by the passed number of lines, pausing following.
+func (v *View) ScrollBy(delta int) {
+	lines := v.visibleLines()
+	if v.following && delta > 0 {
+		return
+	}
+	v.setTop(lines, v.topIndex(lines, v.height)+delta)
+}
+
+// Scrolls by the passed number of pages, pausing following.
+func (v *View) ScrollByPages(pages int) {
+	v.ScrollBy(pages * v.height)
+}
+
+// Scrolls to the oldest line, pausing following.
+func (v *View) ScrollToTop() {
+	v.setTop(v.visibleLines(), 0)
+}
+
+// Scrolls to the first line logged at or after the passed time, pausing following.
+func (v *View) ScrollToTime(timestamp time.Time) {
+	lines := v.visibleLines()
+	v.setTop(lines, sort.Search(len(lines), func(idx int) bool {
+		return !lines[idx].Timestamp.Before(timestamp)
+	}))
+}
+
+// Scrolls to the newest line, and resumes following.
+func (v *View) ScrollToBottom() {
+	v.following = true
+}
+
+// Pauses following, keeping the visible lines in place, or resumes it.
+func (v *View) TogglePause() {
+	if v.following {
+		lines := v.visibleLines()
+		v.setTop(lines, v.topIndex(lines, v.height))
+		return
+	}
+	v.following = true
+}
+
+// Scrolls horizontally by the passed number of shifts.
+func (v *View) ShiftColumns(shifts int) {
+	v.leftColumn += shifts * horizontalShift
+	if v.leftColumn < 0 {
+		v.leftColumn = 0
+	}
+}
+
+// Shows only lines of the passed level and above, or every line for LevelUnknown.
+func (v *View) SetMinLevel(minLevel parser.Level) {
+	v.minLevel = minLevel
+}
+
+func (v *View) MinLevel() parser.Level {
+	return v.minLevel
+}
+
+// Moves the level filter to the next, or the previous, level.
+func (v *View) CycleMinLevel(forward bool) {
+	for idx, level := range filterLevels {
+		if level != v.minLevel {
+			continue
+		}
+		if forward {
+			v.minLevel = filterLevels[(idx+1)%len(filterLevels)]
+		} else {
+			v.minLevel = filterLevels[(idx+len(filterLevels)-1)%len(filterLevels)]
+		}
+		return
+	}
+}
+
+// Shows only the lines containing the passed text, case insensitively, or every line for an empty text.
+func (v *View) SetTextFilter(text string) {
+	v.textFilter = text
+}
+
+func (v *View) TextFilter() string {
+	return v.textFilter
+}
+
+// Sets the search query, matched case insensitively, and moves to its first match from the top visible line.
+// An empty query clears the search. Returns a message for the user, empty when the query was found.
+func (v *View) SetSearch(query string) string {
+	v.search = query
+	v.matchSeq = -1
+	if query == "" {
+		return ""
+	}
+	return v.FindNext(true)
+}
+
+func (v *View) Search() string {
+	return v.search
+}
+
+// Moves to the next, or the previous, line matching the search query, pausing following.
+// Returns a message for the user, empty when a match was found without wrapping around.
+func (v *View) FindNext(forward bool) string {
+	if v.search == "" {
+		return "No previous search"
+	}
+	lines := v.visibleLines()
+	if len(lines) == 0 {
+		return "Pattern not found: " + v.search
+	}
+	start := v.topIndex(lines, v.height)
+	if v.matchSeq >= 0 {
+		start = seqIndex(lines, v.matchSeq)
+		if forward && start < len(lines) && lines[start].Seq == v.matchSeq {
+			start++
+		}
+		if !forward {
+			start--
+		}
+	} else if !forward {
+		start--
+	}
+	query := strings.ToLower(v.search)
+	step := 1
+	if !forward {
+		step = -1
+	}
+	for checked, idx := 0, start; checked < len(lines); checked, idx = checked+1, idx+step {
+		wrapped := idx < 0 || idx >= len(lines)
+		idx = (idx%len(lines) + len(lines)) % len(lines)
+		if !strings.Contains(strings.ToLower(lines[idx].Text), query) {
+			continue
+		}
+		v.matchSeq = lines[idx].Seq
+		v.setTop(lines, idx-v.height/3)
+		if wrapped || (checked > 0 && (forward && idx < start || !forward && idx > start)) {
+			if forward {
+				return "Search hit BOTTOM, continuing at TOP"
+			}
+			return "Search hit TOP, continuing at BOTTOM"
+		}
+		return ""
+	}
+	return "Pattern not found: " + v.search
+}
+
+// Renders the View into the passed number of lines, each padded to the passed width: the visible lines, followed by a status bar.
+func (v *View) Render(width, height int) []string {
+	if height < 1 {
+		return nil
+	}
+	v.height = height - 1
+	if v.height < 1 {
+		v.height = 1
+	}
+	lines := v.visibleLines()
+	top := v.topIndex(lines, height-1)
+	rendered := make([]string, 0, height)
+	for idx := top; idx < len(lines) && len(rendered) < height-1; idx++ {
+		rendered = append(rendered, v.renderLine(lines[idx], width))
+	}
+	for len(rendered) < height-1 {
+		rendered = append(rendered, strings.Repeat(" ", width))
+	}
+	return append(rendered, v.renderStatus(lines, width))
+}
+
+func (v *View) renderLine(line Line, width int) string {
+	runes := []rune(sanitize(line.Text))
+	var highlights []bool
+	if v.search != "" {
+		highlights = matchedRunes(runes, []rune(strings.ToLower(v.search)))
+	}
+	start, end := v.leftColumn, v.leftColumn+width
+	if start > len(runes) {
+		start = len(runes)
+	}
+	if end > len(runes) {
+		end = len(runes)
+	}
+	padding := strings.Repeat(" ", width-(end-start))
+
+	var rendered strings.Builder
+	levelStyle := levelStyle(line.Level)
+	rendered.WriteString(levelStyle)
+	matchStyle, matchEnd := styleMatch, styleMatchEnd
+	if line.Seq == v.matchSeq {
+		matchStyle, matchEnd = styleCurrentMatch, styleCurrentEnd
+	}
+	highlighted := false
+	for idx := start; idx < end; idx++ {
+		if highlights != nil && highlights[idx] != highlighted {
+			highlighted = highlights[idx]
+			if highlighted {
+				rendered.WriteString(matchStyle)
+			} else {
+				rendered.WriteString(matchEnd)
+			}
+		}
+		rendered.WriteRune(runes[idx])
+	}
+	if levelStyle != "" || highlighted {
+		rendered.WriteString(styleReset)
+	}
+	rendered.WriteString(padding)
+	return rendered.String()
+}
+
+func levelStyle(level parser.Level) string {
+	switch {
+	case level >= parser.LevelError:
+		return styleLevelError
+	case level == parser.LevelWarn:
+		return styleLevelWarn
+	default:
+		return styleNone
+	}
+}
+
+// Returns which of the passed runes are part of a case insensitive match of the passed lower cased query.
+func matchedRunes(runes []rune, query []rune) []bool {
+	if len(query) == 0 || len(query) > len(runes) {
+		return nil
+	}
+	lowered := []rune(strings.ToLower(string(runes)))
+	if len(lowered) != len(runes) {
+		// lower casing changed the number of runes, so matches cannot be mapped back
+		return nil
+	}
+	matched := make([]bool, len(runes))
+	for start := 0; start+len(query) <= len(lowered); start++ {
+		if string(lowered[start:start+len(query)]) == string(query) {
+			for idx := start; idx < start+len(query); idx++ {
+				matched[idx] = true
+			}
+		}
+	}
+	return matched
+}
+
+// Expands tabs, and replaces other control characters, which would break the layout of the screen.
+func sanitize(text string) string {
+	if strings.IndexFunc(text, isControl) < 0 {
+		return text
+	}
+	var sanitized strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\t':
+			sanitized.WriteString(strings.Repeat(" ", tabWidth))
+		case isControl(r):
+			sanitized.WriteRune('?')
+		default:
+			sanitized.WriteRune(r)
+		}
+	}
+	return sanitized.String()
+}
+
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
+
+func (v *View) renderStatus(lines []Line, width int) string {
+	parts := []string{v.session.Title(), formatBytes(v.session.buffer.Received()), fmt.Sprintf("%d lines", len(lines))}
+	if lastPoll := v.session.LastPoll(); !lastPoll.IsZero() {
+		parts = append(parts, "polled "+lastPoll.Format("15:04:05"))
+	}
+	stopped, err := v.session.Stopped()
+	switch {
+	case stopped:
+		parts = append(parts, "STOPPED")
+	case v.following:
+		parts = append(parts, "FOLLOWING")
+	default:
+		paused := "PAUSED"
+		if newLines := v.session.buffer.NextSeq() - v.pausedAtSeq; newLines > 0 {
+			paused += fmt.Sprintf(" +%d new", newLines)
+		}
+		parts = append(parts, paused)
+	}
+	if v.minLevel != parser.LevelUnknown {
+		parts = append(parts, "level "+v.minLevel.String()+"+")
+	}
+	if v.textFilter != "" {
+		parts = append(parts, "&"+v.textFilter)
+	}
+	if v.search != "" {
+		parts = append(parts, "/"+v.search+" "+v.matchPosition(lines))
+	}
+	style := styleStatus
+	if !v.focused {
+		style = styleStatusBlurred
+	}
+	if err != nil {
+		parts = append(parts, err.Error())
+		style = styleStatusError
+	}
+	status := []rune(sanitize(" " + strings.Join(parts, " | ") + " "))
+	if len(status) > width {
+		status = status[:width]
+	}
+	return style + string(status) + strings.Repeat(" ", width-len(status)) + styleReset
+}
+
+// Returns the position of the current search match among every match, such as "3/17".
+func (v *View) matchPosition(lines []Line) string {
+	query := strings.ToLower(v.search)
+	current, total := 0, 0
+	for _, line := range lines {
+		if strings.Contains(strings.ToLower(line.Text), query) {
+			total++
+			if line.Seq == v.matchSeq {
+				current = total
+			}
+		}
+	}
+	if current == 0 {
+		return fmt.Sprintf("%d matches", total)
+	}
+	return fmt.Sprintf("%d/%d", current, total)
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value, suffix := float64(bytes), ""
+	for _, suffix = range []string{"KB", "MB", "GB", "TB"} {
+		value /= unit
+		if value < unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+// Returns the timestamp of the top visible line, or the zero time if there is none.
+func (v *View) TopTimestamp() time.Time {
+	lines := v.visibleLines()
+	top := v.topIndex(lines, v.height)
+	if top >= len(lines) {
+		return time.Time{}
+	}
+	return lines[top].Timestamp
+}
@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"fmt"
+	"github.com/hanoch-jfrog/forest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Creates a View of a Buffer holding the passed number of lines; every third line is a warning, the others are info.
+func newTestView(t *testing.T, lineCount int) *View {
+	buffer := NewBuffer(0)
+	for idx := 0; idx < lineCount; idx++ {
+		level := "INFO "
+		if idx%3 == 2 {
+			level = "WARN "
+		}
+		_, err := fmt.Fprintf(buffer, "2020-12-06T19:21:52.%03dZ [jfrt ] [%s] - line %d\n", idx, level, idx)
+		require.NoError(t, err)
+	}
+	require.NoError(t, buffer.Close())
+	return NewView(NewSession("server node console.log", buffer))
+}
+
+// Returns the messages of the content lines rendered by the passed View, without the status bar.
+func renderedMessages(v *View, height int) []string {
+	rendered := v.Render(200, height)
+	var messages []string
+	for _, line := range rendered[:len(rendered)-1] {
+		line = strings.TrimRight(line, " ")
+		line = strings.NewReplacer(styleReset, "", styleLevelWarn, "", styleMatch, "", styleMatchEnd, "",
+			styleCurrentMatch, "", styleCurrentEnd, "").Replace(line)
+		if sep := strings.Index(line, " - "); sep >= 0 {
+			line = line[sep+3:]
+		}
+		messages = append(messages, line)
+	}
+	return messages
+}
+
+func TestView_following(t *testing.T) {
+	v := newTestView(t, 10)
+	assert.Equal(t, []string{"line 7", "line 8", "line 9"}, renderedMessages(v, 4))
+
+	_, err := v.Session().Buffer().Write([]byte("2020-12-06T19:21:53.000Z [jfrt ] [INFO ] - line 10\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line 8", "line 9", "line 10"}, renderedMessages(v, 4))
+}
+
+func TestView_pause(t *testing.T) {
+	v := newTestView(t, 10)
+	renderedMessages(v, 4)
+	v.TogglePause()
+	assert.False(t, v.IsFollowing())
+
+	_, err := v.Session().Buffer().Write([]byte("2020-12-06T19:21:53.000Z [jfrt ] [INFO ] - line 10\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line 7", "line 8", "line 9"}, renderedMessages(v, 4))
+	assert.Contains(t, v.Render(200, 4)[3], "PAUSED +1 new")
+
+	v.TogglePause()
+	assert.Equal(t, []string{"line 8", "line 9", "line 10"}, renderedMessages(v, 4))
+	assert.Contains(t, v.Render(200, 4)[3], "FOLLOWING")
+}
+
+func TestView_scroll(t *testing.T) {
+	v := newTestView(t, 10)
+	renderedMessages(v, 4)
+
+	v.ScrollBy(-2)
+	assert.Equal(t, []string{"line 5", "line 6", "line 7"}, renderedMessages(v, 4))
+	v.ScrollByPages(-1)
+	assert.Equal(t, []string{"line 2", "line 3", "line 4"}, renderedMessages(v, 4))
+	v.ScrollToTop()
+	assert.Equal(t, []string{"line 0", "line 1", "line 2"}, renderedMessages(v, 4))
+	v.ScrollBy(-1)
+	assert.Equal(t, []string{"line 0", "line 1", "line 2"}, renderedMessages(v, 4))
+	// scrolling past the bottom stays paused on the last page
+	v.ScrollByPages(5)
+	assert.Equal(t, []string{"line 7", "line 8", "line 9"}, renderedMessages(v, 4))
+	assert.False(t, v.IsFollowing())
+	v.ScrollToBottom()
+	assert.True(t, v.IsFollowing())
+}
+
+func TestView_textFilter(t *testing.T) {
+	v := newTestView(t, 12)
+	v.SetTextFilter("LINE 1")
+	assert.Equal(t, []string{"line 1", "line 10", "line 11"}, renderedMessages(v, 4))
+	assert.Contains(t, v.Render(200, 4)[3], "&LINE 1")
+
+	v.SetMinLevel(parser.LevelWarn)
+	assert.Equal(t, []string{"line 11", "", ""}, renderedMessages(v, 4))
+}
+
+func TestView_ScrollToTime(t *testing.T) {
+	v := newTestView(t, 10)
+	renderedMessages(v, 4)
+	lines := v.Session().Buffer().Lines()
+
+	v.ScrollToTime(lines[4].Timestamp.Add(-time.Microsecond))
+	assert.False(t, v.IsFollowing())
+	assert.Equal(t, []string{"line 4", "line 5", "line 6"}, renderedMessages(v, 4))
+	assert.Equal(t, lines[4].Timestamp, v.TopTimestamp())
+	v.ScrollToTime(lines[9].Timestamp.Add(time.Second))
+	assert.Equal(t, []string{"line 7", "line 8", "line 9"}, renderedMessages(v, 4))
+}
+
+func TestView_minLevel(t *testing.T) {
+	v := newTestView(t, 10)
+	v.SetMinLevel(parser.LevelWarn)
+	assert.Equal(t, []string{"line 2", "line 5", "line 8"}, renderedMessages(v, 4))
+	assert.Contains(t, v.Render(200, 4)[3], "level WARN+")
+
+	v.CycleMinLevel(true)
+	assert.Equal(t, parser.LevelError, v.MinLevel())
+	assert.Equal(t, []string{"", "", ""}, renderedMessages(v, 4))
+	v.CycleMinLevel(true)
+	v.CycleMinLevel(true)
+	assert.Equal(t, parser.LevelUnknown, v.MinLevel())
+	v.CycleMinLevel(false)
+	assert.Equal(t, parser.LevelFatal, v.MinLevel())
+}
+
+func TestView_search(t *testing.T) {
+	v := newTestView(t, 10)
+	renderedMessages(v, 4)
+	v.ScrollToTop()
+
+	assert.Equal(t, "", v.SetSearch("LINE 1"))
+	assert.False(t, v.IsFollowing())
+	rendered := v.Render(200, 4)
+	assert.Contains(t, rendered[1], styleCurrentMatch+"line 1"+styleReset)
+	assert.Contains(t, rendered[3], "/LINE 1 1/1")
+
+	assert.Equal(t, "Search hit BOTTOM, continuing at TOP", v.FindNext(true))
+	assert.Equal(t, "Search hit TOP, continuing at BOTTOM", v.FindNext(false))
+	assert.Equal(t, "Pattern not found: missing", v.SetSearch("missing"))
+	assert.Contains(t, v.Render(200, 4)[3], "/missing 0 matches")
+}
+
+func TestView_searchNavigation(t *testing.T) {
+	v := newTestView(t, 10)
+	renderedMessages(v, 4)
+	v.ScrollToTop()
+
+	assert.Equal(t, "", v.SetSearch("WARN"))
+	assert.Contains(t, v.Render(200, 4)[3], "1/3")
+	assert.Equal(t, "", v.FindNext(true))
+	assert.Contains(t, v.Render(200, 4)[3], "2/3")
+	assert.Equal(t, "", v.FindNext(true))
+	assert.Contains(t, v.Render(200, 4)[3], "3/3")
+	assert.Equal(t, "", v.FindNext(false))
+	assert.Contains(t, v.Render(200, 4)[3], "2/3")
+}
+
+func TestView_renderLine(t *testing.T) {
+	v := NewView(NewSession("title", NewBuffer(0)))
+	assert.Equal(t, "a    b?c", v.renderLine(Line{Text: "a\tb\x07c"}, 8))
+	assert.Equal(t, "abc", v.renderLine(Line{Text: "abcdef"}, 3))
+	assert.Equal(t, "ab  ", v.renderLine(Line{Text: "ab"}, 4))
+	assert.Equal(t, styleLevelError+"boom"+styleReset+" ", v.renderLine(Line{Text: "boom", Level: parser.LevelError}, 5))
+
+	v.search = "B"
+	assert.Equal(t, "a"+styleMatch+"bb"+styleMatchEnd+"a"+styleMatch+"b"+styleReset, v.renderLine(Line{Text: "abbab"}, 5))
+
+	v.ShiftColumns(1)
+	assert.Equal(t, "   ", v.renderLine(Line{Text: "abbab"}, 3))
+	assert.Equal(t, "89 ", v.renderLine(Line{Text: "0123456789"}, 3))
+}
+
+func TestView_status(t *testing.T) {
+	v := newTestView(t, 3)
+	status := v.Render(40, 2)[1]
+	assert.True(t, strings.HasPrefix(status, styleStatus+" server node console.log | "))
+	assert.Equal(t, 40, len([]rune(strings.TrimSuffix(strings.TrimPrefix(status, styleStatus), styleReset))))
+
+	v.SetFocused(false)
+	assert.True(t, strings.HasPrefix(v.Render(40, 2)[1], styleStatusBlurred))
+}
+
+func Test_formatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KB", formatBytes(1536))
+	assert.Equal(t, "64.0 MB", formatBytes(64<<20))
+	assert.Equal(t, "2.0 GB", formatBytes(2<<30))
+}
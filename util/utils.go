@@ -3,6 +3,9 @@ package util
 import (
 	"bytes"
 	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -31,3 +34,33 @@ func SliceToCsv(values []string) string {
 func MillisToDuration(timeInMillis int64) time.Duration {
 	return time.Duration(timeInMillis) * time.Millisecond
 }
+
+// Writes the passed content into a temporary file next to the passed path, and renames it over the path once the content is synced,
+// so that the file at the path is never left partially written.
+func WriteFileAtomically(path string, content []byte, perm os.FileMode) (err error) {
+	tempFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tempFile.Name())
+		}
+	}()
+	if err = tempFile.Chmod(perm); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if _, err = tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err = tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
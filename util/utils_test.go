@@ -2,6 +2,10 @@ package util
 
 import (
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -111,3 +115,28 @@ func TestInSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteFileAtomically(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forest-util-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "templates.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte("old content"), 0600))
+
+	require.NoError(t, WriteFileAtomically(path, []byte("new content"), 0644))
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new content", string(content))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	// the temporary file was renamed over the path
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	// failing to write leaves no file
+	assert.Error(t, WriteFileAtomically(filepath.Join(dir, "missing", "templates.json"), []byte("content"), 0644))
+	_, err = os.Stat(filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+}
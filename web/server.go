@@ -0,0 +1,366 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hanoch-jfrog/forest/client/livelog"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/hanoch-jfrog/forest/util"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apiPrefix = "/api/servers"
+	// How often a comment is sent on an idle log stream, so that proxies do not close it.
+	keepAliveInterval = 15 * time.Second
+)
+
+// The JFrog CLI servers whose logs are served.
+type Servers interface {
+	// Returns the ids of the configured servers.
+	ServerIds() ([]string, error)
+	// Returns the strategy.Http that sends requests to the server of the passed id.
+	HttpStrategy(serverId string) (strategy.Http, error)
+}
+
+// The selection of a server, node or log. Fields that are not selected yet are empty.
+type sourceKey struct {
+	serverId string
+	nodeId   string
+	logName  string
+}
+
+// An error of a selected server, node or log that does not exist.
+type notFoundError struct {
+	what  string
+	value string
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("%s not found [%s]", e.what, e.value)
+}
+
+// An http.Handler serving the web UI at its root path, and the JSON API under /api/servers it is built upon:
+// the server ids, the node ids of a server at <server_id>/nodes, the log names of a node at <server_id>/nodes/<node_id>/logs,
+// and the entries of a log at .../logs/<log_name>/stream, as Server-Sent Events, or the whole log at .../logs/<log_name>/download.
+// Every stream subscribes to the log through a livelog.Hub, so that the streams of the same log share a single poller of the server.
+// Since the logs are read with the credentials of the configured servers, requests of other hosts than localhost,
+// a loopback address or the host of the listen address, and API requests of pages of other origins, are rejected,
+// so that no web page can read the logs through a browser on this machine, including by DNS rebinding.
+type Server struct {
+	servers      Servers
+	noticeOutput io.Writer
+	hub          *livelog.Hub
+	listenHost   string
+
+	mu         sync.Mutex
+	strategies map[string]strategy.Http
+}
+
+func NewServer(servers Servers) *Server {
+	s := &Server{
+		servers:      servers,
+		noticeOutput: ioutil.Discard,
+		strategies:   make(map[string]strategy.Http),
+	}
+	s.hub = livelog.NewHub(func(key livelog.HubKey) (livelog.Client, error) {
+		return s.newLogClient(context.Background(), sourceKey{serverId: key.ServerId, nodeId: key.NodeId, logName: key.LogName})
+	})
+	return s
+}
+
+// Sets the io.Writer that notices, such as a log stream failing, are written into.
+// Notices are discarded by default.
+func (s *Server) SetNoticeOutput(noticeOutput io.Writer) {
+	s.noticeOutput = noticeOutput
+	s.hub.SetNoticeOutput(noticeOutput)
+}
+
+// Sets the address the server listens on, such as forest.example.com:8080, whose host is accepted in the Host header of requests,
+// along with localhost, 127.0.0.1 and ::1, which are always accepted.
+func (s *Server) SetListenAddress(listenAddress string) {
+	s.listenHost = hostOf(listenAddress)
+}
+
+// Stops every poller, closing the streams of its log.
+func (s *Server) Close() {
+	s.hub.Close()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.isAllowedHost(r.Host) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("host not allowed [%s]", r.Host))
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("only GET requests are supported"))
+		return
+	}
+	if r.URL.Path == "/" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, indexHtml)
+		return
+	}
+	key, resource, ok := parseApiPath(r.URL.EscapedPath())
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && !isSameOrigin(origin, r.Host) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("cross-site origin not allowed [%s]", origin))
+		return
+	}
+	switch resource {
+	case "servers":
+		s.serveServers(w)
+	case "nodes":
+		s.serveNodes(w, r, key)
+	case "logs":
+		s.serveLogs(w, r, key)
+	case "stream":
+		s.serveStream(w, r, key)
+	case "download":
+		s.serveDownload(w, r, key)
+	}
+}
+
+func (s *Server) isAllowedHost(hostHeader string) bool {
+	host := hostOf(hostHeader)
+	switch strings.ToLower(host) {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return s.listenHost != "" && strings.EqualFold(host, s.listenHost)
+}
+
+// Returns the host of the passed host and optional port, without the brackets of an IPv6 address.
+func hostOf(hostPort string) string {
+	if host, _, err := net.SplitHostPort(hostPort); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(hostPort, "["), "]")
+}
+
+// Returns whether the passed Origin header is of the page of this server, as reached by the passed Host header.
+func isSameOrigin(origin, hostHeader string) bool {
+	originUrl, err := url.Parse(origin)
+	return err == nil && (originUrl.Scheme == "http" || originUrl.Scheme == "https") && strings.EqualFold(originUrl.Host, hostHeader)
+}
+
+// Parses an API path into the selected source, and the resource requested of it.
+func parseApiPath(escapedPath string) (key sourceKey, resource string, ok bool) {
+	if escapedPath == apiPrefix {
+		return key, "servers", true
+	}
+	if !strings.HasPrefix(escapedPath, apiPrefix+"/") {
+		return
+	}
+	var segments []string
+	for _, segment := range strings.Split(strings.TrimPrefix(escapedPath, apiPrefix+"/"), "/") {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil || unescaped == "" {
+			return
+		}
+		segments = append(segments, unescaped)
+	}
+	switch {
+	case len(segments) == 2 && segments[1] == "nodes":
+		return sourceKey{serverId: segments[0]}, "nodes", true
+	case len(segments) == 4 && segments[1] == "nodes" && segments[3] == "logs":
+		return sourceKey{serverId: segments[0], nodeId: segments[2]}, "logs", true
+	case len(segments) == 6 && segments[1] == "nodes" && segments[3] == "logs" && (segments[5] == "stream" || segments[5] == "download"):
+		return sourceKey{serverId: segments[0], nodeId: segments[2], logName: segments[4]}, segments[5], true
+	}
+	return
+}
+
+func (s *Server) serveServers(w http.ResponseWriter) {
+	serverIds, err := s.servers.ServerIds()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJson(w, serverIds)
+}
+
+func (s *Server) serveNodes(w http.ResponseWriter, r *http.Request, key sourceKey) {
+	client, err := s.newClient(key)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	nodeIds, err := client.GetServiceNodeIds(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	writeJson(w, nodeIds)
+}
+
+func (s *Server) serveLogs(w http.ResponseWriter, r *http.Request, key sourceKey) {
+	client, err := s.newNodeClient(r.Context(), key)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	srvConfig, err := client.GetConfig(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	writeJson(w, srvConfig.LogFileNames)
+}
+
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request, key sourceKey) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+	// validated before subscribing, so that a missing log is responded with its status rather than with a failure event
+	if _, err := s.newLogClient(r.Context(), key); err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	output := newStreamWriter(w, flusher)
+	ctx, cancel := context.WithCancel(r.Context())
+	keepAliveDone := make(chan struct{})
+	go func() {
+		defer close(keepAliveDone)
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-keepAlive.C:
+				if output.keepAlive() != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	defer func() {
+		cancel()
+		<-keepAliveDone
+	}()
+
+	hubKey := livelog.HubKey{ServerId: key.serverId, NodeId: key.nodeId, LogName: key.logName}
+	// a stream that fell behind by the buffer size drops log data, with a notice, rather than holding back the other streams of the log
+	err := s.hub.Subscribe(ctx, hubKey, livelog.SubscriberConfig{TailStart: livelog.TailStart{Lines: tailLines}, NoticeOutput: s.noticeOutput}, output)
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil && ctx.Err() == nil {
+		_, _ = fmt.Fprintf(s.noticeOutput, "- Stopped streaming log %s of node %s: %v\n", key.logName, key.nodeId, err)
+		_ = output.writeFailure(err)
+	}
+}
+
+func (s *Server) serveDownload(w http.ResponseWriter, r *http.Request, key sourceKey) {
+	client, err := s.newLogClient(r.Context(), key)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", key.nodeId+"-"+key.logName))
+	if err = client.CatLog(r.Context(), w); err != nil {
+		// the status was already sent, so the failure can only be noticed
+		_, _ = fmt.Fprintf(s.noticeOutput, "- Failed downloading log %s of node %s: %v\n", key.logName, key.nodeId, err)
+	}
+}
+
+// Returns a client of the selected server, which must exist.
+func (s *Server) newClient(key sourceKey) (livelog.Client, error) {
+	serverIds, err := s.servers.ServerIds()
+	if err != nil {
+		return nil, err
+	}
+	if !util.InSlice(serverIds, key.serverId) {
+		return nil, &notFoundError{what: "server id", value: key.serverId}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	httpStrategy, ok := s.strategies[key.serverId]
+	if !ok {
+		if httpStrategy, err = s.servers.HttpStrategy(key.serverId); err != nil {
+			return nil, err
+		}
+		s.strategies[key.serverId] = httpStrategy
+	}
+	return livelog.NewClient(httpStrategy), nil
+}
+
+// Returns a client of the selected node, which must exist.
+func (s *Server) newNodeClient(ctx context.Context, key sourceKey) (livelog.Client, error) {
+	client, err := s.newClient(key)
+	if err != nil {
+		return nil, err
+	}
+	nodeIds, err := client.GetServiceNodeIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !util.InSlice(nodeIds, key.nodeId) {
+		return nil, &notFoundError{what: "node id", value: key.nodeId}
+	}
+	client.SetNodeId(key.nodeId)
+	return client, nil
+}
+
+// Returns a client of the selected log, which must exist, refreshing at the rate of the node's config.
+func (s *Server) newLogClient(ctx context.Context, key sourceKey) (livelog.Client, error) {
+	client, err := s.newNodeClient(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	srvConfig, err := client.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !util.InSlice(srvConfig.LogFileNames, key.logName) {
+		return nil, &notFoundError{what: "log name", value: key.logName}
+	}
+	client.SetLogFileName(key.logName)
+	client.SetLogsRefreshRate(util.MillisToDuration(srvConfig.RefreshRateMillis))
+	return client, nil
+}
+
+func writeJson(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// Writes an error of a client, which is either a selection that does not exist, or a failed request to the remote service.
+func writeClientError(w http.ResponseWriter, err error) {
+	var notFound *notFoundError
+	if errors.As(err, &notFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeError(w, http.StatusBadGateway, err)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,220 @@
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/hanoch-jfrog/forest/client/livelog/fakeserver"
+	"github.com/hanoch-jfrog/forest/client/livelog/strategy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type testServers struct {
+	httpStrategy strategy.Http
+}
+
+func (s *testServers) ServerIds() ([]string, error) {
+	return []string{"local-arti"}, nil
+}
+
+func (s *testServers) HttpStrategy(string) (strategy.Http, error) {
+	return s.httpStrategy, nil
+}
+
+// Starts a web Server of a fake server that holds console.log and request.log of node-1.
+func startServer(t *testing.T) (*fakeserver.Server, *Server, *httptest.Server, func()) {
+	logsDir, err := ioutil.TempDir("", "web")
+	require.NoError(t, err)
+	fakeServer := fakeserver.New(fakeserver.Config{LogsDir: logsDir, RefreshRateMillis: 20})
+	require.NoError(t, fakeServer.AppendLog("node-1", "console.log", "2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first\n"))
+	require.NoError(t, fakeServer.AppendLog("node-1", "request.log", "2020-12-06T19:21:52Z|request\n"))
+	fakeHttpServer := httptest.NewServer(fakeServer)
+	httpStrategy, err := strategy.NewStandaloneHttpStrategy(strategy.StandaloneHttpConfig{BaseUrl: fakeHttpServer.URL + "/artifactory/"})
+	require.NoError(t, err)
+
+	server := NewServer(&testServers{httpStrategy: httpStrategy})
+	httpServer := httptest.NewServer(server)
+	return fakeServer, server, httpServer, func() {
+		httpServer.Close()
+		server.Close()
+		fakeHttpServer.Close()
+		_ = os.RemoveAll(logsDir)
+	}
+}
+
+func getJson(t *testing.T, url string, value interface{}) int {
+	res, err := http.Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.NoError(t, json.NewDecoder(res.Body).Decode(value))
+	return res.StatusCode
+}
+
+func TestServer_api(t *testing.T) {
+	_, _, httpServer, stop := startServer(t)
+	defer stop()
+
+	var values []string
+	assert.Equal(t, http.StatusOK, getJson(t, httpServer.URL+"/api/servers", &values))
+	assert.Equal(t, []string{"local-arti"}, values)
+	assert.Equal(t, http.StatusOK, getJson(t, httpServer.URL+"/api/servers/local-arti/nodes", &values))
+	assert.Equal(t, []string{"node-1"}, values)
+	assert.Equal(t, http.StatusOK, getJson(t, httpServer.URL+"/api/servers/local-arti/nodes/node-1/logs", &values))
+	assert.Equal(t, []string{"console.log", "request.log"}, values)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantError  string
+	}{
+		{"unknown server", "/api/servers/remote-arti/nodes", http.StatusNotFound, "server id not found [remote-arti]"},
+		{"unknown node", "/api/servers/local-arti/nodes/node-2/logs", http.StatusNotFound, "node id not found [node-2]"},
+		{"unknown log", "/api/servers/local-arti/nodes/node-1/logs/missing.log/stream", http.StatusNotFound, "log name not found [missing.log]"},
+		{"unknown resource", "/api/servers/local-arti/users", http.StatusNotFound, "not found"},
+		{"empty segment", "/api/servers//nodes", http.StatusNotFound, "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]string{}
+			assert.Equal(t, tt.wantStatus, getJson(t, httpServer.URL+tt.path, &body))
+			assert.Equal(t, tt.wantError, body["error"])
+		})
+	}
+
+	res, err := http.Post(httpServer.URL+"/api/servers", "application/json", nil)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	res, err = http.Get(httpServer.URL + "/")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	index, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "<title>Forest</title>")
+}
+
+func TestServer_hostAndOrigin(t *testing.T) {
+	_, server, httpServer, stop := startServer(t)
+	defer stop()
+	server.SetListenAddress("forest.example.com:8080")
+
+	tests := []struct {
+		name       string
+		path       string
+		host       string
+		origin     string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "loopback address", path: "/api/servers", wantStatus: http.StatusOK},
+		{name: "localhost", path: "/api/servers", host: "localhost:8080", wantStatus: http.StatusOK},
+		{name: "ipv6 loopback address", path: "/api/servers", host: "[::1]:8080", wantStatus: http.StatusOK},
+		{name: "listen address", path: "/api/servers", host: "forest.example.com:8080", wantStatus: http.StatusOK},
+		{name: "other host", path: "/api/servers", host: "attacker.example.com:8080", wantStatus: http.StatusForbidden,
+			wantError: "host not allowed [attacker.example.com:8080]"},
+		{name: "other host of the web UI", path: "/", host: "attacker.example.com", wantStatus: http.StatusForbidden,
+			wantError: "host not allowed [attacker.example.com]"},
+		{name: "same origin", path: "/api/servers", host: "localhost:8080", origin: "http://localhost:8080", wantStatus: http.StatusOK},
+		{name: "cross-site origin", path: "/api/servers", host: "localhost:8080", origin: "http://attacker.example.com", wantStatus: http.StatusForbidden,
+			wantError: "cross-site origin not allowed [http://attacker.example.com]"},
+		{name: "null origin", path: "/api/servers/local-arti/nodes", host: "localhost:8080", origin: "null", wantStatus: http.StatusForbidden,
+			wantError: "cross-site origin not allowed [null]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, httpServer.URL+tt.path, nil)
+			require.NoError(t, err)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantError != "" {
+				body := map[string]string{}
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+				assert.Equal(t, tt.wantError, body["error"])
+			}
+		})
+	}
+}
+
+func TestServer_download(t *testing.T) {
+	_, _, httpServer, stop := startServer(t)
+	defer stop()
+
+	res, err := http.Get(httpServer.URL + "/api/servers/local-arti/nodes/node-1/logs/console.log/download")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	content, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first\n", string(content))
+	assert.Equal(t, `attachment; filename="node-1-console.log"`, res.Header.Get("Content-Disposition"))
+}
+
+// The events read from a stream, with the id and data of each.
+type streamReader struct {
+	res     *http.Response
+	scanner *bufio.Scanner
+}
+
+func openStream(t *testing.T, url string) *streamReader {
+	res, err := http.Get(url)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	return &streamReader{res: res, scanner: bufio.NewScanner(res.Body)}
+}
+
+// Reads the next entry event.
+func (r *streamReader) next(t *testing.T) streamEntry {
+	for r.scanner.Scan() {
+		if line := r.scanner.Text(); strings.HasPrefix(line, "data: ") {
+			var entry streamEntry
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &entry))
+			return entry
+		}
+	}
+	require.Fail(t, "the stream ended", "%v", r.scanner.Err())
+	return streamEntry{}
+}
+
+func (r *streamReader) close() {
+	_ = r.res.Body.Close()
+}
+
+func TestServer_stream(t *testing.T) {
+	fakeServer, _, httpServer, stop := startServer(t)
+	defer stop()
+	streamUrl := httpServer.URL + "/api/servers/local-arti/nodes/node-1/logs/console.log/stream"
+	firstEntry := streamEntry{Text: "2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first", Level: "INFO"}
+	secondEntry := streamEntry{Text: "2020-12-06T19:21:53.000Z [jfrt ] [ERROR] - second\n\tat stack", Level: "ERROR"}
+
+	first := openStream(t, streamUrl)
+	defer first.close()
+	assert.Equal(t, firstEntry, first.next(t))
+	require.NoError(t, fakeServer.AppendLog("node-1", "console.log", "2020-12-06T19:21:53.000Z [jfrt ] [ERROR] - second\n\tat stack\n"))
+	assert.Equal(t, secondEntry, first.next(t))
+
+	// a later stream of the same log, such as a reconnecting one, starts with the latest lines the poller holds
+	second := openStream(t, streamUrl)
+	defer second.close()
+	assert.Equal(t, firstEntry, second.next(t))
+	assert.Equal(t, secondEntry, second.next(t))
+
+	require.NoError(t, fakeServer.AppendLog("node-1", "console.log", "2020-12-06T19:21:54.000Z [jfrt ] [INFO ] - third\n"))
+	assert.Equal(t, "2020-12-06T19:21:54.000Z [jfrt ] [INFO ] - third", first.next(t).Text)
+	assert.Equal(t, "2020-12-06T19:21:54.000Z [jfrt ] [INFO ] - third", second.next(t).Text)
+}
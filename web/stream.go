@@ -0,0 +1,101 @@
+package web
+
+import (
+	"encoding/json"
+	"github.com/hanoch-jfrog/forest/parser"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// The number of the latest lines a stream starts with.
+const tailLines = 1000
+
+// An event of a log stream: either an entry, or the failure the stream stopped with.
+type event struct {
+	name string
+	data []byte
+}
+
+type streamEntry struct {
+	Text  string `json:"text"`
+	Level string `json:"level"`
+}
+
+// An io.WriteCloser that writes the entries of the log data written into it as Server-Sent Events, flushing them once per write,
+// so that the log data of a poll is sent in a single flush. It is safe for concurrent use, so that keep-alives can be sent while streaming.
+type streamWriter struct {
+	mu          sync.Mutex
+	output      io.Writer
+	flusher     http.Flusher
+	entryWriter *parser.EntryWriter
+}
+
+func newStreamWriter(output io.Writer, flusher http.Flusher) *streamWriter {
+	w := &streamWriter{
+		output:  output,
+		flusher: flusher,
+	}
+	w.entryWriter = parser.NewEntryWriter(w.writeEntry)
+	return w
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.entryWriter.Write(p)
+	w.flusher.Flush()
+	return n, err
+}
+
+// Writes the remaining partial line, if any, as an entry.
+func (w *streamWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.entryWriter.Close()
+	w.flusher.Flush()
+	return err
+}
+
+// Writes a comment, so that proxies do not close an idle stream.
+func (w *streamWriter) keepAlive() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := io.WriteString(w.output, ": keep-alive\n\n"); err != nil {
+		return err
+	}
+	w.flusher.Flush()
+	return nil
+}
+
+// Writes the failure the stream stopped with.
+func (w *streamWriter) writeFailure(failure error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := writeEvent(w.output, event{name: "failure", data: []byte(failure.Error())}); err != nil {
+		return err
+	}
+	w.flusher.Flush()
+	return nil
+}
+
+// NOTE: the caller must hold the lock.
+func (w *streamWriter) writeEntry(logLine *parser.LogLine) error {
+	// marshalling a string and a level name cannot fail
+	data, _ := json.Marshal(streamEntry{Text: logLine.Raw, Level: logLine.Level.String()})
+	return writeEvent(w.output, event{data: data})
+}
+
+func writeEvent(w io.Writer, e event) error {
+	var message strings.Builder
+	if e.name != "" {
+		message.WriteString("event: " + e.name + "\n")
+	}
+	for _, line := range strings.Split(string(e.data), "\n") {
+		message.WriteString("data: " + line + "\n")
+	}
+	message.WriteString("\n")
+	_, err := io.WriteString(w, message.String())
+	return err
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_streamWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		writes  []string
+		failure error
+		want    string
+	}{
+		{
+			name:   "entries",
+			writes: []string{"2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first\n2020-12-06T19:21:53.000Z [jfrt ] [ERROR] - second\n\tat stack\n"},
+			want: `data: {"text":"2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first","level":"INFO"}` + "\n\n" +
+				`data: {"text":"2020-12-06T19:21:53.000Z [jfrt ] [ERROR] - second\n\tat stack","level":"ERROR"}` + "\n\n",
+		},
+		{
+			name:   "line split over writes",
+			writes: []string{"2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - fi", "rst\n"},
+			want:   `data: {"text":"2020-12-06T19:21:52.549Z [jfrt ] [INFO ] - first","level":"INFO"}` + "\n\n",
+		},
+		{
+			name:   "partial line written once closed",
+			writes: []string{"unparsed"},
+			want:   `data: {"text":"unparsed","level":""}` + "\n\n",
+		},
+		{
+			name:    "failure",
+			failure: errors.New("the log ended\nunexpectedly"),
+			want:    "event: failure\ndata: the log ended\ndata: unexpectedly\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			output := newStreamWriter(recorder, recorder)
+			for _, content := range tt.writes {
+				_, err := output.Write([]byte(content))
+				require.NoError(t, err)
+				assert.True(t, recorder.Flushed)
+			}
+			require.NoError(t, output.Close())
+			if tt.failure != nil {
+				require.NoError(t, output.writeFailure(tt.failure))
+			}
+			assert.Equal(t, tt.want, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package web
+
+// The single-page web UI, served at the root path.
+// It keeps up to maxEntries entries of the selected log, filtering and highlighting them in the browser.
+const indexHtml = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Forest</title>
+<style>
+  body { margin: 0; font-family: sans-serif; display: flex; flex-direction: column; height: 100vh; }
+  header { display: flex; flex-wrap: wrap; gap: 8px; align-items: center; padding: 8px; background: #2d3e50; color: #fff; }
+  header label { font-size: 13px; }
+  header input[type=text] { width: 160px; }
+  #status { margin-left: auto; font-size: 13px; }
+  #log { flex: 1; overflow: auto; margin: 0; padding: 8px; font: 12px monospace; background: #fafafa; }
+  .entry { white-space: pre-wrap; word-break: break-all; }
+  .entry.hidden { display: none; }
+  .WARN { color: #b36b00; }
+  .ERROR, .FATAL { color: #c62828; }
+  mark { background: #ffeb3b; }
+  .failure { color: #c62828; font-weight: bold; }
+</style>
+</head>
+<body>
+<header>
+  <select id="server"></select>
+  <select id="node"></select>
+  <select id="log-name"></select>
+  <label>Filter <input type="text" id="filter" placeholder="text"></label>
+  <label>Level <select id="level">
+    <option value="0">all</option><option value="1">TRACE+</option><option value="2">DEBUG+</option>
+    <option value="3">INFO+</option><option value="4">WARN+</option><option value="5">ERROR+</option>
+  </select></label>
+  <label>Highlight <input type="text" id="highlight" placeholder="text"></label>
+  <label><input type="checkbox" id="follow" checked> Follow</label>
+  <button id="clear">Clear</button>
+  <a id="download" href="#" download><button>Download</button></a>
+  <span id="status"></span>
+</header>
+<pre id="log"></pre>
+<script>
+(function () {
+  var maxEntries = 20000;
+  var levels = {"TRACE": 1, "DEBUG": 2, "INFO": 3, "WARN": 4, "ERROR": 5, "FATAL": 6};
+  var $ = function (id) { return document.getElementById(id); };
+  var logElement = $("log");
+  var source = null;
+
+  function apiPath() {
+    var path = "/api/servers";
+    for (var i = 0; i < arguments.length; i++) {
+      path += "/" + encodeURIComponent(arguments[i]);
+    }
+    return path;
+  }
+
+  function setStatus(text, failed) {
+    $("status").textContent = text;
+    $("status").className = failed ? "failure" : "";
+  }
+
+  function fill(select, path, onFilled) {
+    select.innerHTML = "";
+    fetch(path).then(function (res) {
+      return res.json().then(function (body) {
+        if (!res.ok) { throw new Error(body.error); }
+        return body;
+      });
+    }).then(function (values) {
+      (values || []).forEach(function (value) {
+        var option = document.createElement("option");
+        option.value = option.textContent = value;
+        select.appendChild(option);
+      });
+      onFilled();
+    }).catch(function (err) { setStatus(err.message, true); });
+  }
+
+  function escapeHtml(text) {
+    return text.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+  }
+
+  function render(element) {
+    var text = element.dataset.text, highlight = $("highlight").value;
+    if (!highlight) {
+      element.textContent = text;
+      return;
+    }
+    var lower = text.toLowerCase(), query = highlight.toLowerCase(), html = "", from = 0, at;
+    while ((at = lower.indexOf(query, from)) >= 0) {
+      html += escapeHtml(text.substring(from, at)) + "<mark>" + escapeHtml(text.substr(at, query.length)) + "</mark>";
+      from = at + query.length;
+    }
+    element.innerHTML = html + escapeHtml(text.substring(from));
+  }
+
+  function applyFilter(element) {
+    var filter = $("filter").value.toLowerCase(), minLevel = Number($("level").value);
+    var visible = (levels[element.dataset.level] || 0) >= minLevel &&
+      element.dataset.text.toLowerCase().indexOf(filter) >= 0;
+    element.classList.toggle("hidden", !visible);
+  }
+
+  function addEntry(entry) {
+    var element = document.createElement("div");
+    element.className = "entry " + entry.level;
+    element.dataset.text = entry.text;
+    element.dataset.level = entry.level;
+    render(element);
+    applyFilter(element);
+    logElement.appendChild(element);
+    while (logElement.childElementCount > maxEntries) {
+      logElement.removeChild(logElement.firstElementChild);
+    }
+  }
+
+  function stream() {
+    if (source) { source.close(); }
+    logElement.innerHTML = "";
+    var server = $("server").value, node = $("node").value, logName = $("log-name").value;
+    if (!server || !node || !logName) { return; }
+    $("download").href = apiPath(server, "nodes", node, "logs", logName, "download");
+    source = new EventSource(apiPath(server, "nodes", node, "logs", logName, "stream"));
+    // a reconnected stream starts again with the latest lines, so the entries received before are replaced
+    source.onopen = function () {
+      logElement.innerHTML = "";
+      setStatus("Streaming " + logName + " of " + node);
+    };
+    source.onerror = function () { setStatus("Reconnecting...", true); };
+    source.onmessage = function (message) {
+      addEntry(JSON.parse(message.data));
+      if ($("follow").checked) { logElement.scrollTop = logElement.scrollHeight; }
+    };
+    source.addEventListener("failure", function (message) {
+      source.close();
+      setStatus("Streaming stopped: " + message.data, true);
+    });
+  }
+
+  function forEachEntry(action) {
+    Array.prototype.forEach.call(logElement.children, action);
+  }
+
+  $("server").onchange = function () {
+    fill($("node"), apiPath($("server").value, "nodes"), $("node").onchange);
+  };
+  $("node").onchange = function () {
+    fill($("log-name"), apiPath($("server").value, "nodes", $("node").value, "logs"), stream);
+  };
+  $("log-name").onchange = stream;
+  $("filter").oninput = $("level").onchange = function () { forEachEntry(applyFilter); };
+  $("highlight").oninput = function () { forEachEntry(render); };
+  $("clear").onclick = function () { logElement.innerHTML = ""; };
+  fill($("server"), apiPath(), $("server").onchange);
+})();
+</script>
+</body>
+</html>
+`